@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"os"
@@ -8,13 +9,29 @@ import (
 	"strings"
 
 	"github.com/Niceblueman/cwmp-codegen/internal/generator"
+	"github.com/Niceblueman/cwmp-codegen/internal/models"
 	"github.com/Niceblueman/cwmp-codegen/internal/parser"
 )
 
 func main() {
+	// Subcommands have their own flags
+	if len(os.Args) > 1 && os.Args[1] == "simulate" {
+		runSimulate(os.Args[2:])
+		return
+	}
+
 	// Define command-line flags
 	inputFile := flag.String("input", "", "Path to the XML model file (required)")
 	outputDir := flag.String("output", "./output", "Directory for generated files")
+	lang := flag.String("lang", "golang", "Output language: golang, typescript, cheader, rust, python, proto, jsonschema, openapi, graphql, kotlin or java")
+	tsPackage := flag.Bool("ts-package", false, "Generate TypeScript as an ES module package with one module per top-level object")
+	tsPackageName := flag.String("ts-package-name", "", "npm package name for -ts-package (derived from the model name by default)")
+	tsDeclarations := flag.Bool("ts-declarations", false, "Generate a .d.ts-only TypeScript package (implies -ts-package)")
+	protoPackage := flag.String("proto-package", "", "proto package name (derived from the model name by default)")
+	jvmPackage := flag.String("jvm-package", "", "Kotlin or Java package name (derived from the model name by default)")
+	protoGoPackage := flag.String("proto-go-package", "", "go_package option of the generated .proto file")
+	modelName := flag.String("model", "", "Name of the model to generate, such as InternetGatewayDevice:1.0 (all models of the document by default, each in its own subdirectory)")
+	usp := flag.Bool("usp", false, "Also generate USP (TR-369) command and event arguments, supported data model metadata and message bindings with -lang golang")
 
 	// Parse flags
 	flag.Parse()
@@ -39,19 +56,64 @@ func main() {
 
 	// Parse the XML file
 	fmt.Println("Parsing XML model:", *inputFile)
-	model, err := parser.ParseXML(*inputFile)
+	document, err := parser.ParseDocument(*inputFile)
 	if err != nil {
 		fmt.Printf("Error parsing XML: %v\n", err)
 		os.Exit(1)
 	}
-
-	// Generate Golang code
-	fmt.Println("Generating Golang code...")
-	outputFiles, err := generator.GenerateGolang(model, *outputDir)
+	selected, err := selectModels(document, *modelName)
 	if err != nil {
-		fmt.Printf("Error generating code: %v\n", err)
+		fmt.Printf("Error: %v\n", err)
 		os.Exit(1)
 	}
+	if len(selected) > 1 {
+		// Package names are derived per model unless given, and a given
+		// name would be shared by every model
+		if name := packageFlagSet(); name != "" {
+			fmt.Printf("Error: -%s names the package of a single model; select it with -model\n", name)
+			os.Exit(1)
+		}
+	}
+
+	// Generate code for the requested language, in a subdirectory per model
+	// when the document has several
+	var outputFiles []string
+	for _, model := range selected {
+		dir := *outputDir
+		prefix := ""
+		if len(selected) > 1 {
+			prefix = modelDir(model.Name)
+			dir = filepath.Join(*outputDir, prefix)
+			fmt.Println("Model:", model.Name)
+			if *lang == "proto" || *lang == "protobuf" {
+				if err := moveProtoLock(*outputDir, dir, model); err != nil {
+					fmt.Printf("Error moving the proto lock file: %v\n", err)
+					os.Exit(1)
+				}
+			}
+		}
+		files, err := generate(*lang, model, dir, options{
+			tsPackage:      *tsPackage,
+			tsPackageName:  *tsPackageName,
+			tsDeclarations: *tsDeclarations,
+			protoPackage:   *protoPackage,
+			protoGoPackage: *protoGoPackage,
+			jvmPackage:     *jvmPackage,
+			usp:            *usp,
+		})
+		if errors.Is(err, errUnsupportedLanguage) {
+			fmt.Printf("Error: unsupported language %q\n", *lang)
+			flag.Usage()
+			os.Exit(1)
+		}
+		if err != nil {
+			fmt.Printf("Error generating code: %v\n", err)
+			os.Exit(1)
+		}
+		for _, file := range files {
+			outputFiles = append(outputFiles, filepath.Join(prefix, file))
+		}
+	}
 
 	// Report success
 	fmt.Println("Code generation completed successfully!")
@@ -60,3 +122,135 @@ func main() {
 		fmt.Println("-", filepath.Join(*outputDir, file))
 	}
 }
+
+// errUnsupportedLanguage is returned by generate for an unknown -lang
+var errUnsupportedLanguage = errors.New("unsupported language")
+
+// options holds the generator flags
+type options struct {
+	tsPackage      bool
+	tsPackageName  string
+	tsDeclarations bool
+	protoPackage   string
+	protoGoPackage string
+	jvmPackage     string
+	usp            bool
+}
+
+// generate generates code for a model in a language
+func generate(lang string, model *models.DataModel, outputDir string, opts options) ([]string, error) {
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return nil, err
+	}
+	switch lang {
+	case "golang", "go":
+		fmt.Println("Generating Golang code...")
+		if opts.usp {
+			return generator.GenerateGolangUSP(model, outputDir)
+		}
+		return generator.GenerateGolang(model, outputDir)
+	case "typescript", "ts":
+		fmt.Println("Generating TypeScript code...")
+		if opts.tsPackage || opts.tsDeclarations {
+			return generator.GenerateTypeScriptPackage(model, outputDir, generator.TypeScriptOptions{
+				PackageName:      opts.tsPackageName,
+				DeclarationsOnly: opts.tsDeclarations,
+			})
+		}
+		return generator.GenerateTypeScript(model, outputDir)
+	case "cheader", "c":
+		fmt.Println("Generating C header...")
+		return generator.GenerateCHeader(model, outputDir)
+	case "rust", "rs":
+		fmt.Println("Generating Rust code...")
+		return generator.GenerateRust(model, outputDir)
+	case "python", "py":
+		fmt.Println("Generating Python package...")
+		return generator.GeneratePython(model, outputDir)
+	case "proto", "protobuf":
+		fmt.Println("Generating Protocol Buffers schema...")
+		return generator.GenerateProto(model, outputDir, generator.ProtoOptions{
+			Package:   opts.protoPackage,
+			GoPackage: opts.protoGoPackage,
+		})
+	case "jsonschema", "json-schema":
+		fmt.Println("Generating JSON Schema...")
+		return generator.GenerateJSONSchema(model, outputDir)
+	case "openapi":
+		fmt.Println("Generating OpenAPI document...")
+		return generator.GenerateOpenAPI(model, outputDir)
+	case "graphql", "gql":
+		fmt.Println("Generating GraphQL schema...")
+		return generator.GenerateGraphQL(model, outputDir)
+	case "kotlin", "kt":
+		fmt.Println("Generating Kotlin code...")
+		return generator.GenerateKotlin(model, outputDir, generator.KotlinOptions{Package: opts.jvmPackage})
+	case "java":
+		fmt.Println("Generating Java records...")
+		return generator.GenerateJava(model, outputDir, generator.KotlinOptions{Package: opts.jvmPackage})
+	default:
+		return nil, errUnsupportedLanguage
+	}
+}
+
+// packageFlags are the flags naming the generated package of one model
+var packageFlags = []string{"proto-package", "proto-go-package", "jvm-package", "ts-package-name"}
+
+// packageFlagSet returns the name of the first package flag given on the
+// command line, or an empty string
+func packageFlagSet() string {
+	set := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	for _, name := range packageFlags {
+		if set[name] {
+			return name
+		}
+	}
+	return ""
+}
+
+// moveProtoLock moves the lock file of a model written to the output
+// directory by a single-model run into the model's subdirectory, where
+// runs over several models look for it, so field numbers stay stable
+func moveProtoLock(outputDir, dir string, model *models.DataModel) error {
+	name := generator.ProtoLockFile(model)
+	from, to := filepath.Join(outputDir, name), filepath.Join(dir, name)
+	if _, err := os.Stat(from); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if _, err := os.Stat(to); err == nil {
+		fmt.Printf("Warning: ignoring %s, %s is used instead\n", from, to)
+		return nil
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	fmt.Printf("Moving %s to %s\n", from, to)
+	return os.Rename(from, to)
+}
+
+// selectModels returns the model of the document with a name, or all of its
+// models when the name is empty
+func selectModels(document *models.Document, name string) ([]*models.DataModel, error) {
+	if name != "" {
+		model, ok := document.Model(name)
+		if !ok {
+			return nil, fmt.Errorf("model %s not found in the document", name)
+		}
+		return []*models.DataModel{model}, nil
+	}
+	if len(document.Models) == 0 {
+		return nil, errors.New("no models found in the document")
+	}
+	selected := make([]*models.DataModel, len(document.Models))
+	for i := range document.Models {
+		selected[i] = &document.Models[i]
+	}
+	return selected, nil
+}
+
+// modelDir returns the output subdirectory of a model, such as
+// InternetGatewayDevice_1_0 for InternetGatewayDevice:1.0
+func modelDir(name string) string {
+	return strings.NewReplacer(":", "_", ".", "_", "/", "_").Replace(name)
+}
@@ -4,6 +4,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -73,8 +74,9 @@ func TestMainCLI(t *testing.T) {
 		t.Errorf("C header generation failed: %v\n%s", err, output)
 	}
 
-	// Verify output files exist
-	goFile := filepath.Join(goOutDir, "testintegration.go")
+	// Verify output files exist. Go files are named after objects, the
+	// TypeScript module and C header after the model.
+	goFile := filepath.Join(goOutDir, "IntegrationTest.go")
 	tsFile := filepath.Join(tsOutDir, "TestIntegration.ts")
 	cFile := filepath.Join(cOutDir, "TestIntegration.h")
 
@@ -88,3 +90,108 @@ func TestMainCLI(t *testing.T) {
 		}
 	}
 }
+
+func TestMainCLIModels(t *testing.T) {
+	// Skip if short tests requested
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	tmpDir := t.TempDir()
+	binPath := filepath.Join(tmpDir, "cwmp-codegen-test")
+
+	buildCmd := exec.Command("go", "build", "-o", binPath)
+	if output, err := buildCmd.CombinedOutput(); err != nil {
+		t.Fatalf("Failed to build test binary: %v\n%s", err, output)
+	}
+
+	// Create a document with two models
+	testFile := filepath.Join(tmpDir, "models.xml")
+	xmlContent := `<?xml version="1.0" encoding="UTF-8"?>
+<document>
+  <model name="InternetGatewayDevice:1.0">
+    <object name="InternetGatewayDevice.DeviceInfo." access="readOnly" minEntries="1" maxEntries="1">
+      <parameter name="Manufacturer" access="readOnly"><syntax><string/></syntax></parameter>
+    </object>
+  </model>
+  <model name="Device:1.0">
+    <object name="Device.LAN." access="readOnly" minEntries="1" maxEntries="1">
+      <parameter name="MACAddress" access="readOnly"><syntax><string/></syntax></parameter>
+    </object>
+  </model>
+</document>`
+	if err := os.WriteFile(testFile, []byte(xmlContent), 0644); err != nil {
+		t.Fatalf("Failed to write test XML file: %v", err)
+	}
+
+	// Generate a single model by name
+	selectedDir := filepath.Join(tmpDir, "selected")
+	cmd := exec.Command(binPath, "-input", testFile, "-lang", "golang", "-model", "InternetGatewayDevice:1.0", "-output", selectedDir)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("Generating a selected model failed: %v\n%s", err, output)
+	}
+	if _, err := os.Stat(filepath.Join(selectedDir, "InternetGatewayDevice_DeviceInfo.go")); err != nil {
+		t.Errorf("Expected the selected model in the output directory: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(selectedDir, "Device_LAN.go")); err == nil {
+		t.Error("Expected the other model not to be generated")
+	}
+
+	// Generate all models, one subdirectory each
+	allDir := filepath.Join(tmpDir, "all")
+	cmd = exec.Command(binPath, "-input", testFile, "-lang", "golang", "-output", allDir)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("Generating all models failed: %v\n%s", err, output)
+	}
+	for _, file := range []string{
+		filepath.Join("InternetGatewayDevice_1_0", "InternetGatewayDevice_DeviceInfo.go"),
+		filepath.Join("Device_1_0", "Device_LAN.go"),
+	} {
+		if _, err := os.Stat(filepath.Join(allDir, file)); err != nil {
+			t.Errorf("Expected %s to be generated: %v", file, err)
+		}
+	}
+
+	// Package names would be shared by every model
+	cmd = exec.Command(binPath, "-input", testFile, "-lang", "proto", "-proto-package", "acme.tr069", "-output", filepath.Join(tmpDir, "shared"))
+	output, err := cmd.CombinedOutput()
+	if err == nil || !strings.Contains(string(output), "-proto-package names the package of a single model") {
+		t.Errorf("Expected a package flag with several models to fail, got %v\n%s", err, output)
+	}
+
+	// The lock file of a single-model run moves into the model's subdirectory
+	protoDir := filepath.Join(tmpDir, "proto")
+	cmd = exec.Command(binPath, "-input", testFile, "-lang", "proto", "-model", "Device:1.0", "-output", protoDir)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("Generating a selected proto model failed: %v\n%s", err, output)
+	}
+	lock, err := os.ReadFile(filepath.Join(protoDir, "device_1_0.proto.lock"))
+	if err != nil {
+		t.Fatalf("Expected a lock file for the selected model: %v", err)
+	}
+	cmd = exec.Command(binPath, "-input", testFile, "-lang", "proto", "-output", protoDir)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("Generating all proto models failed: %v\n%s", err, output)
+	}
+	if _, err := os.Stat(filepath.Join(protoDir, "device_1_0.proto.lock")); err == nil {
+		t.Error("Expected the lock file to leave the output directory")
+	}
+	moved, err := os.ReadFile(filepath.Join(protoDir, "Device_1_0", "device_1_0.proto.lock"))
+	if err != nil || string(moved) != string(lock) {
+		t.Errorf("Expected the lock file in Device_1_0 unchanged, got %v\n%s", err, moved)
+	}
+
+	// Simulating needs a single model
+	cmd = exec.Command(binPath, "simulate", "-input", testFile, "-acs", "http://127.0.0.1:1/")
+	output, err = cmd.CombinedOutput()
+	if err == nil || !strings.Contains(string(output), "the document has 2 models; select one with -model") {
+		t.Errorf("Expected simulating several models to fail, got %v\n%s", err, output)
+	}
+
+	// Unknown models are reported
+	cmd = exec.Command(binPath, "-input", testFile, "-model", "Device:2.0", "-output", filepath.Join(tmpDir, "missing"))
+	output, err = cmd.CombinedOutput()
+	if err == nil || !strings.Contains(string(output), "model Device:2.0 not found") {
+		t.Errorf("Expected an unknown model to fail, got %v\n%s", err, output)
+	}
+}
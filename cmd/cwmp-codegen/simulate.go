@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/Niceblueman/cwmp-codegen/internal/parser"
+	"github.com/Niceblueman/cwmp-codegen/internal/simulator"
+)
+
+// runSimulate runs the simulate command, which simulates devices of a data
+// model against an ACS until interrupted
+func runSimulate(args []string) {
+	flags := flag.NewFlagSet("simulate", flag.ExitOnError)
+	inputFile := flags.String("input", "", "Path to the XML model file (required)")
+	modelName := flags.String("model", "", "Name of the model to simulate, such as InternetGatewayDevice:1.0 (required when the document has several models)")
+	acsURL := flags.String("acs", "", "URL of the ACS to inform (required)")
+	devices := flags.Int("devices", 1, "Number of devices to simulate")
+	periodic := flags.Duration("periodic", 5*time.Minute, "Interval of PERIODIC informs, 0 to disable them")
+	retry := flags.Duration("retry", 10*time.Second, "Delay before retrying a failed session")
+	serialPrefix := flags.String("serial-prefix", "SIM", "Prefix of the device serial numbers")
+	manufacturer := flags.String("manufacturer", "cwmp-codegen", "Manufacturer reported by the devices")
+	oui := flags.String("oui", "000000", "OUI reported by the devices")
+	productClass := flags.String("product-class", "Simulator", "ProductClass reported by the devices")
+	flags.Parse(args)
+
+	if *inputFile == "" || *acsURL == "" {
+		fmt.Println("Error: input file and ACS URL are required")
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	fmt.Println("Parsing XML model:", *inputFile)
+	document, err := parser.ParseDocument(*inputFile)
+	if err != nil {
+		fmt.Printf("Error parsing XML: %v\n", err)
+		os.Exit(1)
+	}
+	selected, err := selectModels(document, *modelName)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if len(selected) > 1 {
+		fmt.Printf("Error: the document has %d models; select one with -model\n", len(selected))
+		os.Exit(1)
+	}
+	model := selected[0]
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	fmt.Printf("Simulating %d device(s) against %s, press Ctrl+C to stop\n", *devices, *acsURL)
+	err = simulator.Run(ctx, model, simulator.Config{
+		ACSURL:           *acsURL,
+		Devices:          *devices,
+		PeriodicInterval: *periodic,
+		RetryInterval:    *retry,
+		Manufacturer:     *manufacturer,
+		OUI:              *oui,
+		ProductClass:     *productClass,
+		SerialPrefix:     *serialPrefix,
+		Logger:           log.New(os.Stdout, "", log.LstdFlags),
+	})
+	if err != nil {
+		fmt.Printf("Error simulating devices: %v\n", err)
+		os.Exit(1)
+	}
+}
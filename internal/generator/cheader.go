@@ -1,8 +1,9 @@
 package generator
 
 import (
-	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"text/template"
 
@@ -14,28 +15,78 @@ const cHeaderTemplate = `/* Code generated by cwmp-codegen. DO NOT EDIT. */
 #ifndef {{.GuardMacro}}
 #define {{.GuardMacro}}
 
+#include <stddef.h>
+#include <stdint.h>
+#include <stdbool.h>
+
 #ifdef __cplusplus
 extern "C" {
 #endif
 
-#include <stdint.h>
-#include <stdbool.h>
+/* Buffer size, including the terminator, of strings without a maximum length */
+#ifndef {{.Prefix}}_STRING_SIZE
+#define {{.Prefix}}_STRING_SIZE 257
+#endif
+
+/* Buffer size of binary values without a maximum length */
+#ifndef {{.Prefix}}_BINARY_SIZE
+#define {{.Prefix}}_BINARY_SIZE 256
+#endif
 
-{{range .Structs}}
+/* Buffer size of dateTime values in ISO 8601 format */
+#define {{.Prefix}}_DATETIME_SIZE 32
+
+/* Number of entries reserved for tables without a bounded maxEntries */
+#ifndef {{.Prefix}}_MAX_INSTANCES
+#define {{.Prefix}}_MAX_INSTANCES 8
+#endif
+{{range .Structs}}{{if .MaxInstancesMacro}}
+#ifndef {{.MaxInstancesMacro}}
+#define {{.MaxInstancesMacro}} {{.MaxInstances}}
+#endif
+{{end}}{{end}}
+/* Copies a string into a fixed-size field, e.g. {{.Prefix}}_SET_STRING(obj->Alias, "lan") */
+#define {{.Prefix}}_SET_STRING(field, value) {{.FuncPrefix}}_copy_string((field), sizeof(field), (value))
+
+/**
+ * Copies src into the buffer dst of size bytes, always terminating it.
+ * Returns 0 on success or -1 if the value was truncated.
+ */
+int {{.FuncPrefix}}_copy_string(char *dst, size_t size, const char *src);
+
+/**
+ * Copies length bytes of src into the buffer dst of size bytes, storing the
+ * number of bytes used in *used. Returns 0 on success or -1 if src does not fit.
+ */
+int {{.FuncPrefix}}_copy_binary(uint8_t *dst, size_t size, size_t *used, const uint8_t *src, size_t length);
+{{template "enumTypes" .}}{{range .Structs}}
 /**
- * {{.Description}}
+ * {{cComment .Description}}
  */
 typedef struct {{.Name}} {
 {{range .Fields}}
     /**
-     * {{.Description}}
+     * {{cComment .Description}}
      */
     {{.Type}} {{.Name}}{{.ArraySize}};
 {{end}}
 } {{.Name}};
 
-{{end}}
+/* Resets every field of obj to its zero value */
+void {{.Name}}_init({{.Name}} *obj);
+
+/* Allocates and initializes a {{.Name}}, returning NULL when out of memory */
+{{.Name}} *{{.Name}}_new(void);
+
+/* Releases a {{.Name}} allocated by {{.Name}}_new */
+void {{.Name}}_free({{.Name}} *obj);
+{{$struct := .Name}}{{range .Tables}}
+/* Appends an entry to {{.Field}} with the next instance number, returning NULL when the table is full */
+{{.EntryType}} *{{$struct}}_add_{{.Field}}({{$struct}} *obj);
 
+/* Removes the entry of {{.Field}} with the given instance number, returning -1 if there is none */
+int {{$struct}}_remove_{{.Field}}({{$struct}} *obj, uint32_t instance_number);
+{{end}}{{end}}{{template "parameterTable" .}}
 #ifdef __cplusplus
 }
 #endif
@@ -46,14 +97,22 @@ typedef struct {{.Name}} {
 // CTemplate contains data for the C header template
 type CTemplate struct {
 	GuardMacro string
+	HeaderFile string
+	Prefix     string // Prefix of generated macros
+	FuncPrefix string // Prefix of generated helper functions
 	Structs    []CStruct
+	Parameters []CParameter
 }
 
 // CStruct represents a C struct
 type CStruct struct {
-	Name        string
-	Description string
-	Fields      []CField
+	Name              string
+	Description       string
+	Path              string
+	Fields            []CField
+	Tables            []CTable
+	MaxInstancesMacro string // Set for the entries of a table
+	MaxInstances      string
 }
 
 // CField represents a field in a C struct
@@ -64,86 +123,232 @@ type CField struct {
 	ArraySize   string
 }
 
-// GenerateCHeader generates C header code from a data model
-func GenerateCHeader(model *models.DataModel, outputDir string) ([]string, error) {
-	fileName := sanitize(model.Name) + ".h"
-	outputFile := filepath.Join(outputDir, fileName)
+// CTable is a fixed-capacity table of child object entries
+type CTable struct {
+	Field        string
+	EntryType    string
+	MaxInstances string
+}
 
-	// Create the guard macro
-	guardMacro := strings.ToUpper(sanitize(model.Name)) + "_H"
+// GenerateCHeader generates a C header and its companion source file from a
+// data model. All values are stored in fixed-size buffers so that the
+// generated structs can be copied and freed without tracking ownership.
+func GenerateCHeader(model *models.DataModel, outputDir string) ([]string, error) {
+	baseName := sanitize(model.Name)
+	prefix := strings.ToUpper(baseName)
 
 	// Create the template data
 	tmplData := CTemplate{
-		GuardMacro: guardMacro,
+		GuardMacro: prefix + "_H",
+		HeaderFile: baseName + ".h",
+		Prefix:     prefix,
+		FuncPrefix: strings.ToLower(baseName),
 		Structs:    []CStruct{},
 	}
 
+	dataTypes := make(map[string]models.DataType)
+	for _, dataType := range model.DataTypes {
+		dataTypes[dataType.Name] = dataType
+	}
+
 	// Convert each object to a C struct
+	tree := linkGoObjectTree(model)
 	for _, obj := range model.Objects {
-		cStruct := convertObjectToCStruct(obj)
+		cStruct := convertObjectToCStruct(obj, prefix, dataTypes)
+		addCChildFields(&cStruct, tree[objectPathPrefix(obj)])
+		if len(cStruct.Fields) == 0 {
+			// C does not allow empty structs
+			cStruct.Fields = append(cStruct.Fields, CField{
+				Name:        "unused_",
+				Description: "Placeholder for an object without parameters",
+				Type:        "char",
+			})
+		}
 		tmplData.Structs = append(tmplData.Structs, cStruct)
 	}
 
-	// Create the output file
-	file, err := os.Create(outputFile)
-	if err != nil {
-		return nil, err
-	}
-	defer file.Close()
+	tmplData.Parameters = collectCParameters(model.Objects, tmplData.Prefix, dataTypes)
+
+	// Child structs are embedded by value, so they must be declared first
+	sort.SliceStable(tmplData.Structs, func(i, j int) bool {
+		return strings.Count(tmplData.Structs[i].Path, ".") > strings.Count(tmplData.Structs[j].Path, ".")
+	})
 
-	// Parse and execute the template
-	tmpl, err := template.New("cheader").Parse(cHeaderTemplate)
-	if err != nil {
-		return nil, err
+	funcs := template.FuncMap{"cComment": cComment}
+	files := []struct {
+		name, text string
+		sections   []string
+	}{
+		{baseName + ".h", cHeaderTemplate, []string{cParameterTableHeader, cEnumHeader}},
+		{baseName + ".c", cSourceTemplate, []string{cParameterTableSource, cEnumSource}},
 	}
 
-	if err := tmpl.Execute(file, tmplData); err != nil {
-		return nil, err
+	outputFiles := []string{}
+	for _, f := range files {
+		tmpl, err := template.New(f.name).Funcs(funcs).Parse(f.text)
+		for _, section := range f.sections {
+			if err == nil {
+				_, err = tmpl.Parse(section)
+			}
+		}
+		if err != nil {
+			return outputFiles, err
+		}
+		if err := writeTemplate(tmpl, filepath.Join(outputDir, f.name), tmplData); err != nil {
+			return outputFiles, err
+		}
+		outputFiles = append(outputFiles, f.name)
 	}
 
-	return []string{fileName}, nil
+	return outputFiles, nil
 }
 
 // convertObjectToCStruct converts a CWMP object to a C struct
-func convertObjectToCStruct(obj models.Object) CStruct {
+func convertObjectToCStruct(obj models.Object, prefix string, dataTypes map[string]models.DataType) CStruct {
 	cStruct := CStruct{
 		Name:        toExportedName(sanitize(obj.Name)),
 		Description: obj.Description,
+		Path:        objectPathPrefix(obj),
 		Fields:      []CField{},
 	}
 
+	// Table entries have a configurable capacity
+	if isTableEntry(obj) {
+		cStruct.MaxInstancesMacro = strings.ToUpper(cStruct.Name) + "_MAX_INSTANCES"
+		cStruct.MaxInstances = prefix + "_MAX_INSTANCES"
+		if n, err := strconv.Atoi(obj.MaxEntries); err == nil && n > 0 {
+			cStruct.MaxInstances = obj.MaxEntries
+		}
+		cStruct.Fields = append(cStruct.Fields, CField{
+			Name:        "InstanceNumber",
+			Description: instanceNumberDescription,
+			Type:        "uint32_t",
+		})
+	}
+
 	// Convert parameters to fields
 	for _, param := range obj.Parameters {
-		cField := CField{
-			Name:        sanitizeCFieldName(param.Name),
-			Description: param.Description,
-			Type:        mapCWMPTypeToCType(param.Type),
-			ArraySize:   "", // Default to non-array
-		}
-		cStruct.Fields = append(cStruct.Fields, cField)
+		cStruct.Fields = append(cStruct.Fields, convertParameterToCFields(param, cStruct.Path, cStruct.Name, prefix, dataTypes)...)
+	}
+
+	return cStruct
+}
+
+// convertParameterToCFields converts a parameter to struct fields. Strings
+// and binary values are stored in buffers sized from their maxLength facet;
+// binary values also get a field holding the number of bytes used.
+// Enumerated strings are stored as enum constants.
+func convertParameterToCFields(param models.Parameter, objectPath, structName, prefix string, dataTypes map[string]models.DataType) []CField {
+	name := sanitizeCFieldName(param.Name)
+	field := CField{
+		Name:        name,
+		Description: param.Description,
+	}
+
+	// Lists are kept in their comma-separated wire encoding
+	if param.IsList {
+		field.Type = "char"
+		field.ArraySize = cStringArraySize(listSize(param.Syntax.List), prefix)
+		return []CField{field}
 	}
 
-	// Handle nested objects
-	for _, childObj := range obj.Objects {
-		fieldType := toExportedName(sanitize(childObj.Name)) + "*"
-		arraySize := ""
+	// Enumerated strings are stored as their enum constant
+	if enum := cEnumFor(param, objectPath, structName, dataTypes); enum != nil {
+		field.Type = enum.TypeName
+		return []CField{field}
+	}
 
-		if childObj.MultiInstance {
-			// For multi-instance objects, use a pointer to an array
-			// In real implementation, this would need proper memory management
-			arraySize = "[MAX_INSTANCES]" // This would need to be defined
+	value := models.ResolveSyntax(param, dataTypes)
+	size := sizeMax(value.Size)
+	switch strings.ToLower(value.Type) {
+	case "string":
+		field.Type = "char"
+		field.ArraySize = cStringArraySize(size, prefix)
+	case "datetime":
+		field.Type = "char"
+		field.ArraySize = "[" + prefix + "_DATETIME_SIZE]"
+	case "base64", "hexbinary":
+		field.Type = "uint8_t"
+		field.ArraySize = "[" + prefix + "_BINARY_SIZE]"
+		if size > 0 {
+			field.ArraySize = "[" + strconv.Itoa(size) + "]"
 		}
+		return []CField{field, {
+			Name:        name + "_len",
+			Description: "Number of bytes used in " + name,
+			Type:        "size_t",
+		}}
+	default:
+		field.Type = mapCWMPTypeToCType(value.Type)
+	}
+
+	return []CField{field}
+}
 
-		cField := CField{
-			Name:        sanitizeCFieldName(childObj.Name),
-			Description: childObj.Description,
-			Type:        fieldType,
-			ArraySize:   arraySize,
+// addCChildFields embeds single-instance children by value and stores the
+// entries of multi-instance children in fixed-capacity arrays with a count
+func addCChildFields(cStruct *CStruct, children []GoChildObject) {
+	for _, child := range children {
+		name := sanitizeCFieldName(child.Name)
+		if !child.IsMultiInstance {
+			cStruct.Fields = append(cStruct.Fields, CField{
+				Name:        name,
+				Description: child.Name + " object",
+				Type:        child.StructName,
+			})
+			continue
 		}
-		cStruct.Fields = append(cStruct.Fields, cField)
+
+		maxInstances := strings.ToUpper(child.StructName) + "_MAX_INSTANCES"
+		cStruct.Fields = append(cStruct.Fields,
+			CField{
+				Name:        name,
+				Description: child.Name + " table entries, ordered by insertion",
+				Type:        child.StructName,
+				ArraySize:   "[" + maxInstances + "]",
+			},
+			CField{
+				Name:        name + "_count",
+				Description: "Number of entries used in " + name,
+				Type:        "size_t",
+			})
+		cStruct.Tables = append(cStruct.Tables, CTable{
+			Field:        name,
+			EntryType:    child.StructName,
+			MaxInstances: maxInstances,
+		})
 	}
+}
 
-	return cStruct
+// cStringArraySize returns the buffer size of a string, leaving room for the
+// terminator
+func cStringArraySize(maxLength int, prefix string) string {
+	if maxLength <= 0 {
+		return "[" + prefix + "_STRING_SIZE]"
+	}
+	return "[" + strconv.Itoa(maxLength+1) + "]"
+}
+
+// listSize returns the maxLength facet of a list
+func listSize(list *models.List) int {
+	if list == nil {
+		return 0
+	}
+	return sizeMax(list.Size)
+}
+
+// sizeMax returns the maxLength of a size facet, or 0 when unbounded
+func sizeMax(size *models.Size) int {
+	if size == nil {
+		return 0
+	}
+	return size.Max
+}
+
+// cComment escapes text for use inside a C block comment
+func cComment(text string) string {
+	text = strings.ReplaceAll(text, "*/", "* /")
+	return strings.ReplaceAll(text, "/*", "/ *")
 }
 
 // mapCWMPTypeToCType maps CWMP types to C types
@@ -155,12 +360,18 @@ func mapCWMPTypeToCType(cwmpType string) string {
 		return "int32_t"
 	case "unsignedint", "unsignedinteger":
 		return "uint32_t"
+	case "long":
+		return "int64_t"
+	case "unsignedlong":
+		return "uint64_t"
 	case "boolean", "bool":
 		return "bool"
 	case "datetime":
 		return "char*" // Represented as a string in C
-	case "base64":
+	case "base64", "hexbinary":
 		return "uint8_t*"
+	case "list":
+		return "char*" // Kept in its comma-separated wire encoding
 	default:
 		return "void*"
 	}
@@ -0,0 +1,100 @@
+package generator
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/Niceblueman/cwmp-codegen/internal/models"
+)
+
+// Header section declaring an enum type per enumerated parameter
+const cEnumHeader = `{{define "enumTypes"}}{{range .Parameters}}{{with .Enum}}
+/* Values of {{.Path}} */
+typedef enum {{.TypeName}} {
+    {{.Unset}} = 0, /* No value, or a value outside the enumeration */
+{{- range .Constants}}
+    {{.}},
+{{- end}}
+    {{.Count}}
+} {{.TypeName}};
+
+/* Returns the string value of v, or NULL if v is not enumerated */
+const char *{{.Function}}_to_string({{.TypeName}} v);
+
+/* Stores the enumerated value of s in *v, returning 0 on success or -1 if s is not enumerated */
+int {{.Function}}_from_string(const char *s, {{.TypeName}} *v);
+{{end}}{{end}}{{end}}`
+
+// Source section converting enum values to and from strings, indexing the
+// enumeration arrays of the parameter table
+const cEnumSource = `{{define "enumFunctions"}}{{range .Parameters}}{{with .Enum}}
+const char *{{.Function}}_to_string({{.TypeName}} v)
+{
+    if (v <= {{.Unset}} || v >= {{.Count}}) {
+        return NULL;
+    }
+    return {{.ValuesName}}[v - 1];
+}
+
+int {{.Function}}_from_string(const char *s, {{.TypeName}} *v)
+{
+    size_t i;
+
+    if (s == NULL || v == NULL) {
+        return -1;
+    }
+    for (i = 0; i < sizeof({{.ValuesName}}) / sizeof({{.ValuesName}}[0]); i++) {
+        if (strcmp({{.ValuesName}}[i], s) == 0) {
+            *v = ({{.TypeName}})(i + 1);
+            return 0;
+        }
+    }
+    return -1;
+}
+{{end}}{{end}}{{end}}`
+
+// CEnum is the enum type of an enumerated parameter
+type CEnum struct {
+	Path       string
+	TypeName   string
+	Function   string // Prefix of the conversion functions
+	ValuesName string // Array of string values in the parameter table
+	Unset      string
+	Count      string
+	Constants  []string
+}
+
+// cEnumFor returns the enum type of a parameter, or nil when the parameter
+// is not a single enumerated string
+func cEnumFor(param models.Parameter, objectPath, structName string, dataTypes map[string]models.DataType) *CEnum {
+	if param.IsList {
+		return nil
+	}
+	value := models.ResolveSyntax(param, dataTypes)
+	if value.Type != "string" || len(value.Enumeration) == 0 {
+		return nil
+	}
+
+	name := structName + "_" + sanitizeCFieldName(param.Name)
+	constPrefix := strings.ToUpper(name) + "_"
+	enum := &CEnum{
+		Path:       objectPath + param.Name,
+		TypeName:   name + "_t",
+		Function:   name,
+		ValuesName: strings.ToLower(name) + "_values",
+		Unset:      constPrefix + "UNSET",
+		Count:      constPrefix + "COUNT",
+	}
+
+	// Constants must stay unique after sanitizing, e.g. "10BASE-T" and "10BASE_T"
+	used := map[string]bool{"UNSET": true, "COUNT": true}
+	for _, enumeration := range value.Enumeration {
+		suffix := upperSnakeCase(enumeration.Value)
+		for n := 2; used[suffix]; n++ {
+			suffix = upperSnakeCase(enumeration.Value) + "_" + strconv.Itoa(n)
+		}
+		used[suffix] = true
+		enum.Constants = append(enum.Constants, constPrefix+suffix)
+	}
+	return enum
+}
@@ -0,0 +1,367 @@
+package generator
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/Niceblueman/cwmp-codegen/internal/models"
+)
+
+// Header section declaring the parameter descriptor table and callbacks
+const cParameterTableHeader = `{{define "parameterTable"}}
+/* Value types of data model parameters */
+typedef enum {{.FuncPrefix}}_param_type {
+    {{.Prefix}}_TYPE_STRING,
+    {{.Prefix}}_TYPE_INT,
+    {{.Prefix}}_TYPE_UNSIGNED_INT,
+    {{.Prefix}}_TYPE_LONG,
+    {{.Prefix}}_TYPE_UNSIGNED_LONG,
+    {{.Prefix}}_TYPE_BOOLEAN,
+    {{.Prefix}}_TYPE_DATETIME,
+    {{.Prefix}}_TYPE_BASE64,
+    {{.Prefix}}_TYPE_HEXBINARY
+} {{.FuncPrefix}}_param_type;
+
+/* Access rights of data model parameters */
+typedef enum {{.FuncPrefix}}_param_access {
+    {{.Prefix}}_ACCESS_READ_ONLY,
+    {{.Prefix}}_ACCESS_READ_WRITE
+} {{.FuncPrefix}}_param_access;
+
+/* Whether an ACS may change the notification attribute of a parameter */
+typedef enum {{.FuncPrefix}}_active_notify {
+    {{.Prefix}}_ACTIVE_NOTIFY_NORMAL,
+    {{.Prefix}}_ACTIVE_NOTIFY_FORCE_ENABLED,
+    {{.Prefix}}_ACTIVE_NOTIFY_FORCE_DEFAULT_ENABLED,
+    {{.Prefix}}_ACTIVE_NOTIFY_CAN_DENY
+} {{.FuncPrefix}}_active_notify;
+
+/* Describes a parameter of the data model */
+typedef struct {{.FuncPrefix}}_param_descriptor {
+    const char *path; /* Path with {i} in place of instance numbers */
+    {{.FuncPrefix}}_param_type type; /* Type of the value, or of each item of a list */
+    {{.FuncPrefix}}_param_access access;
+    bool is_list; /* Whether the value is a comma-separated list */
+    unsigned int instance_depth; /* Number of {i} placeholders in path */
+    bool has_min; /* Lower bound of numbers, or minimum length of other values */
+    int64_t min;
+    bool has_max; /* Upper bound of numbers, or maximum length of other values */
+    int64_t max;
+    const char *const *values; /* Values of an enumeration, or NULL */
+    size_t value_count;
+    int notification; /* Default notification: 0 off, 1 passive, 2 active */
+    {{.FuncPrefix}}_active_notify active_notify;
+} {{.FuncPrefix}}_param_descriptor;
+
+/* Indexes of the parameters in {{.FuncPrefix}}_parameters */
+typedef enum {{.FuncPrefix}}_param_index {
+{{- range .Parameters}}
+    {{.Index}},
+{{- end}}
+    {{.Prefix}}_PARAM_COUNT
+} {{.FuncPrefix}}_param_index;
+{{if .Parameters}}
+/* Descriptors of every parameter, indexed by {{.FuncPrefix}}_param_index */
+extern const {{.FuncPrefix}}_param_descriptor {{.FuncPrefix}}_parameters[{{.Prefix}}_PARAM_COUNT];
+{{end}}
+/**
+ * Returns the descriptor matching a parameter path such as
+ * "Device.Host.1.Alias", or NULL if there is none. The instance numbers in the
+ * path are stored in instances, which may be NULL, up to max_instances entries.
+ */
+const {{.FuncPrefix}}_param_descriptor *{{.FuncPrefix}}_find_parameter(const char *path, uint32_t *instances, size_t max_instances);
+
+/*
+ * Callbacks reading and writing a parameter value. ctx is passed through from
+ * the agent and instances holds the instance numbers of the path. Callbacks
+ * return 0 on success or a CWMP fault code, e.g. 9007 for an invalid value.
+ */
+{{range .Parameters}}
+/* {{.Path}} */
+typedef int (*{{.Callback}}_get_fn)(void *ctx, const uint32_t *instances, {{.GetArgs}});
+{{- if .Writable}}
+typedef int (*{{.Callback}}_set_fn)(void *ctx, const uint32_t *instances, {{.SetArgs}});
+{{- end}}
+{{end}}{{end}}`
+
+// Source section defining the parameter descriptor table
+const cParameterTableSource = `{{define "parameterTable"}}{{range .Parameters}}{{if .Values}}
+static const char *const {{.ValuesName}}[] = { {{.Values}} };
+{{end}}{{end}}{{if .Parameters}}
+const {{.FuncPrefix}}_param_descriptor {{.FuncPrefix}}_parameters[{{.Prefix}}_PARAM_COUNT] = {
+{{- range .Parameters}}
+    [{{.Index}}] = {
+        .path = {{.PathLiteral}},
+        .type = {{.TypeConst}},
+        .access = {{.AccessConst}},
+{{- if .IsList}}
+        .is_list = true,
+{{- end}}
+{{- if .InstanceDepth}}
+        .instance_depth = {{.InstanceDepth}},
+{{- end}}
+{{- if .Min}}
+        .has_min = true,
+        .min = {{.Min}},
+{{- end}}
+{{- if .Max}}
+        .has_max = true,
+        .max = {{.Max}},
+{{- end}}
+{{- if .Values}}
+        .values = {{.ValuesName}},
+        .value_count = {{.ValueCount}},
+{{- end}}
+{{- if .Notification}}
+        .notification = {{.Notification}},
+{{- end}}
+{{- if .ActiveNotifyConst}}
+        .active_notify = {{.ActiveNotifyConst}},
+{{- end}}
+    },
+{{- end}}
+};
+{{end}}
+/* Matches a path against a pattern, storing the numbers matched by {i} */
+static bool {{.FuncPrefix}}_match_path(const char *pattern, const char *path, uint32_t *instances, size_t max_instances)
+{
+    size_t depth = 0;
+
+    while (*pattern != '\0') {
+        if (strncmp(pattern, "{i}", 3) == 0) {
+            uint32_t n = 0;
+
+            if (*path < '1' || *path > '9') {
+                return false;
+            }
+            while (*path >= '0' && *path <= '9') {
+                if (n > (UINT32_MAX - 9) / 10) {
+                    return false;
+                }
+                n = n * 10 + (uint32_t)(*path - '0');
+                path++;
+            }
+            if (instances != NULL && depth < max_instances) {
+                instances[depth] = n;
+            }
+            depth++;
+            pattern += 3;
+        } else if (*pattern++ != *path++) {
+            return false;
+        }
+    }
+    return *path == '\0';
+}
+
+const {{.FuncPrefix}}_param_descriptor *{{.FuncPrefix}}_find_parameter(const char *path, uint32_t *instances, size_t max_instances)
+{
+{{- if .Parameters}}
+    size_t i;
+
+    if (path == NULL) {
+        return NULL;
+    }
+    for (i = 0; i < {{.Prefix}}_PARAM_COUNT; i++) {
+        if ({{.FuncPrefix}}_match_path({{.FuncPrefix}}_parameters[i].path, path, instances, max_instances)) {
+            return &{{.FuncPrefix}}_parameters[i];
+        }
+    }
+{{- else}}
+    (void)path;
+    (void)instances;
+    (void)max_instances;
+    (void){{.FuncPrefix}}_match_path;
+{{- end}}
+    return NULL;
+}
+{{end}}`
+
+// CParameter is an entry of the C parameter descriptor table
+type CParameter struct {
+	Index             string // Enum constant indexing the table
+	Path              string
+	PathLiteral       string
+	Callback          string // Prefix of the callback typedefs
+	TypeConst         string
+	AccessConst       string
+	Writable          bool
+	IsList            bool
+	InstanceDepth     int
+	Min               string // C expression, empty when unbounded
+	Max               string // C expression, empty when unbounded
+	ValuesName        string
+	Values            string // Comma-separated C string literals
+	ValueCount        int
+	Notification      int
+	ActiveNotifyConst string // Empty for normal parameters
+	GetArgs           string
+	SetArgs           string
+	Enum              *CEnum // Set for enumerated strings
+}
+
+// collectCParameters builds the descriptor table entries of every parameter
+func collectCParameters(objects []models.Object, prefix string, dataTypes map[string]models.DataType) []CParameter {
+	parameters := []CParameter{}
+	for _, obj := range objects {
+		structName := toExportedName(sanitize(obj.Name))
+		objectPath := objectPathPrefix(obj)
+		for _, param := range obj.Parameters {
+			parameters = append(parameters, convertParameterToCDescriptor(param, objectPath, structName, prefix, dataTypes))
+		}
+	}
+	return parameters
+}
+
+// convertParameterToCDescriptor converts a parameter to a descriptor table entry
+func convertParameterToCDescriptor(param models.Parameter, objectPath, structName, prefix string, dataTypes map[string]models.DataType) CParameter {
+	callback := structName + "_" + sanitizeCFieldName(param.Name)
+	path := objectPath + param.Name
+	value := models.ResolveSyntax(param, dataTypes)
+
+	cParam := CParameter{
+		Index:         prefix + "_PARAM_" + strings.ToUpper(callback),
+		Path:          path,
+		PathLiteral:   cStringLiteral(path),
+		Callback:      callback,
+		TypeConst:     prefix + "_TYPE_" + cTypeConstSuffix(value.Type),
+		AccessConst:   prefix + "_ACCESS_READ_ONLY",
+		Writable:      param.Access == "readWrite",
+		IsList:        param.IsList,
+		InstanceDepth: strings.Count(path, "{i}"),
+	}
+	if cParam.Writable {
+		cParam.AccessConst = prefix + "_ACCESS_READ_WRITE"
+	}
+
+	// Numbers are bounded by their range; other values by their length
+	switch {
+	case value.Range != nil:
+		cParam.Min = cInt64Literal(rangeMin(value.Range))
+		cParam.Max = cInt64Literal(rangeMax(value.Range))
+	case param.IsList:
+		if list := param.Syntax.List; list != nil && list.Size != nil {
+			cParam.Min, cParam.Max = cSizeLiterals(list.Size)
+		}
+	case value.Size != nil:
+		cParam.Min, cParam.Max = cSizeLiterals(value.Size)
+	}
+
+	if len(value.Enumeration) > 0 {
+		literals := make([]string, len(value.Enumeration))
+		for i, enum := range value.Enumeration {
+			literals[i] = cStringLiteral(enum.Value)
+		}
+		cParam.ValuesName = strings.ToLower(callback) + "_values"
+		cParam.Values = strings.Join(literals, ", ")
+		cParam.ValueCount = len(literals)
+	}
+
+	switch param.ActiveNotify {
+	case "forceEnabled":
+		cParam.Notification = 2
+		cParam.ActiveNotifyConst = prefix + "_ACTIVE_NOTIFY_FORCE_ENABLED"
+	case "forceDefaultEnabled":
+		cParam.Notification = 2
+		cParam.ActiveNotifyConst = prefix + "_ACTIVE_NOTIFY_FORCE_DEFAULT_ENABLED"
+	case "canDeny":
+		cParam.ActiveNotifyConst = prefix + "_ACTIVE_NOTIFY_CAN_DENY"
+	}
+
+	cParam.GetArgs, cParam.SetArgs = cCallbackArgs(param, value.Type)
+	if cParam.Enum = cEnumFor(param, objectPath, structName, dataTypes); cParam.Enum != nil {
+		cParam.GetArgs = cParam.Enum.TypeName + " *value"
+		cParam.SetArgs = cParam.Enum.TypeName + " value"
+	}
+	return cParam
+}
+
+// cCallbackArgs returns the value arguments of a parameter's getter and setter
+func cCallbackArgs(param models.Parameter, cwmpType string) (string, string) {
+	if param.IsList {
+		return "char *value, size_t size", "const char *value"
+	}
+
+	switch strings.ToLower(cwmpType) {
+	case "base64", "hexbinary":
+		return "uint8_t *value, size_t size, size_t *used", "const uint8_t *value, size_t length"
+	case "int", "integer", "unsignedint", "unsignedinteger", "long", "unsignedlong", "boolean", "bool":
+		cType := mapCWMPTypeToCType(cwmpType)
+		return cType + " *value", cType + " value"
+	default:
+		return "char *value, size_t size", "const char *value"
+	}
+}
+
+// cTypeConstSuffix returns the param_type constant suffix of a CWMP type
+func cTypeConstSuffix(cwmpType string) string {
+	switch strings.ToLower(cwmpType) {
+	case "int", "integer":
+		return "INT"
+	case "unsignedint", "unsignedinteger":
+		return "UNSIGNED_INT"
+	case "long":
+		return "LONG"
+	case "unsignedlong":
+		return "UNSIGNED_LONG"
+	case "boolean", "bool":
+		return "BOOLEAN"
+	case "datetime":
+		return "DATETIME"
+	case "base64":
+		return "BASE64"
+	case "hexbinary":
+		return "HEXBINARY"
+	default:
+		return "STRING"
+	}
+}
+
+// cSizeLiterals returns the length bounds of a size facet
+func cSizeLiterals(size *models.Size) (string, string) {
+	min, max := "", ""
+	if size.Min > 0 {
+		min = cInt64Literal(strconv.Itoa(size.Min))
+	}
+	if size.Max > 0 {
+		max = cInt64Literal(strconv.Itoa(size.Max))
+	}
+	return min, max
+}
+
+// cInt64Literal returns an int64_t literal, or "" when the value is absent or
+// does not fit, in which case the bound of the type applies
+func cInt64Literal(value string) string {
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return ""
+	}
+	if n == math.MinInt64 {
+		return "INT64_MIN"
+	}
+	return "INT64_C(" + strconv.FormatInt(n, 10) + ")"
+}
+
+// cStringLiteral quotes a string as a C string literal
+func cStringLiteral(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '"' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c == '?':
+			// Avoid forming trigraphs
+			b.WriteString(`\?`)
+		case c < 0x20 || c >= 0x7f:
+			fmt.Fprintf(&b, "\\%03o", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
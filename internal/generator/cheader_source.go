@@ -0,0 +1,101 @@
+package generator
+
+// C source template implementing the functions declared by cHeaderTemplate
+const cSourceTemplate = `/* Code generated by cwmp-codegen. DO NOT EDIT. */
+#include "{{.HeaderFile}}"
+
+#include <stdlib.h>
+#include <string.h>
+
+int {{.FuncPrefix}}_copy_string(char *dst, size_t size, const char *src)
+{
+    size_t length;
+
+    if (dst == NULL || size == 0) {
+        return -1;
+    }
+    if (src == NULL) {
+        src = "";
+    }
+
+    length = strlen(src);
+    if (length >= size) {
+        memcpy(dst, src, size - 1);
+        dst[size - 1] = '\0';
+        return -1;
+    }
+    memcpy(dst, src, length + 1);
+    return 0;
+}
+
+int {{.FuncPrefix}}_copy_binary(uint8_t *dst, size_t size, size_t *used, const uint8_t *src, size_t length)
+{
+    if (dst == NULL || used == NULL || (src == NULL && length > 0) || length > size) {
+        return -1;
+    }
+    if (length > 0) {
+        memcpy(dst, src, length);
+    }
+    *used = length;
+    return 0;
+}
+{{range .Structs}}
+void {{.Name}}_init({{.Name}} *obj)
+{
+    if (obj != NULL) {
+        memset(obj, 0, sizeof(*obj));
+    }
+}
+
+{{.Name}} *{{.Name}}_new(void)
+{
+    {{.Name}} *obj = malloc(sizeof(*obj));
+
+    {{.Name}}_init(obj);
+    return obj;
+}
+
+void {{.Name}}_free({{.Name}} *obj)
+{
+    free(obj);
+}
+{{$struct := .Name}}{{range .Tables}}
+{{.EntryType}} *{{$struct}}_add_{{.Field}}({{$struct}} *obj)
+{
+    {{.EntryType}} *entry;
+    uint32_t next = 1;
+    size_t i;
+
+    if (obj == NULL || obj->{{.Field}}_count >= {{.MaxInstances}}) {
+        return NULL;
+    }
+    for (i = 0; i < obj->{{.Field}}_count; i++) {
+        if (obj->{{.Field}}[i].InstanceNumber >= next) {
+            next = obj->{{.Field}}[i].InstanceNumber + 1;
+        }
+    }
+
+    entry = &obj->{{.Field}}[obj->{{.Field}}_count++];
+    {{.EntryType}}_init(entry);
+    entry->InstanceNumber = next;
+    return entry;
+}
+
+int {{$struct}}_remove_{{.Field}}({{$struct}} *obj, uint32_t instance_number)
+{
+    size_t i;
+
+    if (obj == NULL) {
+        return -1;
+    }
+    for (i = 0; i < obj->{{.Field}}_count; i++) {
+        if (obj->{{.Field}}[i].InstanceNumber == instance_number) {
+            memmove(&obj->{{.Field}}[i], &obj->{{.Field}}[i + 1],
+                (obj->{{.Field}}_count - i - 1) * sizeof(obj->{{.Field}}[0]));
+            obj->{{.Field}}_count--;
+            return 0;
+        }
+    }
+    return -1;
+}
+{{end}}{{end}}{{template "parameterTable" .}}{{template "enumFunctions" .}}`
@@ -2,6 +2,7 @@ package generator
 
 import (
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -44,13 +45,13 @@ func TestGenerateCHeader(t *testing.T) {
 		t.Fatalf("GenerateCHeader returned error: %v", err)
 	}
 
-	// Check that we got the expected file
-	if len(files) != 1 {
-		t.Fatalf("Expected 1 file, got %d", len(files))
+	// Check that we got the header and its companion source file
+	if len(files) != 2 {
+		t.Fatalf("Expected 2 files, got %d", len(files))
 	}
 
-	if files[0] != "TestModel.h" {
-		t.Errorf("Expected filename 'TestModel.h', got '%s'", files[0])
+	if files[0] != "TestModel.h" || files[1] != "TestModel.c" {
+		t.Errorf("Expected filenames 'TestModel.h' and 'TestModel.c', got %v", files)
 	}
 
 	// Read the generated file
@@ -73,7 +74,7 @@ func TestGenerateCHeader(t *testing.T) {
 	}
 
 	// Check for field declarations
-	if !strings.Contains(contentStr, "char* TestParam") {
+	if !strings.Contains(contentStr, "char TestParam[TESTMODEL_STRING_SIZE]") {
 		t.Error("Generated code doesn't contain expected string field")
 	}
 
@@ -81,3 +82,235 @@ func TestGenerateCHeader(t *testing.T) {
 		t.Error("Generated code doesn't contain expected int field")
 	}
 }
+
+func TestGenerateCHeaderCompiles(t *testing.T) {
+	cc, err := exec.LookPath("cc")
+	if err != nil {
+		t.Skip("no C compiler available")
+	}
+
+	model := &models.DataModel{
+		Name: "Device:2.1",
+		DataTypes: []models.DataType{
+			{Name: "IPAddress", String: &models.StringType{Size: &models.Size{Max: 45}}},
+		},
+		Objects: []models.Object{
+			{
+				Name: "Device.",
+				Parameters: []models.Parameter{
+					{Name: "HostNumberOfEntries", Type: "unsignedInt"},
+				},
+			},
+			{
+				Name: "Device.ManagementServer.",
+				Parameters: []models.Parameter{
+					{Name: "URL", Type: "string", Syntax: models.Syntax{String: &models.StringCons{Size: &models.Size{Max: 256}}}},
+					{Name: "PeriodicInformTime", Type: "datetime"},
+				},
+			},
+			{
+				Name:       "Device.Host.{i}.",
+				MaxEntries: "2",
+				Parameters: []models.Parameter{
+					{Name: "Alias", Type: "string", Syntax: models.Syntax{String: &models.StringCons{Size: &models.Size{Max: 4}}}},
+					{Name: "IPAddress", Type: "IPAddress"},
+					{Name: "DNSServers", Type: "list", IsList: true, ItemType: "IPAddress"},
+					{Name: "Key", Type: "hexBinary", Syntax: models.Syntax{HexBinary: &models.HexBinary{Size: &models.Size{Max: 16}}}},
+					{Name: "default", Type: "boolean"},
+					{Name: "Status", Type: "string", Syntax: models.Syntax{String: &models.StringCons{
+						Enumeration: []models.Enumeration{{Value: "Up"}, {Value: "Down"}, {Value: "Error-Misconfigured"}},
+					}}},
+				},
+			},
+			{
+				Name: "Device.Host.{i}.IPv4Address.{i}.",
+				Parameters: []models.Parameter{
+					{Name: "Address", Type: "IPAddress"},
+				},
+			},
+		},
+	}
+
+	tmpDir := t.TempDir()
+	if _, err := GenerateCHeader(model, tmpDir); err != nil {
+		t.Fatalf("GenerateCHeader returned error: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, "Device_2_1.h"))
+	if err != nil {
+		t.Fatalf("Failed to read generated header: %v", err)
+	}
+	for _, expected := range []string{
+		"char Alias[5];",
+		"char IPAddress[46];",
+		"uint8_t Key[16];",
+		"#define DEVICE_HOST_INSTANCE_MAX_INSTANCES 2",
+		"#define DEVICE_HOST_INSTANCE_IPV4ADDRESS_INSTANCE_MAX_INSTANCES DEVICE_2_1_MAX_INSTANCES",
+		"Device_ManagementServer ManagementServer;",
+		"Device_Host_Instance Host[DEVICE_HOST_INSTANCE_MAX_INSTANCES];",
+	} {
+		if !strings.Contains(string(content), expected) {
+			t.Errorf("Expected generated header to contain %q", expected)
+		}
+	}
+
+	// Exercise the bounds checks of the generated API
+	program := `#include "Device_2_1.h"
+#include <string.h>
+
+int main(void)
+{
+    Device *device = Device_new();
+    Device_Host_Instance *host;
+    uint32_t instances[1];
+
+    if (device == NULL) {
+        return 1;
+    }
+    host = Device_add_Host(device);
+    if (host == NULL || host->InstanceNumber != 1) {
+        return 2;
+    }
+    if (DEVICE_2_1_SET_STRING(host->Alias, "toolong") != -1 || strcmp(host->Alias, "tool") != 0) {
+        return 3;
+    }
+    if (Device_add_Host(device) == NULL || Device_add_Host(device) != NULL) {
+        return 4;
+    }
+    if (Device_remove_Host(device, 1) != 0 || device->Host_count != 1 || device->Host[0].InstanceNumber != 2) {
+        return 5;
+    }
+    if (Device_add_Host(device)->InstanceNumber != 3) {
+        return 6;
+    }
+    if (device_2_1_find_parameter("Device.Host.12.Alias", instances, 1) !=
+            &device_2_1_parameters[DEVICE_2_1_PARAM_DEVICE_HOST_INSTANCE_ALIAS] || instances[0] != 12) {
+        return 7;
+    }
+    if (device_2_1_find_parameter("Device.Host.0.Alias", NULL, 0) != NULL ||
+            device_2_1_find_parameter("Device.Host.1.Alias.", NULL, 0) != NULL) {
+        return 8;
+    }
+    if (device_2_1_parameters[DEVICE_2_1_PARAM_DEVICE_HOST_INSTANCE_ALIAS].max != 4) {
+        return 9;
+    }
+    if (host->Status != DEVICE_HOST_INSTANCE_STATUS_UNSET || Device_Host_Instance_Status_to_string(host->Status) != NULL) {
+        return 10;
+    }
+    if (Device_Host_Instance_Status_from_string("Error-Misconfigured", &host->Status) != 0 ||
+            host->Status != DEVICE_HOST_INSTANCE_STATUS_ERROR_MISCONFIGURED ||
+            strcmp(Device_Host_Instance_Status_to_string(host->Status), "Error-Misconfigured") != 0) {
+        return 11;
+    }
+    if (Device_Host_Instance_Status_from_string("Dormant", &host->Status) != -1 ||
+            Device_Host_Instance_Status_to_string(DEVICE_HOST_INSTANCE_STATUS_COUNT) != NULL) {
+        return 12;
+    }
+    Device_free(device);
+    return 0;
+}
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, "main.c"), []byte(program), 0644); err != nil {
+		t.Fatalf("Failed to write test program: %v", err)
+	}
+
+	binary := filepath.Join(tmpDir, "device")
+	cmd := exec.Command(cc, "-std=c99", "-Wall", "-Wextra", "-Werror", "-pedantic",
+		"-o", binary, "Device_2_1.c", "main.c")
+	cmd.Dir = tmpDir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("Generated C code does not compile: %v\n%s", err, output)
+	}
+
+	if output, err := exec.Command(binary).CombinedOutput(); err != nil {
+		t.Fatalf("Generated C code failed at runtime: %v\n%s", err, output)
+	}
+}
+
+func TestConvertParameterToCDescriptor(t *testing.T) {
+	param := models.Parameter{
+		Name:         "Status",
+		Access:       "readWrite",
+		ActiveNotify: "forceDefaultEnabled",
+		Type:         "string",
+		Syntax: models.Syntax{String: &models.StringCons{
+			Enumeration: []models.Enumeration{{Value: "Up"}, {Value: "Down"}},
+		}},
+	}
+
+	desc := convertParameterToCDescriptor(param, "Device.Interface.{i}.", "Device_Interface_Instance", "DEV", nil)
+	if desc.Index != "DEV_PARAM_DEVICE_INTERFACE_INSTANCE_STATUS" {
+		t.Errorf("Unexpected index %s", desc.Index)
+	}
+	if desc.PathLiteral != `"Device.Interface.{i}.Status"` || desc.InstanceDepth != 1 {
+		t.Errorf("Unexpected path %s with depth %d", desc.PathLiteral, desc.InstanceDepth)
+	}
+	if desc.AccessConst != "DEV_ACCESS_READ_WRITE" || !desc.Writable {
+		t.Errorf("Expected a writable parameter, got %s", desc.AccessConst)
+	}
+	if desc.Values != `"Up", "Down"` || desc.ValueCount != 2 {
+		t.Errorf("Unexpected enumeration %s", desc.Values)
+	}
+	if desc.Notification != 2 || desc.ActiveNotifyConst != "DEV_ACTIVE_NOTIFY_FORCE_DEFAULT_ENABLED" {
+		t.Errorf("Unexpected notification %d %s", desc.Notification, desc.ActiveNotifyConst)
+	}
+	if desc.SetArgs != "Device_Interface_Instance_Status_t value" {
+		t.Errorf("Unexpected setter arguments %s", desc.SetArgs)
+	}
+
+	counter := convertParameterToCDescriptor(models.Parameter{
+		Name:   "Counter",
+		Type:   "unsignedLong",
+		Syntax: models.Syntax{UnsignedLong: &models.UnsignedLong{Range: &models.Range{MaxInclusive: "18446744073709551615"}}},
+	}, "Device.", "Device", "DEV", nil)
+	if counter.Max != "" || counter.Min != "" || counter.GetArgs != "uint64_t *value" {
+		t.Errorf("Unexpected unsignedLong descriptor %+v", counter)
+	}
+}
+
+func TestCStringLiteral(t *testing.T) {
+	if got := cStringLiteral("a\"b\\c??=\n"); got != `"a\"b\\c\?\?=\012"` {
+		t.Errorf("Unexpected literal %s", got)
+	}
+}
+
+func TestCEnumConstSuffix(t *testing.T) {
+	tests := map[string]string{
+		"Up":                  "UP",
+		"Error_Misconfigured": "ERROR_MISCONFIGURED",
+		"10BASE-T":            "10BASE_T",
+		"802.11b":             "802_11B",
+		"UBR+":                "UBR_PLUS",
+		"":                    "EMPTY",
+		" - ":                 "EMPTY",
+	}
+	for value, expected := range tests {
+		if got := upperSnakeCase(value); got != expected {
+			t.Errorf("upperSnakeCase(%q) = %s, expected %s", value, got, expected)
+		}
+	}
+}
+
+func TestCEnumForDeduplicatesConstants(t *testing.T) {
+	param := models.Parameter{
+		Name: "Mode",
+		Type: "string",
+		Syntax: models.Syntax{String: &models.StringCons{
+			Enumeration: []models.Enumeration{{Value: "Unset"}, {Value: "a-b"}, {Value: "a_b"}},
+		}},
+	}
+
+	enum := cEnumFor(param, "Device.", "Device", nil)
+	if enum == nil {
+		t.Fatal("Expected an enum for an enumerated string")
+	}
+	expected := []string{"DEVICE_MODE_UNSET_2", "DEVICE_MODE_A_B", "DEVICE_MODE_A_B_2"}
+	if strings.Join(enum.Constants, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected constants %v, got %v", expected, enum.Constants)
+	}
+
+	param.IsList = true
+	if cEnumFor(param, "Device.", "Device", nil) != nil {
+		t.Error("Expected no enum for a list")
+	}
+}
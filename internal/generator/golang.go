@@ -4,8 +4,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"text/template"
+	"unicode"
 
 	"github.com/Niceblueman/cwmp-codegen/internal/models"
 )
@@ -29,7 +31,9 @@ type {{.GoName}} struct {
 {{range .Parameters}}
 	{{.GoName}} {{.GoType}} {{if .Description}}// {{.Description | formatComment}}{{end}}
 {{end}}
-}
+{{if .IsTable}}	InstanceNumber int // Instance number of this table entry
+{{end}}{{range .ChildObjects}}	{{.GoName}} {{.GoType}}
+{{end}}}
 
 type {{.LowerName}}BodyStruct struct {
 	Body {{.LowerName}}Struct ` + "`xml:\"cwmp:{{.GoName}}\"`" + `
@@ -186,6 +190,80 @@ func (msg *{{.GoName}}) Parse(doc *xmlx.Document) error {
 	
 	return nil
 }
+{{range .Parameters}}{{if .Codec}}
+// Encode{{.GoName}} returns {{.GoName}} in its TR-069 wire encoding
+func (msg *{{$.GoName}}) Encode{{.GoName}}() string {
+	return Encode{{.Codec}}(msg.{{.GoName}})
+}
+
+// Decode{{.GoName}} decodes a TR-069 wire value into {{.GoName}}
+func (msg *{{$.GoName}}) Decode{{.GoName}}(value string) error {
+	v, err := Decode{{.Codec}}(value{{.DecodeArgs}})
+	if err != nil {
+		return err
+	}
+	msg.{{.GoName}} = v
+	return nil
+}
+{{end}}{{if .IsList}}
+// Marshal{{.GoName}} encodes {{.GoName}} as a TR-069 comma-separated list
+func (msg *{{$.GoName}}) Marshal{{.GoName}}() (string, error) {
+	value := MarshalList(msg.{{.GoName}}, Format{{.ListCodec}}ListItem)
+	return value, CheckList(value, len(msg.{{.GoName}}), {{.MinItems}}, {{.MaxItems}}, {{.MaxLength}})
+}
+
+// Unmarshal{{.GoName}} decodes a TR-069 comma-separated list into {{.GoName}}
+func (msg *{{$.GoName}}) Unmarshal{{.GoName}}(value string) error {
+	items, err := UnmarshalList(value, Parse{{.ListCodec}}ListItem)
+	if err != nil {
+		return err
+	}
+	if err := CheckList(value, len(items), {{.MinItems}}, {{.MaxItems}}, {{.MaxLength}}); err != nil {
+		return err
+	}
+	msg.{{.GoName}} = items
+	return nil
+}
+{{end}}{{end}}
+// bindPath assigns a value at a path relative to {{.GoName}}
+func (obj *{{.GoName}}) bindPath(path []string, value string) error {
+	if len(path) == 0 {
+		return fmt.Errorf("missing parameter name")
+	}
+	if len(path) == 1 {
+		switch path[0] {
+{{range .Parameters}}		case "{{.Name}}":
+{{if .IsList}}			return obj.Unmarshal{{.GoName}}(value)
+{{else if .Codec}}			return obj.Decode{{.GoName}}(value)
+{{else}}			obj.{{.GoName}} = value
+			return nil
+{{end}}{{end}}		}
+		return fmt.Errorf("unknown parameter %q", path[0])
+	}
+
+	switch path[0] {
+{{range .ChildObjects}}	case "{{.Name}}":
+{{if .IsMultiInstance}}		entry, err := bindInstance(&obj.{{.GoName}}, path[1], func(e *{{.StructName}}) *int { return &e.InstanceNumber })
+		if err != nil {
+			return err
+		}
+		return entry.bindPath(path[2:], value)
+{{else}}		return obj.{{.GoName}}.bindPath(path[1:], value)
+{{end}}{{end}}	}
+	return fmt.Errorf("unknown object %q", path[0])
+}
+
+// flatten appends the parameters of {{.GoName}} and its children under prefix to out
+func (obj *{{.GoName}}) flatten(prefix string, out []ParameterValueStruct) []ParameterValueStruct {
+{{range .Parameters}}{{if .IsList}}	out = append(out, parameterValue(prefix+"{{.Name}}", XsdString, MarshalList(obj.{{.GoName}}, Format{{.ListCodec}}ListItem)))
+{{else if .Codec}}	out = append(out, parameterValue(prefix+"{{.Name}}", {{.XsdType}}, obj.Encode{{.GoName}}()))
+{{else}}	out = append(out, parameterValue(prefix+"{{.Name}}", {{.XsdType}}, rawValue(obj.{{.GoName}})))
+{{end}}{{end}}{{range .ChildObjects}}{{if .IsMultiInstance}}	for i := range obj.{{.GoName}} {
+		out = obj.{{.GoName}}[i].flatten(instancePath(prefix, "{{.Name}}", obj.{{.GoName}}[i].InstanceNumber), out)
+	}
+{{else}}	out = obj.{{.GoName}}.flatten(prefix+"{{.Name}}.", out)
+{{end}}{{end}}	return out
+}
 `
 
 // TR-069 specific template for parameter accessors
@@ -258,6 +336,7 @@ import (
 	"github.com/jteeuwen/go-pkg-xmlx"
 	"regexp"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -269,6 +348,9 @@ const (
 	XsdBoolean  = "xsd:boolean"
 	XsdDateTime = "xsd:dateTime"
 	XsdBase64   = "xsd:base64"
+	XsdLong     = "xsd:long"
+	XsdUnsignedLong = "xsd:unsignedLong"
+	XsdHexBinary = "xsd:hexBinary"
 )
 
 // Message interface for CWMP messages
@@ -293,8 +375,9 @@ type Envelope struct {
 
 // HeaderStruct represents the SOAP header
 type HeaderStruct struct {
-	ID     IDStruct    ` + "`xml:\"cwmp:ID\"`" + `
-	NoMore interface{} ` + "`xml:\"cwmp:NoMoreRequests,omitempty\"`" + `
+	ID           IDStruct    ` + "`xml:\"cwmp:ID\"`" + `
+	NoMore       interface{} ` + "`xml:\"cwmp:NoMoreRequests,omitempty\"`" + `
+	HoldRequests interface{} ` + "`xml:\"cwmp:HoldRequests,omitempty\"`" + `
 }
 
 // IDStruct represents the ID in the SOAP header
@@ -347,6 +430,108 @@ type DeviceIDStruct struct {
 	SerialNumber string ` + "`xml:\"SerialNumber\"`" + `
 }
 
+// SplitList splits a TR-069 comma-separated list value into its items
+func SplitList(value string) []string {
+	if strings.TrimSpace(value) == "" {
+		return nil
+	}
+	items := strings.Split(value, ",")
+	for i := range items {
+		items[i] = strings.TrimSpace(items[i])
+	}
+	return items
+}
+
+// MarshalList encodes typed items as a TR-069 comma-separated list value
+func MarshalList[T any](items []T, format func(T) string) string {
+	parts := make([]string, 0, len(items))
+	for _, item := range items {
+		parts = append(parts, format(item))
+	}
+	return strings.Join(parts, ",")
+}
+
+// UnmarshalList decodes a TR-069 comma-separated list value into typed items
+func UnmarshalList[T any](value string, parse func(string) (T, error)) ([]T, error) {
+	parts := SplitList(value)
+	items := make([]T, 0, len(parts))
+	for i, part := range parts {
+		item, err := parse(part)
+		if err != nil {
+			return nil, fmt.Errorf("list item %d: %w", i+1, err)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// CheckList validates a list against its minItems, maxItems and maxLength
+// facets. A zero facet is treated as unbounded.
+func CheckList(value string, count, minItems, maxItems, maxLength int) error {
+	if count < minItems {
+		return fmt.Errorf("list has %d items, minimum is %d", count, minItems)
+	}
+	if maxItems > 0 && count > maxItems {
+		return fmt.Errorf("list has %d items, maximum is %d", count, maxItems)
+	}
+	if maxLength > 0 && len(value) > maxLength {
+		return fmt.Errorf("list is %d characters long, maximum is %d", len(value), maxLength)
+	}
+	return nil
+}
+
+// FormatStringListItem encodes a string list item
+func FormatStringListItem(v string) string {
+	return v
+}
+
+// ParseStringListItem decodes a string list item
+func ParseStringListItem(s string) (string, error) {
+	return s, nil
+}
+
+// FormatIntListItem encodes an integer list item
+func FormatIntListItem(v int) string {
+	return strconv.Itoa(v)
+}
+
+// ParseIntListItem decodes an integer list item
+func ParseIntListItem(s string) (int, error) {
+	n, err := strconv.ParseInt(s, 10, 32)
+	return int(n), err
+}
+
+// FormatUnsignedIntListItem encodes an unsigned integer list item
+func FormatUnsignedIntListItem(v uint32) string {
+	return strconv.FormatUint(uint64(v), 10)
+}
+
+// ParseUnsignedIntListItem decodes an unsigned integer list item
+func ParseUnsignedIntListItem(s string) (uint32, error) {
+	n, err := strconv.ParseUint(s, 10, 32)
+	return uint32(n), err
+}
+
+// FormatBoolListItem encodes a boolean list item
+func FormatBoolListItem(v bool) string {
+	return strconv.FormatBool(v)
+}
+
+// ParseBoolListItem decodes a boolean list item
+func ParseBoolListItem(s string) (bool, error) {
+	return DecodeBoolean(s)
+}
+
+// FormatTimeListItem encodes a dateTime list item
+func FormatTimeListItem(v time.Time) string {
+	return v.Format(time.RFC3339)
+}
+
+// ParseTimeListItem decodes a dateTime list item
+func ParseTimeListItem(s string) (time.Time, error) {
+	return time.Parse(time.RFC3339, s)
+}
+
 // ParseXML parses XML data into a Message
 func ParseXML(data []byte) (msg Message, err error) {
 	doc := xmlx.New()
@@ -413,6 +598,14 @@ type GoParameter struct {
 	GoType      string
 	GoTags      string
 	FullPath    string
+	XsdType     string // Generated xsi:type constant
+	Codec       string // Suffix of the Encode/Decode value helpers
+	DecodeArgs  string // Facet arguments passed to the Decode helper
+	IsList      bool
+	ListCodec   string // Suffix of the Format/Parse*ListItem helpers
+	MinItems    int
+	MaxItems    int
+	MaxLength   int
 }
 
 // GoChildObject represents a nested object in a Golang struct
@@ -421,6 +614,7 @@ type GoChildObject struct {
 	GoName          string
 	GoType          string
 	GoTags          string
+	StructName      string
 	IsMultiInstance bool
 	FullPath        string
 }
@@ -481,14 +675,175 @@ func GenerateGolang(model *models.DataModel, outputDir string) ([]string, error)
 	file.Close()
 	outputFiles = append(outputFiles, "tr069_helper.go")
 
+	// Generate value codecs and the model's parameter type table
+	valuesFile := filepath.Join(outputDir, "value_codec.go")
+	file, err = os.Create(valuesFile)
+	if err != nil {
+		return outputFiles, err
+	}
+
+	tmpl, err = template.New("value_codec").Parse(valueCodecTemplate)
+	if err != nil {
+		file.Close()
+		return outputFiles, err
+	}
+
+	valuesTmplData := struct {
+		PackageName string
+		Parameters  []GoParameterType
+	}{
+		PackageName: packageName,
+		Parameters:  collectGoParameterTypes(model),
+	}
+
+	if err := tmpl.Execute(file, valuesTmplData); err != nil {
+		file.Close()
+		return outputFiles, err
+	}
+	file.Close()
+	outputFiles = append(outputFiles, "value_codec.go")
+
 	// Create template functions for formatting comments
 	funcMap := template.FuncMap{
 		"formatComment": formatComment,
 	}
 
+	// Link the flat object list into a tree for the binder
+	tree := linkGoObjectTree(model)
+
+	binderFile := filepath.Join(outputDir, "binder.go")
+	file, err = os.Create(binderFile)
+	if err != nil {
+		return outputFiles, err
+	}
+
+	tmpl, err = template.New("binder").Parse(binderTemplate)
+	if err != nil {
+		file.Close()
+		return outputFiles, err
+	}
+
+	binderTmplData := struct {
+		PackageName string
+		Roots       []GoRootObject
+	}{
+		PackageName: packageName,
+		Roots:       collectGoRootObjects(model.Objects, tree),
+	}
+
+	if err := tmpl.Execute(file, binderTmplData); err != nil {
+		file.Close()
+		return outputFiles, err
+	}
+	file.Close()
+	outputFiles = append(outputFiles, "binder.go")
+
+	// Generate the search path evaluator over the object trees
+	searchFile := filepath.Join(outputDir, "search_path.go")
+	file, err = os.Create(searchFile)
+	if err != nil {
+		return outputFiles, err
+	}
+
+	tmpl, err = template.New("search_path").Parse(searchPathTemplate)
+	if err != nil {
+		file.Close()
+		return outputFiles, err
+	}
+
+	if err := tmpl.Execute(file, binderTmplData); err != nil {
+		file.Close()
+		return outputFiles, err
+	}
+	file.Close()
+	outputFiles = append(outputFiles, "search_path.go")
+
+	// Generate the ACS session handler
+	acsFile := filepath.Join(outputDir, "acs_session.go")
+	file, err = os.Create(acsFile)
+	if err != nil {
+		return outputFiles, err
+	}
+
+	tmpl, err = template.New("acs_session").Parse(acsSessionTemplate)
+	if err != nil {
+		file.Close()
+		return outputFiles, err
+	}
+
+	if err := tmpl.Execute(file, commonTmplData); err != nil {
+		file.Close()
+		return outputFiles, err
+	}
+	file.Close()
+	outputFiles = append(outputFiles, "acs_session.go")
+
+	// Generate HTTP authentication for ACS sessions and Connection Requests
+	authFile := filepath.Join(outputDir, "cwmp_auth.go")
+	file, err = os.Create(authFile)
+	if err != nil {
+		return outputFiles, err
+	}
+
+	tmpl, err = template.New("cwmp_auth").Parse(cwmpAuthTemplate)
+	if err != nil {
+		file.Close()
+		return outputFiles, err
+	}
+
+	authTmplData := struct {
+		PackageName       string
+		ManagementServers []GoManagementServer
+	}{
+		PackageName:       packageName,
+		ManagementServers: collectGoManagementServers(model, tree, binderTmplData.Roots),
+	}
+
+	if err := tmpl.Execute(file, authTmplData); err != nil {
+		file.Close()
+		return outputFiles, err
+	}
+	file.Close()
+	outputFiles = append(outputFiles, "cwmp_auth.go")
+
+	// Generate typed forced inform parameters and event codes
+	informFile := filepath.Join(outputDir, "inform_params.go")
+	file, err = os.Create(informFile)
+	if err != nil {
+		return outputFiles, err
+	}
+
+	tmpl, err = template.New("inform").Parse(informTemplate)
+	if err != nil {
+		file.Close()
+		return outputFiles, err
+	}
+
+	informParams := collectGoInformParameters(model)
+	informTmplData := struct {
+		PackageName string
+		Parameters  []GoInformParameter
+		NeedsTime   bool
+	}{
+		PackageName: packageName,
+		Parameters:  informParams,
+	}
+	for _, param := range informParams {
+		informTmplData.NeedsTime = informTmplData.NeedsTime || param.GoType == "time.Time"
+	}
+
+	if err := tmpl.Execute(file, informTmplData); err != nil {
+		file.Close()
+		return outputFiles, err
+	}
+	file.Close()
+	outputFiles = append(outputFiles, "inform_params.go")
+
 	// Generate a separate file for each object
+	dataTypes := modelDataTypes(model)
 	for _, obj := range model.Objects {
-		goObj := convertObjectToGoStruct(obj)
+		goObj := convertObjectToGoStruct(obj, dataTypes)
+		goObj.ChildObjects = tree[objectPathPrefix(obj)]
 		fileName := goObj.GoName + ".go"
 		outputFile := filepath.Join(outputDir, fileName)
 
@@ -510,17 +865,21 @@ func GenerateGolang(model *models.DataModel, outputDir string) ([]string, error)
 
 		// Create a simple template data with just this object
 		tmplData := struct {
-			PackageName string
-			GoName      string
-			LowerName   string
-			Description string
-			Parameters  []GoParameter
+			PackageName  string
+			GoName       string
+			LowerName    string
+			Description  string
+			Parameters   []GoParameter
+			ChildObjects []GoChildObject
+			IsTable      bool
 		}{
-			PackageName: packageName,
-			GoName:      goObj.GoName,
-			LowerName:   goObj.LowerName,
-			Description: goObj.Description,
-			Parameters:  goObj.Parameters,
+			PackageName:  packageName,
+			GoName:       goObj.GoName,
+			LowerName:    goObj.LowerName,
+			Description:  goObj.Description,
+			Parameters:   goObj.Parameters,
+			ChildObjects: goObj.ChildObjects,
+			IsTable:      strings.HasSuffix(objectPathPrefix(obj), ".{i}."),
 		}
 
 		if err := tmpl.Execute(file, tmplData); err != nil {
@@ -559,7 +918,7 @@ func formatComment(comment string) string {
 }
 
 // convertObjectToGoStruct converts a CWMP object to a Golang struct
-func convertObjectToGoStruct(obj models.Object) GoObject {
+func convertObjectToGoStruct(obj models.Object, dataTypes map[string]models.DataType) GoObject {
 	// Create basic Go object with path information from enhanced object model
 	goObj := GoObject{
 		Name:            obj.Name,
@@ -590,6 +949,12 @@ func convertObjectToGoStruct(obj models.Object) GoObject {
 			GoType:      mapCWMPTypeToGoType(param.Type),
 			GoTags:      fmt.Sprintf("`xml:\"%s,omitempty\"`", param.Name),
 			FullPath:    param.GetFullPath(),
+			XsdType:     mapCWMPTypeToXsdConst(param.Type),
+		}
+		if param.IsList {
+			applyGoListFacets(&goParam, param, dataTypes)
+		} else {
+			applyGoValueCodec(&goParam, param, dataTypes)
 		}
 		goObj.Parameters = append(goObj.Parameters, goParam)
 
@@ -628,13 +993,19 @@ func mapCWMPTypeToGoType(cwmpType string) string {
 	switch strings.ToLower(cwmpType) {
 	case "string":
 		return "string"
-	case "int", "integer", "unsignedint", "unsignedinteger":
+	case "int", "integer":
 		return "int"
+	case "unsignedint", "unsignedinteger":
+		return "uint32"
+	case "long":
+		return "int64"
+	case "unsignedlong":
+		return "uint64"
 	case "boolean", "bool":
 		return "bool"
 	case "datetime":
 		return "time.Time"
-	case "base64":
+	case "base64", "hexbinary":
 		return "[]byte"
 	case "list":
 		return "[]string"
@@ -643,6 +1014,46 @@ func mapCWMPTypeToGoType(cwmpType string) string {
 	}
 }
 
+// applyGoListFacets types a list parameter as a slice of the built-in type
+// of its items and records the list facets used by the generated CSV helpers
+func applyGoListFacets(goParam *GoParameter, param models.Parameter, dataTypes map[string]models.DataType) {
+	itemType := mapCWMPTypeToGoType(models.ResolveSyntax(param, dataTypes).Type)
+	switch itemType {
+	case "int":
+		goParam.ListCodec = "Int"
+	case "uint32":
+		goParam.ListCodec = "UnsignedInt"
+	case "bool":
+		goParam.ListCodec = "Bool"
+	case "time.Time":
+		goParam.ListCodec = "Time"
+	default:
+		itemType = "string"
+		goParam.ListCodec = "String"
+	}
+
+	goParam.GoType = "[]" + itemType
+	goParam.IsList = true
+
+	if list := param.Syntax.List; list != nil {
+		goParam.MinItems = parseFacet(list.MinItems)
+		goParam.MaxItems = parseFacet(list.MaxItems)
+		if list.Size != nil {
+			goParam.MaxLength = list.Size.Max
+		}
+	}
+}
+
+// parseFacet converts a numeric facet attribute to an int, treating empty
+// and "unbounded" values as 0
+func parseFacet(value string) int {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
 // Helper functions
 func sanitize(name string) string {
 	// Handle TR-069 object paths properly
@@ -660,6 +1071,29 @@ func sanitize(name string) string {
 	return name
 }
 
+// snakeCase converts a CWMP name to snake_case, splitting words at case
+// changes while keeping acronyms such as IPv4 together
+func snakeCase(name string) string {
+	runes := []rune(sanitize(name))
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 && runes[i-1] != '_' {
+			prev := runes[i-1]
+			nextLower := i+2 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsLower(runes[i+2])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+
+	snake := b.String()
+	if snake != "" && unicode.IsDigit(rune(snake[0])) {
+		snake = "_" + snake
+	}
+	return snake
+}
+
 // sanitizeGoComment properly formats a comment for Go code
 func sanitizeGoComment(comment string) string {
 	if comment == "" {
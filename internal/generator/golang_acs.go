@@ -0,0 +1,552 @@
+package generator
+
+// ACS session template implementing the server side of CWMP sessions as an
+// http.Handler that sends queued messages to devices
+const acsSessionTemplate = `// Code generated by cwmp-codegen. DO NOT EDIT.
+package {{.PackageName}}
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SessionCookie is the name of the cookie that tracks ACS sessions
+const SessionCookie = "cwmp-session"
+
+// ErrSessionClosed is passed to the Done callback of an RPC whose session
+// ended before the device answered it
+var ErrSessionClosed = errors.New("session closed before the device responded")
+
+// SessionInform is the Inform that opened an ACS session
+type SessionInform struct {
+	DeviceID     DeviceIDStruct
+	Events       map[string]string // Event code to command key
+	MaxEnvelopes int
+	CurrentTime  string
+	RetryCount   int
+	Params       map[string]string
+}
+
+// HasEvent reports whether the Inform reports an event, such as EventBoot
+func (inform *SessionInform) HasEvent(code string) bool {
+	_, ok := inform.Events[code]
+	return ok
+}
+
+// DeviceKey identifies a device by OUI, product class and serial number,
+// as in the ID of its TR-069 device record
+func DeviceKey(id DeviceIDStruct) string {
+	if id.ProductClass == "" {
+		return id.OUI + "-" + id.SerialNumber
+	}
+	return id.OUI + "-" + id.ProductClass + "-" + id.SerialNumber
+}
+
+// SessionFault is the CWMP fault of a SOAP fault
+type SessionFault struct {
+	FaultCode                int                             ` + "`xml:\"FaultCode\"`" + `
+	FaultString              string                          ` + "`xml:\"FaultString\"`" + `
+	SetParameterValuesFaults []SetParameterValuesFaultStruct ` + "`xml:\"SetParameterValuesFault\"`" + `
+}
+
+// SetParameterValuesFaultStruct is the fault of a single parameter of
+// SetParameterValues
+type SetParameterValuesFaultStruct struct {
+	ParameterName string ` + "`xml:\"ParameterName\"`" + `
+	FaultCode     int    ` + "`xml:\"FaultCode\"`" + `
+	FaultString   string ` + "`xml:\"FaultString\"`" + `
+}
+
+// Error implements the error interface
+func (f *SessionFault) Error() string {
+	return fmt.Sprintf("CWMP fault %d: %s", f.FaultCode, f.FaultString)
+}
+
+// RPCResponse is the answer of a device to a queued RPC
+type RPCResponse struct {
+	ID     string
+	Method string // Such as GetParameterValuesResponse, or Fault
+	Body   []byte // The whole SOAP envelope, as accepted by ParseXML
+}
+
+// QueuedRPC is a message waiting to be sent to a device
+type QueuedRPC struct {
+	Request Message
+	// Done, if set, receives the response of the device. The error is a
+	// *SessionFault when the device answered with a fault, or
+	// ErrSessionClosed when the session ended first.
+	Done func(response *RPCResponse, err error)
+}
+
+// done calls the Done callback, if any
+func (rpc *QueuedRPC) done(response *RPCResponse, err error) {
+	if rpc.Done != nil {
+		rpc.Done(response, err)
+	}
+}
+
+// RPCQueue holds the RPCs waiting for each device, keyed by DeviceKey.
+// Implementations must be safe for concurrent use.
+type RPCQueue interface {
+	// Push queues an RPC for a device
+	Push(deviceKey string, rpc *QueuedRPC)
+	// Pop removes and returns the next RPC for a device, or nil
+	Pop(deviceKey string) *QueuedRPC
+}
+
+// MemoryRPCQueue is an in-memory RPCQueue
+type MemoryRPCQueue struct {
+	mu     sync.Mutex
+	queues map[string][]*QueuedRPC
+}
+
+// NewMemoryRPCQueue creates an empty in-memory RPCQueue
+func NewMemoryRPCQueue() *MemoryRPCQueue {
+	return &MemoryRPCQueue{queues: make(map[string][]*QueuedRPC)}
+}
+
+// Push queues an RPC for a device
+func (q *MemoryRPCQueue) Push(deviceKey string, rpc *QueuedRPC) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.queues[deviceKey] = append(q.queues[deviceKey], rpc)
+}
+
+// Pop removes and returns the next RPC for a device, or nil
+func (q *MemoryRPCQueue) Pop(deviceKey string) *QueuedRPC {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	queue := q.queues[deviceKey]
+	if len(queue) == 0 {
+		return nil
+	}
+	rpc := queue[0]
+	if len(queue) == 1 {
+		delete(q.queues, deviceKey)
+	} else {
+		q.queues[deviceKey] = queue[1:]
+	}
+	return rpc
+}
+
+// ACSSession is a CWMP session opened by the Inform of a device. The
+// requests of a session are handled one at a time.
+type ACSSession struct {
+	ID             string
+	DeviceKey      string
+	Username       string // User authenticated by the ACSHandler's Auth
+	Inform         *SessionInform
+	NoMoreRequests bool // The device announced it has no requests to send
+
+	// mu serializes the requests of the session and its closing
+	mu       sync.Mutex
+	cpeDone  bool       // The device sent its empty POST
+	pending  *QueuedRPC // RPC sent to the device and not yet answered
+	closed   bool
+	lastSeen time.Time // Guarded by the ACSHandler's mu
+}
+
+// ACSHandler is an http.Handler running the ACS side of CWMP sessions. A
+// session starts with the Inform of a device, answered with an
+// InformResponse. The device then sends its own requests, such as
+// TransferComplete, until it sends an empty POST, after which the handler
+// sends the RPCs queued for the device one by one. The session ends with an
+// empty response once the queue is empty.
+type ACSHandler struct {
+	Queue RPCQueue
+	// Auth, if set, authenticates the request opening each session; the
+	// session cookie authenticates the rest of the session
+	Auth *HTTPAuth
+	// HoldRequests asks devices not to send requests before the queued RPCs
+	HoldRequests bool
+	// SessionTimeout closes sessions idle for longer, 30s by default
+	SessionTimeout time.Duration
+	// OnInform, if set, is called for each Inform before the InformResponse
+	// is sent, and may queue RPCs for the device
+	OnInform func(session *ACSSession)
+	// OnRequest, if set, is called for each request of a device, such as
+	// TransferComplete, with the whole SOAP envelope
+	OnRequest func(session *ACSSession, method string, body []byte)
+	// OnSessionEnd, if set, is called when a session ends or expires
+	OnSessionEnd func(session *ACSSession)
+
+	mu       sync.Mutex
+	sessions map[string]*ACSSession
+}
+
+// NewACSHandler creates an ACSHandler sending the RPCs of a queue
+func NewACSHandler(queue RPCQueue) *ACSHandler {
+	return &ACSHandler{Queue: queue, sessions: make(map[string]*ACSSession)}
+}
+
+// sessionEnvelope is a SOAP envelope received from a device. Elements are
+// matched by local name, whatever prefixes the device uses.
+type sessionEnvelope struct {
+	Header struct {
+		ID             string ` + "`xml:\"ID\"`" + `
+		NoMoreRequests string ` + "`xml:\"NoMoreRequests\"`" + `
+	} ` + "`xml:\"Header\"`" + `
+	Body struct {
+		Inform *sessionInformBody ` + "`xml:\"Inform\"`" + `
+		Fault  *struct {
+			Detail struct {
+				Fault SessionFault ` + "`xml:\"Fault\"`" + `
+			} ` + "`xml:\"detail\"`" + `
+		} ` + "`xml:\"Fault\"`" + `
+		Content []byte ` + "`xml:\",innerxml\"`" + `
+	} ` + "`xml:\"Body\"`" + `
+}
+
+type sessionInformBody struct {
+	DeviceID DeviceIDStruct ` + "`xml:\"DeviceId\"`" + `
+	Events   []struct {
+		EventCode  string ` + "`xml:\"EventCode\"`" + `
+		CommandKey string ` + "`xml:\"CommandKey\"`" + `
+	} ` + "`xml:\"Event>EventStruct\"`" + `
+	MaxEnvelopes int    ` + "`xml:\"MaxEnvelopes\"`" + `
+	CurrentTime  string ` + "`xml:\"CurrentTime\"`" + `
+	RetryCount   int    ` + "`xml:\"RetryCount\"`" + `
+	Params       []struct {
+		Name  string ` + "`xml:\"Name\"`" + `
+		Value string ` + "`xml:\"Value\"`" + `
+	} ` + "`xml:\"ParameterList>ParameterValueStruct\"`" + `
+}
+
+// method returns the name of the first element of the SOAP body
+func (env *sessionEnvelope) method() string {
+	decoder := xml.NewDecoder(bytes.NewReader(env.Body.Content))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return ""
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name.Local
+		}
+	}
+}
+
+// sessionInform converts the Inform of the envelope
+func (env *sessionEnvelope) sessionInform() *SessionInform {
+	body := env.Body.Inform
+	inform := &SessionInform{
+		DeviceID:     body.DeviceID,
+		Events:       make(map[string]string),
+		MaxEnvelopes: body.MaxEnvelopes,
+		CurrentTime:  body.CurrentTime,
+		RetryCount:   body.RetryCount,
+		Params:       make(map[string]string),
+	}
+	for _, event := range body.Events {
+		inform.Events[strings.TrimSpace(event.EventCode)] = event.CommandKey
+	}
+	for _, param := range body.Params {
+		inform.Params[param.Name] = param.Value
+	}
+	return inform
+}
+
+// ServeHTTP handles a POST of a device within a CWMP session
+func (h *ACSHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	session := h.session(r)
+	if session != nil {
+		// Another request of the session may have ended it while this one
+		// waited for the lock
+		locked := session
+		locked.mu.Lock()
+		defer locked.mu.Unlock()
+		if locked.closed {
+			session = nil
+		}
+	}
+
+	username := ""
+	if h.Auth != nil && session == nil {
+		var ok bool
+		if username, ok = h.Auth.Authenticate(r); !ok {
+			h.Auth.Challenge(w)
+			return
+		}
+	}
+
+	// An empty POST ends the requests of the device
+	if len(bytes.TrimSpace(body)) == 0 {
+		if session == nil {
+			http.Error(w, "no session", http.StatusBadRequest)
+			return
+		}
+		session.cpeDone = true
+		if session.pending != nil {
+			session.pending.done(nil, errors.New("device sent an empty POST instead of a response"))
+			session.pending = nil
+		}
+		h.sendNext(w, session)
+		return
+	}
+
+	var env sessionEnvelope
+	if err := xml.Unmarshal(body, &env); err != nil {
+		http.Error(w, "invalid SOAP envelope: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	method := env.method()
+
+	switch {
+	case method == "Inform":
+		if env.Body.Inform == nil {
+			http.Error(w, "invalid Inform", http.StatusBadRequest)
+			return
+		}
+		if session != nil {
+			h.endSession(session)
+		}
+		session, err = h.startSession(w, env.sessionInform())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer session.mu.Unlock()
+		session.Username = username
+		session.NoMoreRequests = env.Header.NoMoreRequests == "1" || env.Header.NoMoreRequests == "true"
+		if h.OnInform != nil {
+			h.OnInform(session)
+		}
+		h.writeMessage(w, env.Header.ID, h.HoldRequests, informResponseBody{MaxEnvelopes: 1})
+
+	case session == nil:
+		http.Error(w, "no session", http.StatusBadRequest)
+
+	case session.pending != nil && (method == "Fault" || strings.HasSuffix(method, "Response")):
+		rpc := session.pending
+		if id := rpc.Request.GetID(); id != "" && env.Header.ID != id {
+			h.writeMessage(w, env.Header.ID, false, acsFault(8003, "Response ID "+env.Header.ID+" does not match request ID "+id))
+			return
+		}
+		session.pending = nil
+		response := &RPCResponse{ID: env.Header.ID, Method: method, Body: body}
+		if env.Body.Fault != nil {
+			fault := env.Body.Fault.Detail.Fault
+			rpc.done(response, &fault)
+		} else {
+			rpc.done(response, nil)
+		}
+		h.sendNext(w, session)
+
+	case !session.cpeDone:
+		if h.OnRequest != nil {
+			h.OnRequest(session, method, body)
+		}
+		h.writeMessage(w, env.Header.ID, h.HoldRequests, cpeRequestResponse(method))
+
+	default:
+		http.Error(w, "unexpected "+method, http.StatusBadRequest)
+	}
+}
+
+// session returns the session of the request's cookie, closing expired
+// sessions first
+func (h *ACSHandler) session(r *http.Request) *ACSSession {
+	timeout := h.SessionTimeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+
+	h.mu.Lock()
+	if h.sessions == nil {
+		h.sessions = make(map[string]*ACSSession)
+	}
+	expired := []*ACSSession{}
+	for id, session := range h.sessions {
+		if time.Since(session.lastSeen) > timeout {
+			delete(h.sessions, id)
+			expired = append(expired, session)
+		}
+	}
+	var session *ACSSession
+	if cookie, err := r.Cookie(SessionCookie); err == nil {
+		session = h.sessions[cookie.Value]
+	}
+	if session != nil {
+		session.lastSeen = time.Now()
+	}
+	h.mu.Unlock()
+
+	for _, s := range expired {
+		s.mu.Lock()
+		h.closeSession(s)
+		s.mu.Unlock()
+	}
+	return session
+}
+
+// startSession registers a session for an Inform and sets its cookie. The
+// session is returned locked.
+func (h *ACSHandler) startSession(w http.ResponseWriter, inform *SessionInform) (*ACSSession, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	session := &ACSSession{
+		ID:        hex.EncodeToString(id),
+		DeviceKey: DeviceKey(inform.DeviceID),
+		Inform:    inform,
+		lastSeen:  time.Now(),
+	}
+	session.mu.Lock()
+
+	h.mu.Lock()
+	h.sessions[session.ID] = session
+	h.mu.Unlock()
+
+	http.SetCookie(w, &http.Cookie{Name: SessionCookie, Value: session.ID, Path: "/", HttpOnly: true})
+	return session, nil
+}
+
+// endSession removes a locked session and closes it
+func (h *ACSHandler) endSession(session *ACSSession) {
+	h.mu.Lock()
+	delete(h.sessions, session.ID)
+	h.mu.Unlock()
+	h.closeSession(session)
+}
+
+// closeSession fails the unanswered RPC of a removed, locked session and
+// reports its end
+func (h *ACSHandler) closeSession(session *ACSSession) {
+	if session.closed {
+		return
+	}
+	session.closed = true
+	if session.pending != nil {
+		session.pending.done(nil, ErrSessionClosed)
+		session.pending = nil
+	}
+	if h.OnSessionEnd != nil {
+		h.OnSessionEnd(session)
+	}
+}
+
+// sendNext sends the next queued RPC of the device, or ends the session
+// with an empty response
+func (h *ACSHandler) sendNext(w http.ResponseWriter, session *ACSSession) {
+	for {
+		rpc := h.Queue.Pop(session.DeviceKey)
+		if rpc == nil {
+			h.endSession(session)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		data, err := rpc.Request.CreateXML()
+		if err != nil {
+			rpc.done(nil, err)
+			continue
+		}
+		session.pending = rpc
+		w.Header().Set("Content-Type", ` + "`text/xml; charset=\"utf-8\"`" + `)
+		w.Write(data)
+		return
+	}
+}
+
+// writeMessage writes a SOAP envelope answering the message with the given ID
+func (h *ACSHandler) writeMessage(w http.ResponseWriter, id string, holdRequests bool, body interface{}) {
+	env := Envelope{}
+	env.XmlnsEnv = "http://schemas.xmlsoap.org/soap/envelope/"
+	env.XmlnsEnc = "http://schemas.xmlsoap.org/soap/encoding/"
+	env.XmlnsXsd = "http://www.w3.org/2001/XMLSchema"
+	env.XmlnsXsi = "http://www.w3.org/2001/XMLSchema-instance"
+	env.XmlnsCwmp = "urn:dslforum-org:cwmp-1-0"
+	env.Header = HeaderStruct{ID: IDStruct{Attr: "1", Value: id}}
+	if holdRequests {
+		env.Header.HoldRequests = IDStruct{Attr: "1", Value: "1"}
+	}
+	env.Body = body
+
+	output, err := xml.MarshalIndent(env, "  ", "    ")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", ` + "`text/xml; charset=\"utf-8\"`" + `)
+	w.Write(output)
+}
+
+type informResponseBody struct {
+	MaxEnvelopes int ` + "`xml:\"cwmp:InformResponse>MaxEnvelopes\"`" + `
+}
+
+type transferCompleteResponseBody struct {
+	Response struct{} ` + "`xml:\"cwmp:TransferCompleteResponse\"`" + `
+}
+
+type autonomousTransferCompleteResponseBody struct {
+	Response struct{} ` + "`xml:\"cwmp:AutonomousTransferCompleteResponse\"`" + `
+}
+
+type getRPCMethodsResponseBody struct {
+	Methods struct {
+		Type    string   ` + "`xml:\"SOAP-ENC:arrayType,attr\"`" + `
+		Strings []string ` + "`xml:\"string\"`" + `
+	} ` + "`xml:\"cwmp:GetRPCMethodsResponse>MethodList\"`" + `
+}
+
+type faultBody struct {
+	Fault struct {
+		FaultCode   string ` + "`xml:\"faultcode\"`" + `
+		FaultString string ` + "`xml:\"faultstring\"`" + `
+		Detail      struct {
+			Fault SessionFault ` + "`xml:\"cwmp:Fault\"`" + `
+		} ` + "`xml:\"detail\"`" + `
+	} ` + "`xml:\"SOAP-ENV:Fault\"`" + `
+}
+
+// acsMethods lists the methods the ACS answers
+var acsMethods = []string{"Inform", "GetRPCMethods", "TransferComplete", "AutonomousTransferComplete"}
+
+// cpeRequestResponse returns the body answering a request of a device
+func cpeRequestResponse(method string) interface{} {
+	switch method {
+	case "GetRPCMethods":
+		body := getRPCMethodsResponseBody{}
+		body.Methods.Type = "xsd:string[" + strconv.Itoa(len(acsMethods)) + "]"
+		body.Methods.Strings = acsMethods
+		return body
+	case "TransferComplete":
+		return transferCompleteResponseBody{}
+	case "AutonomousTransferComplete":
+		return autonomousTransferCompleteResponseBody{}
+	}
+
+	return acsFault(8000, "Method not supported: "+method)
+}
+
+// acsFault returns the body of an ACS fault, such as 8000 for unsupported
+// methods or 8003 for invalid arguments
+func acsFault(code int, message string) faultBody {
+	body := faultBody{}
+	body.Fault.FaultCode = "Client"
+	body.Fault.FaultString = "CWMP fault"
+	body.Fault.Detail.Fault = SessionFault{FaultCode: code, FaultString: message}
+	return body
+}
+`
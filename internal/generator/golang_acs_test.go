@@ -0,0 +1,247 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Niceblueman/cwmp-codegen/internal/models"
+)
+
+func TestGenerateGolangACSSession(t *testing.T) {
+	model := &models.DataModel{
+		Name:    "Device:2.16",
+		Objects: []models.Object{{Name: "Device.", Path: "Device."}},
+	}
+
+	tmpDir := t.TempDir()
+	files, err := GenerateGolang(model, tmpDir)
+	if err != nil {
+		t.Fatalf("GenerateGolang returned error: %v", err)
+	}
+	if !contains(files, "acs_session.go") {
+		t.Fatalf("Expected acs_session.go in %v", files)
+	}
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, "acs_session.go"))
+	if err != nil {
+		t.Fatalf("Failed to read acs_session.go: %v", err)
+	}
+
+	for _, expected := range []string{
+		"package messages",
+		"type RPCQueue interface {",
+		"func NewMemoryRPCQueue() *MemoryRPCQueue {",
+		"func NewACSHandler(queue RPCQueue) *ACSHandler {",
+		"func (h *ACSHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {",
+		"func DeviceKey(id DeviceIDStruct) string {",
+		"data, err := rpc.Request.CreateXML()",
+		"env.Header.HoldRequests = IDStruct{Attr: \"1\", Value: \"1\"}",
+		"Response struct{} `xml:\"cwmp:TransferCompleteResponse\"`",
+		"return acsFault(8000, \"Method not supported: \"+method)",
+	} {
+		if !strings.Contains(string(content), expected) {
+			t.Errorf("Expected acs_session.go to contain %q", expected)
+		}
+	}
+
+	common, err := os.ReadFile(filepath.Join(tmpDir, "common_types.go"))
+	if err != nil {
+		t.Fatalf("Failed to read common_types.go: %v", err)
+	}
+	if !strings.Contains(string(common), "HoldRequests interface{} `xml:\"cwmp:HoldRequests,omitempty\"`") {
+		t.Error("Expected the SOAP header to carry HoldRequests")
+	}
+}
+
+func TestGolangACSSessionRuns(t *testing.T) {
+	model := &models.DataModel{
+		Name:    "Device:2.16",
+		Objects: []models.Object{{Name: "Device.", Path: "Device."}},
+	}
+
+	runGeneratedGoTests(t, GenerateGolang, model, map[string]string{"acs_test.go": `package messages
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	xmlx "github.com/jteeuwen/go-pkg-xmlx"
+)
+
+type rpcMessage struct{ id, name string }
+
+func (m *rpcMessage) GetID() string                  { return m.id }
+func (m *rpcMessage) GetName() string                { return m.name }
+func (m *rpcMessage) Parse(doc *xmlx.Document) error { return nil }
+func (m *rpcMessage) CreateXML() ([]byte, error) {
+	return []byte(envelope(m.id, "<cwmp:"+m.name+"/>")), nil
+}
+
+func envelope(id, body string) string {
+	return ` + "`" + `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/" xmlns:cwmp="urn:dslforum-org:cwmp-1-0"><soap:Header><cwmp:ID soap:mustUnderstand="1">` + "`" + ` + id + ` + "`" + `</cwmp:ID></soap:Header><soap:Body>` + "`" + ` + body + ` + "`" + `</soap:Body></soap:Envelope>` + "`" + `
+}
+
+const informBody = ` + "`" + `<cwmp:Inform><DeviceId><Manufacturer>ACME</Manufacturer><OUI>00D09E</OUI><ProductClass>IGD</ProductClass><SerialNumber>SN1</SerialNumber></DeviceId><Event><EventStruct><EventCode>1 BOOT</EventCode><CommandKey></CommandKey></EventStruct></Event><MaxEnvelopes>1</MaxEnvelopes><CurrentTime>2024-01-01T00:00:00Z</CurrentTime><RetryCount>0</RetryCount><ParameterList><ParameterValueStruct><Name>Device.DeviceInfo.SoftwareVersion</Name><Value>1.0</Value></ParameterValueStruct></ParameterList></cwmp:Inform>` + "`" + `
+
+// device posts to the ACS with its own session cookie
+type device struct {
+	t      *testing.T
+	url    string
+	client *http.Client
+}
+
+func newDevice(t *testing.T, url string) *device {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &device{t: t, url: url, client: &http.Client{Jar: jar}}
+}
+
+func (d *device) post(body string) (int, string) {
+	d.t.Helper()
+	resp, err := d.client.Post(d.url, "text/xml", strings.NewReader(body))
+	if err != nil {
+		d.t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		d.t.Fatal(err)
+	}
+	return resp.StatusCode, string(data)
+}
+
+func TestSession(t *testing.T) {
+	queue := NewMemoryRPCQueue()
+	handler := NewACSHandler(queue)
+	handler.HoldRequests = true
+
+	var mu sync.Mutex
+	results := []string{}
+	record := func(response *RPCResponse, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		method := ""
+		if response != nil {
+			method = response.Method
+		}
+		results = append(results, fmt.Sprintf("%s %v", method, err))
+	}
+	handler.OnInform = func(session *ACSSession) {
+		if session.DeviceKey != "00D09E-IGD-SN1" || !session.Inform.HasEvent("1 BOOT") || session.Inform.Params["Device.DeviceInfo.SoftwareVersion"] != "1.0" {
+			t.Errorf("Unexpected Inform %+v", session.Inform)
+		}
+		queue.Push(session.DeviceKey, &QueuedRPC{Request: &rpcMessage{"a", "GetParameterValues"}, Done: record})
+		queue.Push(session.DeviceKey, &QueuedRPC{Request: &rpcMessage{"b", "SetParameterValues"}, Done: record})
+	}
+	ended := 0
+	handler.OnSessionEnd = func(session *ACSSession) { ended++ }
+
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	cpe := newDevice(t, server.URL)
+
+	code, body := cpe.post(envelope("42", informBody))
+	if code != http.StatusOK || !strings.Contains(body, "<cwmp:InformResponse>") || !strings.Contains(body, ">42</cwmp:ID>") || !strings.Contains(body, "HoldRequests") {
+		t.Fatalf("Unexpected InformResponse %d: %s", code, body)
+	}
+	code, body = cpe.post(envelope("43", "<cwmp:TransferComplete><CommandKey>k</CommandKey></cwmp:TransferComplete>"))
+	if !strings.Contains(body, "cwmp:TransferCompleteResponse") {
+		t.Fatalf("Unexpected TransferCompleteResponse %d: %s", code, body)
+	}
+
+	code, body = cpe.post("")
+	if code != http.StatusOK || !strings.Contains(body, "<cwmp:GetParameterValues/>") || !strings.Contains(body, ">a</cwmp:ID>") {
+		t.Fatalf("Expected the first queued RPC, got %d: %s", code, body)
+	}
+
+	// A response to another request is rejected and the RPC stays pending
+	code, body = cpe.post(envelope("z", "<cwmp:GetParameterValuesResponse/>"))
+	if !strings.Contains(body, "<FaultCode>8003</FaultCode>") {
+		t.Fatalf("Expected a fault for a mismatched ID, got %d: %s", code, body)
+	}
+	code, body = cpe.post(envelope("a", "<soap:Fault><faultcode>Client</faultcode><detail><cwmp:Fault><FaultCode>9005</FaultCode><FaultString>Invalid parameter name</FaultString></cwmp:Fault></detail></soap:Fault>"))
+	if !strings.Contains(body, "<cwmp:SetParameterValues/>") {
+		t.Fatalf("Expected the second queued RPC, got %d: %s", code, body)
+	}
+	code, body = cpe.post(envelope("b", "<cwmp:SetParameterValuesResponse><Status>0</Status></cwmp:SetParameterValuesResponse>"))
+	if code != http.StatusNoContent {
+		t.Fatalf("Expected the session to end, got %d: %s", code, body)
+	}
+
+	want := []string{"Fault CWMP fault 9005: Invalid parameter name", "SetParameterValuesResponse <nil>"}
+	if strings.Join(results, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected results %q, got %q", want, results)
+	}
+	if ended != 1 {
+		t.Errorf("Expected one ended session, got %d", ended)
+	}
+	if code, _ := cpe.post(""); code != http.StatusBadRequest {
+		t.Errorf("Expected no session after the end, got %d", code)
+	}
+}
+
+func TestConcurrentResponses(t *testing.T) {
+	queue := NewMemoryRPCQueue()
+	handler := NewACSHandler(queue)
+
+	var mu sync.Mutex
+	calls := 0
+	handler.OnInform = func(session *ACSSession) {
+		queue.Push(session.DeviceKey, &QueuedRPC{Request: &rpcMessage{"a", "Reboot"}, Done: func(*RPCResponse, error) {
+			mu.Lock()
+			calls++
+			mu.Unlock()
+		}})
+	}
+
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	cpe := newDevice(t, server.URL)
+	cpe.post(envelope("1", informBody))
+	if _, body := cpe.post(""); !strings.Contains(body, "<cwmp:Reboot/>") {
+		t.Fatalf("Expected the queued Reboot, got %s", body)
+	}
+
+	// The same answer posted twice with one cookie completes the RPC once
+	var wg sync.WaitGroup
+	codes := make([]int, 8)
+	for i := range codes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := cpe.client.Post(server.URL, "text/xml", strings.NewReader(envelope("a", "<cwmp:RebootResponse/>")))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			resp.Body.Close()
+			codes[i] = resp.StatusCode
+		}()
+	}
+	wg.Wait()
+
+	if calls != 1 {
+		t.Errorf("Expected one Done call, got %d", calls)
+	}
+	ended := 0
+	for _, code := range codes {
+		if code == http.StatusNoContent {
+			ended++
+		}
+	}
+	if ended != 1 {
+		t.Errorf("Expected one response to end the session, got codes %v", codes)
+	}
+}
+`})
+}
@@ -0,0 +1,454 @@
+package generator
+
+import (
+	"github.com/Niceblueman/cwmp-codegen/internal/models"
+)
+
+// GoManagementServer describes the ManagementServer object of a root object,
+// whose parameters hold the credentials of CWMP sessions and Connection
+// Requests. Field names are empty for parameters the model lacks.
+type GoManagementServer struct {
+	RootGoName                string
+	Path                      string
+	Username                  string
+	Password                  string
+	ConnectionRequestURL      string
+	ConnectionRequestUsername string
+	ConnectionRequestPassword string
+}
+
+// collectGoManagementServers finds the string-typed credential parameters of
+// the ManagementServer object below each root object
+func collectGoManagementServers(model *models.DataModel, tree map[string][]GoChildObject, roots []GoRootObject) []GoManagementServer {
+	servers := []GoManagementServer{}
+	for _, root := range roots {
+		for _, child := range tree[root.Path] {
+			if child.Name != "ManagementServer" || child.IsMultiInstance {
+				continue
+			}
+			server := GoManagementServer{RootGoName: root.GoName, Path: child.FullPath}
+			for _, param := range model.ParametersOf(child.FullPath) {
+				if mapCWMPTypeToGoType(param.Type) != "string" {
+					continue
+				}
+				goName := toExportedName(sanitize(param.Name))
+				switch param.Name {
+				case "Username":
+					server.Username = goName
+				case "Password":
+					server.Password = goName
+				case "ConnectionRequestURL":
+					server.ConnectionRequestURL = goName
+				case "ConnectionRequestUsername":
+					server.ConnectionRequestUsername = goName
+				case "ConnectionRequestPassword":
+					server.ConnectionRequestPassword = goName
+				}
+			}
+			servers = append(servers, server)
+		}
+	}
+	return servers
+}
+
+// CWMP authentication template with HTTP Digest and Basic authentication for
+// ACS sessions and Connection Requests
+const cwmpAuthTemplate = `// Code generated by cwmp-codegen. DO NOT EDIT.
+package {{.PackageName}}
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Credentials are a username and password for HTTP authentication
+type Credentials struct {
+	Username string
+	Password string
+}
+{{range .ManagementServers}}{{if and .Username .Password}}
+// ACSCredentials returns the credentials the device authenticates to the
+// ACS with, from {{.Path}}Username and Password
+func (obj *{{.RootGoName}}) ACSCredentials() Credentials {
+	return Credentials{Username: obj.ManagementServer.{{.Username}}, Password: obj.ManagementServer.{{.Password}}}
+}
+{{end}}{{if and .ConnectionRequestURL .ConnectionRequestUsername .ConnectionRequestPassword}}
+// ConnectionRequest returns the URL and credentials of Connection Requests
+// to the device, from {{.Path}}ConnectionRequestURL,
+// ConnectionRequestUsername and ConnectionRequestPassword
+func (obj *{{.RootGoName}}) ConnectionRequest() (string, Credentials) {
+	return obj.ManagementServer.{{.ConnectionRequestURL}}, Credentials{
+		Username: obj.ManagementServer.{{.ConnectionRequestUsername}},
+		Password: obj.ManagementServer.{{.ConnectionRequestPassword}},
+	}
+}
+{{end}}{{end}}
+// ConnectionRequestURL returns the ManagementServer.ConnectionRequestURL
+// reported in the Inform, or an empty string
+func (inform *SessionInform) ConnectionRequestURL() string {
+	for name, value := range inform.Params {
+		if strings.HasSuffix(name, ".ManagementServer.ConnectionRequestURL") {
+			return value
+		}
+	}
+	return ""
+}
+
+// StaticCredentials returns a password lookup accepting a single user
+func StaticCredentials(credentials Credentials) func(username string) (string, bool) {
+	return func(username string) (string, bool) {
+		return credentials.Password, username == credentials.Username
+	}
+}
+
+// HTTPAuth authenticates HTTP requests with Digest authentication (MD5,
+// qop=auth) and optionally Basic authentication, as CWMP requires of ACS
+// and Connection Request endpoints
+type HTTPAuth struct {
+	Realm string
+	// AllowBasic also accepts Basic authentication, which CWMP only permits
+	// over TLS
+	AllowBasic bool
+	// Lookup returns the password of a user
+	Lookup func(username string) (string, bool)
+	// NonceLifetime is how long a nonce is accepted, 5 minutes by default
+	NonceLifetime time.Duration
+
+	mu     sync.Mutex
+	nonces map[string]*digestNonce
+}
+
+// digestNonce is a nonce issued in a challenge
+type digestNonce struct {
+	issued time.Time
+	count  uint64 // Highest nonce count used
+}
+
+// NewHTTPAuth creates an HTTPAuth for a realm
+func NewHTTPAuth(realm string, lookup func(username string) (string, bool)) *HTTPAuth {
+	return &HTTPAuth{Realm: realm, Lookup: lookup}
+}
+
+// Authenticate returns the user authenticated by the request's
+// Authorization header
+func (a *HTTPAuth) Authenticate(r *http.Request) (string, bool) {
+	if a.AllowBasic {
+		if username, password, ok := r.BasicAuth(); ok {
+			expected, found := a.Lookup(username)
+			return username, found && subtle.ConstantTimeCompare([]byte(password), []byte(expected)) == 1
+		}
+	}
+
+	scheme, params, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Digest") {
+		return "", false
+	}
+	fields := parseAuthParams(params)
+	username := fields["username"]
+	if fields["realm"] != a.Realm || fields["uri"] != r.URL.RequestURI() {
+		return "", false
+	}
+	if algorithm := fields["algorithm"]; algorithm != "" && !strings.EqualFold(algorithm, "MD5") {
+		return "", false
+	}
+	// Challenge offers qop="auth" only, and without it the nonce count that
+	// guards against replays is missing
+	if fields["qop"] != "auth" {
+		return "", false
+	}
+	password, found := a.Lookup(username)
+	if !found {
+		return "", false
+	}
+
+	ha1 := md5Hex(username + ":" + a.Realm + ":" + password)
+	ha2 := md5Hex(r.Method + ":" + fields["uri"])
+	expected := md5Hex(ha1 + ":" + fields["nonce"] + ":" + fields["nc"] + ":" + fields["cnonce"] + ":auth:" + ha2)
+	if subtle.ConstantTimeCompare([]byte(fields["response"]), []byte(expected)) != 1 {
+		return "", false
+	}
+	return username, a.useNonce(fields["nonce"], fields["nc"])
+}
+
+// useNonce checks that a nonce was issued, has not expired and that its
+// count increases
+func (a *HTTPAuth) useNonce(nonce, nc string) bool {
+	lifetime := a.NonceLifetime
+	if lifetime <= 0 {
+		lifetime = 5 * time.Minute
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	entry, ok := a.nonces[nonce]
+	if !ok || time.Since(entry.issued) > lifetime {
+		return false
+	}
+	var count uint64
+	if _, err := fmt.Sscanf(nc, "%x", &count); err != nil || count <= entry.count {
+		return false
+	}
+	entry.count = count
+	return true
+}
+
+// Challenge answers a request with 401 Unauthorized and a Digest challenge,
+// preceded by a Basic challenge when Basic authentication is allowed
+func (a *HTTPAuth) Challenge(w http.ResponseWriter) {
+	nonce, opaque := randomHex(16), randomHex(8)
+
+	lifetime := a.NonceLifetime
+	if lifetime <= 0 {
+		lifetime = 5 * time.Minute
+	}
+	a.mu.Lock()
+	if a.nonces == nil {
+		a.nonces = make(map[string]*digestNonce)
+	}
+	for n, entry := range a.nonces {
+		if time.Since(entry.issued) > lifetime {
+			delete(a.nonces, n)
+		}
+	}
+	a.nonces[nonce] = &digestNonce{issued: time.Now()}
+	a.mu.Unlock()
+
+	if a.AllowBasic {
+		w.Header().Add("WWW-Authenticate", fmt.Sprintf("Basic realm=%q", a.Realm))
+	}
+	w.Header().Add("WWW-Authenticate", fmt.Sprintf("Digest realm=%q, qop=\"auth\", nonce=%q, opaque=%q, algorithm=MD5", a.Realm, nonce, opaque))
+	http.Error(w, "unauthorized", http.StatusUnauthorized)
+}
+
+// Wrap returns a handler that challenges unauthenticated requests before
+// passing them to next
+func (a *HTTPAuth) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := a.Authenticate(r); !ok {
+			a.Challenge(w)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// AuthTransport is an http.RoundTripper answering Digest and Basic
+// challenges with credentials, for CPEs informing an ACS and for ACSs
+// sending Connection Requests. After the first challenge, requests are
+// authenticated up front.
+type AuthTransport struct {
+	Base        http.RoundTripper // http.DefaultTransport when nil
+	Credentials Credentials
+
+	mu        sync.Mutex
+	challenge map[string]string // Parameters of the last challenge, with "scheme"
+	count     uint64            // Nonce count of the challenge
+}
+
+// RoundTrip sends a request, repeating it with credentials when the server
+// answers with a challenge
+func (t *AuthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	// Buffer the body so that the request can be repeated
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		data, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		body = data
+	}
+
+	resp, err := base.RoundTrip(t.authorize(req, body))
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	challenge := parseChallenge(resp.Header.Values("WWW-Authenticate"))
+	if challenge == nil {
+		return resp, nil
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	t.mu.Lock()
+	t.challenge, t.count = challenge, 0
+	t.mu.Unlock()
+	return base.RoundTrip(t.authorize(req, body))
+}
+
+// authorize returns a copy of the request with the body and, once
+// challenged, an Authorization header
+func (t *AuthTransport) authorize(req *http.Request, body []byte) *http.Request {
+	out := req.Clone(req.Context())
+	if body != nil {
+		out.Body = io.NopCloser(bytes.NewReader(body))
+		out.ContentLength = int64(len(body))
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	switch t.challenge["scheme"] {
+	case "basic":
+		out.SetBasicAuth(t.Credentials.Username, t.Credentials.Password)
+	case "digest":
+		t.count++
+		out.Header.Set("Authorization", t.digestAuthorization(out))
+	}
+	return out
+}
+
+// digestAuthorization computes the Digest Authorization header of a request
+func (t *AuthTransport) digestAuthorization(req *http.Request) string {
+	c := t.challenge
+	uri := req.URL.RequestURI()
+	ha1 := md5Hex(t.Credentials.Username + ":" + c["realm"] + ":" + t.Credentials.Password)
+	ha2 := md5Hex(req.Method + ":" + uri)
+
+	header := fmt.Sprintf("Digest username=%q, realm=%q, nonce=%q, uri=%q, algorithm=MD5", t.Credentials.Username, c["realm"], c["nonce"], uri)
+	qopAuth := false
+	for _, qop := range strings.Split(c["qop"], ",") {
+		qopAuth = qopAuth || strings.TrimSpace(qop) == "auth"
+	}
+	if qopAuth {
+		nc, cnonce := fmt.Sprintf("%08x", t.count), randomHex(8)
+		response := md5Hex(ha1 + ":" + c["nonce"] + ":" + nc + ":" + cnonce + ":auth:" + ha2)
+		header += fmt.Sprintf(", qop=auth, nc=%s, cnonce=%q, response=%q", nc, cnonce, response)
+	} else {
+		header += fmt.Sprintf(", response=%q", md5Hex(ha1+":"+c["nonce"]+":"+ha2))
+	}
+	if c["opaque"] != "" {
+		header += fmt.Sprintf(", opaque=%q", c["opaque"])
+	}
+	return header
+}
+
+// parseChallenge picks the Digest challenge of WWW-Authenticate headers, or
+// else a Basic challenge
+func parseChallenge(headers []string) map[string]string {
+	var basic map[string]string
+	for _, header := range headers {
+		scheme, params, _ := strings.Cut(strings.TrimSpace(header), " ")
+		switch strings.ToLower(scheme) {
+		case "digest":
+			challenge := parseAuthParams(params)
+			challenge["scheme"] = "digest"
+			return challenge
+		case "basic":
+			basic = map[string]string{"scheme": "basic"}
+		}
+	}
+	return basic
+}
+
+// parseAuthParams parses comma-separated key=value parameters of an
+// authentication header, where values may be quoted
+func parseAuthParams(s string) map[string]string {
+	params := make(map[string]string)
+	for s = strings.TrimSpace(s); s != ""; s = strings.TrimSpace(s) {
+		key, rest, ok := strings.Cut(s, "=")
+		if !ok {
+			break
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		rest = strings.TrimSpace(rest)
+
+		var value string
+		if strings.HasPrefix(rest, "\"") {
+			end := 1
+			for end < len(rest) && rest[end] != '"' {
+				if rest[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(rest) {
+				value, rest = rest[1:], ""
+			} else {
+				value, rest = rest[1:end], rest[end+1:]
+			}
+			value = strings.ReplaceAll(value, "\\", "")
+		} else {
+			value, rest, _ = strings.Cut(rest, ",")
+			rest = "," + rest
+		}
+		params[key] = strings.TrimSpace(value)
+		_, s, _ = strings.Cut(rest, ",")
+	}
+	return params
+}
+
+// md5Hex returns the hex-encoded MD5 digest of s
+func md5Hex(s string) string {
+	sum := md5.Sum([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+// randomHex returns n random bytes, hex-encoded
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// SendConnectionRequest asks a device to open a session with the ACS by an
+// authenticated HTTP GET to its ConnectionRequestURL
+func SendConnectionRequest(ctx context.Context, url string, credentials Credentials) error {
+	client := &http.Client{Transport: &AuthTransport{Credentials: credentials}, Timeout: 30 * time.Second}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("connection request to %s: %s", url, resp.Status)
+	}
+	return nil
+}
+
+// ConnectionRequestHandler returns the handler of a device's
+// ConnectionRequestURL. It authenticates the ACS and calls trigger, which
+// should start a session reporting the "6 CONNECTION REQUEST" event.
+func ConnectionRequestHandler(auth *HTTPAuth, trigger func()) http.Handler {
+	return auth.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		trigger()
+		w.WriteHeader(http.StatusOK)
+	}))
+}
+
+// NewCPEClient returns an HTTP client for the sessions of a device with the
+// ACS, authenticating with the device's credentials and keeping the session
+// cookie. A new client should be used for each session.
+func NewCPEClient(credentials Credentials, jar http.CookieJar) *http.Client {
+	return &http.Client{
+		Transport: &AuthTransport{Credentials: credentials},
+		Jar:       jar,
+		Timeout:   30 * time.Second,
+	}
+}
+`
@@ -0,0 +1,263 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Niceblueman/cwmp-codegen/internal/models"
+)
+
+func TestGenerateGolangAuth(t *testing.T) {
+	model := &models.DataModel{
+		Name: "Device:2.16",
+		Objects: []models.Object{
+			{Name: "Device.", Path: "Device."},
+			{Name: "Device.ManagementServer.", Path: "Device.ManagementServer.", Parameters: []models.Parameter{
+				{Name: "URL", Type: "string"},
+				{Name: "Username", Type: "string"},
+				{Name: "Password", Type: "string"},
+				{Name: "ConnectionRequestURL", Type: "string"},
+				{Name: "ConnectionRequestUsername", Type: "string"},
+				{Name: "ConnectionRequestPassword", Type: "string"},
+			}},
+		},
+	}
+
+	tmpDir := t.TempDir()
+	files, err := GenerateGolang(model, tmpDir)
+	if err != nil {
+		t.Fatalf("GenerateGolang returned error: %v", err)
+	}
+	if !contains(files, "cwmp_auth.go") {
+		t.Fatalf("Expected cwmp_auth.go in %v", files)
+	}
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, "cwmp_auth.go"))
+	if err != nil {
+		t.Fatalf("Failed to read cwmp_auth.go: %v", err)
+	}
+
+	for _, expected := range []string{
+		"package messages",
+		"func (obj *Device) ACSCredentials() Credentials {",
+		"return Credentials{Username: obj.ManagementServer.Username, Password: obj.ManagementServer.Password}",
+		"func (obj *Device) ConnectionRequest() (string, Credentials) {",
+		"return obj.ManagementServer.ConnectionRequestURL, Credentials{",
+		"func NewHTTPAuth(realm string, lookup func(username string) (string, bool)) *HTTPAuth {",
+		"func (a *HTTPAuth) Challenge(w http.ResponseWriter) {",
+		"func (t *AuthTransport) RoundTrip(req *http.Request) (*http.Response, error) {",
+		"func SendConnectionRequest(ctx context.Context, url string, credentials Credentials) error {",
+		"func ConnectionRequestHandler(auth *HTTPAuth, trigger func()) http.Handler {",
+	} {
+		if !strings.Contains(string(content), expected) {
+			t.Errorf("Expected cwmp_auth.go to contain %q", expected)
+		}
+	}
+
+	session, err := os.ReadFile(filepath.Join(tmpDir, "acs_session.go"))
+	if err != nil {
+		t.Fatalf("Failed to read acs_session.go: %v", err)
+	}
+	if !strings.Contains(string(session), "Auth *HTTPAuth") {
+		t.Error("Expected the ACS handler to support authentication")
+	}
+}
+
+func TestGolangAuthRuns(t *testing.T) {
+	model := &models.DataModel{
+		Name: "Device:2.16",
+		Objects: []models.Object{
+			{Name: "Device.", Path: "Device."},
+			{Name: "Device.ManagementServer.", Path: "Device.ManagementServer.", Parameters: []models.Parameter{
+				{Name: "URL", Type: "string"},
+				{Name: "Username", Type: "string"},
+				{Name: "Password", Type: "string"},
+				{Name: "ConnectionRequestURL", Type: "string"},
+				{Name: "ConnectionRequestUsername", Type: "string"},
+				{Name: "ConnectionRequestPassword", Type: "string"},
+			}},
+		},
+	}
+
+	runGeneratedGoTests(t, GenerateGolang, model, map[string]string{"auth_test.go": `package messages
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func hexMD5(s string) string {
+	sum := md5.Sum([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+// digest answers a challenge as RFC 2617 describes for MD5 and qop=auth
+func digest(challenge map[string]string, username, password, method, uri, nc string) string {
+	cnonce := "0a4f113b"
+	ha1 := hexMD5(username + ":" + challenge["realm"] + ":" + password)
+	ha2 := hexMD5(method + ":" + uri)
+	response := hexMD5(ha1 + ":" + challenge["nonce"] + ":" + nc + ":" + cnonce + ":auth:" + ha2)
+	return fmt.Sprintf("Digest username=%q, realm=%q, nonce=%q, uri=%q, algorithm=MD5, qop=auth, nc=%s, cnonce=%q, response=%q, opaque=%q",
+		username, challenge["realm"], challenge["nonce"], uri, nc, cnonce, response, challenge["opaque"])
+}
+
+func TestDigest(t *testing.T) {
+	auth := NewHTTPAuth("acs", StaticCredentials(Credentials{Username: "cpe", Password: "secret"}))
+	server := httptest.NewServer(auth.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+	defer server.Close()
+
+	send := func(authorization string) *http.Response {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodPost, server.URL+"/acs", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	resp := send("")
+	headers := resp.Header.Values("WWW-Authenticate")
+	if resp.StatusCode != http.StatusUnauthorized || len(headers) != 1 || !strings.HasPrefix(headers[0], "Digest ") {
+		t.Fatalf("Expected a Digest challenge only, got %d %q", resp.StatusCode, headers)
+	}
+	challenge := parseAuthParams(strings.TrimPrefix(headers[0], "Digest "))
+	if challenge["realm"] != "acs" || challenge["qop"] != "auth" || challenge["algorithm"] != "MD5" || challenge["nonce"] == "" {
+		t.Fatalf("Unexpected challenge %q", headers[0])
+	}
+
+	// RFC 2069 response without qop, which carries no nonce count
+	ha1 := hexMD5("cpe:acs:secret")
+	withoutQop := fmt.Sprintf("Digest username=\"cpe\", realm=\"acs\", nonce=%q, uri=\"/acs\", response=%q",
+		challenge["nonce"], hexMD5(ha1+":"+challenge["nonce"]+":"+hexMD5("POST:/acs")))
+
+	tests := []struct {
+		name          string
+		authorization string
+		want          int
+	}{
+		{"without qop", withoutQop, http.StatusUnauthorized},
+		{"replayed without qop", withoutQop, http.StatusUnauthorized},
+		{"valid", digest(challenge, "cpe", "secret", "POST", "/acs", "00000001"), http.StatusOK},
+		{"replayed nonce count", digest(challenge, "cpe", "secret", "POST", "/acs", "00000001"), http.StatusUnauthorized},
+		{"next nonce count", digest(challenge, "cpe", "secret", "POST", "/acs", "00000002"), http.StatusOK},
+		{"lower nonce count", digest(challenge, "cpe", "secret", "POST", "/acs", "00000001"), http.StatusUnauthorized},
+		{"wrong password", digest(challenge, "cpe", "wrong", "POST", "/acs", "00000003"), http.StatusUnauthorized},
+		{"unknown user", digest(challenge, "acs", "secret", "POST", "/acs", "00000003"), http.StatusUnauthorized},
+		{"other URI", digest(challenge, "cpe", "secret", "POST", "/other", "00000003"), http.StatusUnauthorized},
+		{"unknown nonce", digest(map[string]string{"realm": "acs", "nonce": "0123"}, "cpe", "secret", "POST", "/acs", "00000001"), http.StatusUnauthorized},
+		{"Basic not allowed", "Basic " + hexMD5("x"), http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		if resp := send(tt.authorization); resp.StatusCode != tt.want {
+			t.Errorf("%s: got %d, want %d", tt.name, resp.StatusCode, tt.want)
+		}
+	}
+}
+
+func TestBasic(t *testing.T) {
+	auth := NewHTTPAuth("acs", StaticCredentials(Credentials{Username: "cpe", Password: "secret"}))
+	auth.AllowBasic = true
+	server := httptest.NewServer(auth.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+	defer server.Close()
+
+	resp, err := http.Post(server.URL, "text/xml", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	headers := resp.Header.Values("WWW-Authenticate")
+	if len(headers) != 2 || headers[0] != ` + "`" + `Basic realm="acs"` + "`" + ` || !strings.HasPrefix(headers[1], "Digest ") {
+		t.Fatalf("Expected Basic and Digest challenges, got %q", headers)
+	}
+
+	for password, want := range map[string]int{"secret": http.StatusOK, "wrong": http.StatusUnauthorized} {
+		req, err := http.NewRequest(http.MethodPost, server.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.SetBasicAuth("cpe", password)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("Basic with password %q: got %d, want %d", password, resp.StatusCode, want)
+		}
+	}
+}
+
+func TestCPEClient(t *testing.T) {
+	handler := NewACSHandler(NewMemoryRPCQueue())
+	handler.Auth = NewHTTPAuth("acs", StaticCredentials(Credentials{Username: "cpe", Password: "secret"}))
+	username := ""
+	handler.OnInform = func(session *ACSSession) { username = session.Username }
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	inform := ` + "`" + `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/" xmlns:cwmp="urn:dslforum-org:cwmp-1-0"><soap:Header><cwmp:ID>1</cwmp:ID></soap:Header><soap:Body><cwmp:Inform><DeviceId><OUI>00D09E</OUI><SerialNumber>SN1</SerialNumber></DeviceId><MaxEnvelopes>1</MaxEnvelopes></cwmp:Inform></soap:Body></soap:Envelope>` + "`" + `
+	post := func(client *http.Client, body string) int {
+		t.Helper()
+		resp, err := client.Post(server.URL, "text/xml", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code := post(NewCPEClient(Credentials{Username: "cpe", Password: "wrong"}, jar), inform); code != http.StatusUnauthorized {
+		t.Errorf("Expected wrong credentials to be rejected, got %d", code)
+	}
+
+	client := NewCPEClient(Credentials{Username: "cpe", Password: "secret"}, jar)
+	if code := post(client, inform); code != http.StatusOK || username != "cpe" {
+		t.Fatalf("Expected an authenticated Inform, got %d for %q", code, username)
+	}
+	// The session cookie authenticates the rest of the session
+	if code := post(client, ""); code != http.StatusNoContent {
+		t.Errorf("Expected the session to end, got %d", code)
+	}
+}
+
+func TestConnectionRequest(t *testing.T) {
+	triggered := 0
+	auth := NewHTTPAuth("cpe", StaticCredentials(Credentials{Username: "acs", Password: "pw"}))
+	server := httptest.NewServer(ConnectionRequestHandler(auth, func() { triggered++ }))
+	defer server.Close()
+
+	if err := SendConnectionRequest(context.Background(), server.URL+"/cr", Credentials{Username: "acs", Password: "pw"}); err != nil || triggered != 1 {
+		t.Fatalf("Expected the Connection Request to trigger a session, got %v after %d", err, triggered)
+	}
+	if err := SendConnectionRequest(context.Background(), server.URL+"/cr", Credentials{Username: "acs", Password: "wrong"}); err == nil || triggered != 1 {
+		t.Errorf("Expected wrong credentials to fail, got %v after %d", err, triggered)
+	}
+
+	inform := &SessionInform{Params: map[string]string{"Device.ManagementServer.ConnectionRequestURL": server.URL + "/cr"}}
+	if inform.ConnectionRequestURL() != server.URL+"/cr" {
+		t.Errorf("Unexpected ConnectionRequestURL %q", inform.ConnectionRequestURL())
+	}
+}
+`})
+}
@@ -0,0 +1,164 @@
+package generator
+
+import (
+	"strings"
+
+	"github.com/Niceblueman/cwmp-codegen/internal/models"
+)
+
+// Binder template for converting between flat parameter maps and object trees
+const binderTemplate = `// Code generated by cwmp-codegen. DO NOT EDIT.
+package {{.PackageName}}
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+{{range .Roots}}
+// Bind populates the object tree from a flat map of parameter paths to
+// values, such as the Values of a GetParameterValuesResponse. Paths outside
+// {{.Path}} are ignored.
+func (obj *{{.GoName}}) Bind(values map[string]string) error {
+	return bindTree(values, "{{.Path}}", obj.bindPath)
+}
+
+// Flatten returns every parameter of the object tree as a ParameterValueStruct
+func (obj *{{.GoName}}) Flatten() []ParameterValueStruct {
+	return obj.flatten("{{.Path}}", nil)
+}
+{{end}}
+// bindTree binds each value under prefix in path order, so that table entries
+// are created deterministically
+func bindTree(values map[string]string, prefix string, bind func([]string, string) error) error {
+	paths := make([]string, 0, len(values))
+	for path := range values {
+		if strings.HasPrefix(path, prefix) && !strings.HasSuffix(path, ".") {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	var errs []error
+	for _, path := range paths {
+		if err := bind(strings.Split(strings.TrimPrefix(path, prefix), "."), values[path]); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// bindInstance returns the table entry named by an instance number segment,
+// inserting a new entry so that the table stays ordered by instance number
+func bindInstance[T any](table *[]T, segment string, number func(*T) *int) (*T, error) {
+	n, err := strconv.Atoi(segment)
+	if err != nil || n < 1 {
+		return nil, fmt.Errorf("invalid instance number %q", segment)
+	}
+
+	entries := *table
+	i := sort.Search(len(entries), func(i int) bool { return *number(&entries[i]) >= n })
+	if i == len(entries) || *number(&entries[i]) != n {
+		var entry T
+		*number(&entry) = n
+		entries = append(entries, entry)
+		copy(entries[i+1:], entries[i:])
+		entries[i] = entry
+		*table = entries
+	}
+	return &entries[i], nil
+}
+
+// instancePath returns the object path of a table entry
+func instancePath(prefix, name string, n int) string {
+	return prefix + name + "." + strconv.Itoa(n) + "."
+}
+
+// parameterValue builds a ParameterValueStruct for a flattened parameter
+func parameterValue(path, xsdType, value string) ParameterValueStruct {
+	return ParameterValueStruct{
+		Name:  NodeStruct{Type: XsdString, Value: path},
+		Value: NodeStruct{Type: xsdType, Value: value},
+	}
+}
+
+// rawValue encodes a parameter whose type is not known to the generator
+func rawValue(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+`
+
+// GoRootObject is an object that is not contained by any other object
+type GoRootObject struct {
+	GoName string
+	Path   string
+}
+
+// objectPathPrefix returns an object's path with exactly one trailing dot
+func objectPathPrefix(obj models.Object) string {
+	return strings.TrimSuffix(obj.GetPath(), ".") + "."
+}
+
+// linkGoObjectTree derives the child objects of every object from the path
+// index of the model, since data model files usually declare objects flat
+// with full paths. The result is keyed by object path prefix.
+func linkGoObjectTree(model *models.DataModel) map[string][]GoChildObject {
+	tree := make(map[string][]GoChildObject)
+	model.Walk(func(node *models.Node) error {
+		if !node.IsObject() || node.Parent == nil {
+			return nil
+		}
+		// Only link objects whose parent object is declared
+		isTable := node.IsTable()
+		segment := node.Name()
+		if node.Path != node.Parent.Path+segment+"." && !(isTable && node.Path == node.Parent.Path+segment+".{i}.") {
+			return nil
+		}
+
+		structName := toExportedName(sanitize(node.Object.Name))
+		goType := structName
+		if isTable {
+			goType = "[]" + structName
+		}
+
+		tree[node.Parent.Path] = append(tree[node.Parent.Path], GoChildObject{
+			Name:            segment,
+			GoName:          toExportedName(sanitize(segment)),
+			GoType:          goType,
+			StructName:      structName,
+			IsMultiInstance: isTable,
+			FullPath:        node.Path,
+		})
+		return nil
+	})
+
+	return tree
+}
+
+// collectGoRootObjects returns the objects that have no parent object
+func collectGoRootObjects(objects []models.Object, tree map[string][]GoChildObject) []GoRootObject {
+	children := make(map[string]bool)
+	for _, childObjects := range tree {
+		for _, child := range childObjects {
+			children[child.FullPath] = true
+		}
+	}
+
+	roots := []GoRootObject{}
+	for _, obj := range objects {
+		path := objectPathPrefix(obj)
+		if children[path] || strings.HasSuffix(path, ".{i}.") {
+			continue
+		}
+		roots = append(roots, GoRootObject{
+			GoName: toExportedName(sanitize(obj.Name)),
+			Path:   path,
+		})
+	}
+	return roots
+}
@@ -0,0 +1,205 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Niceblueman/cwmp-codegen/internal/models"
+)
+
+func TestLinkGoObjectTree(t *testing.T) {
+	objects := []models.Object{
+		{Name: "Device.", Path: "Device."},
+		{Name: "Device.DeviceInfo.", Path: "Device.DeviceInfo."},
+		{Name: "Device.Hosts.Host.{i}.", Path: "Device.Hosts.Host.{i}."},
+		{Name: "Device.Hosts.", Path: "Device.Hosts."},
+	}
+
+	tree := linkGoObjectTree(&models.DataModel{Objects: objects})
+
+	deviceChildren := tree["Device."]
+	if len(deviceChildren) != 2 {
+		t.Fatalf("Expected 2 children of Device., got %d", len(deviceChildren))
+	}
+	if deviceChildren[0].GoName != "DeviceInfo" || deviceChildren[0].GoType != "Device_DeviceInfo" {
+		t.Errorf("Unexpected child %+v", deviceChildren[0])
+	}
+
+	hosts := tree["Device.Hosts."]
+	if len(hosts) != 1 {
+		t.Fatalf("Expected 1 child of Device.Hosts., got %d", len(hosts))
+	}
+	if !hosts[0].IsMultiInstance || hosts[0].GoName != "Host" || hosts[0].GoType != "[]Device_Hosts_Host_Instance" {
+		t.Errorf("Expected Host table child, got %+v", hosts[0])
+	}
+
+	roots := collectGoRootObjects(objects, tree)
+	if len(roots) != 1 || roots[0].GoName != "Device" {
+		t.Errorf("Expected Device to be the only root, got %+v", roots)
+	}
+}
+
+func TestGenerateGolangBinder(t *testing.T) {
+	model := &models.DataModel{
+		Name: "BinderModel",
+		Objects: []models.Object{
+			{Name: "Device.", Path: "Device."},
+			{
+				Name: "Device.Host.{i}.",
+				Path: "Device.Host.{i}.",
+				Parameters: []models.Parameter{
+					{Name: "Active", Type: "boolean"},
+				},
+			},
+		},
+	}
+
+	tmpDir := t.TempDir()
+	if _, err := GenerateGolang(model, tmpDir); err != nil {
+		t.Fatalf("GenerateGolang returned error: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, "binder.go"))
+	if err != nil {
+		t.Fatalf("Failed to read binder.go: %v", err)
+	}
+	if !strings.Contains(string(content), "func (obj *Device) Bind(values map[string]string) error") {
+		t.Error("binder.go doesn't contain Bind for the root object")
+	}
+
+	hostContent, err := os.ReadFile(filepath.Join(tmpDir, "Device_Host_Instance.go"))
+	if err != nil {
+		t.Fatalf("Failed to read generated object file: %v", err)
+	}
+	if !strings.Contains(string(hostContent), "InstanceNumber int") {
+		t.Error("Table entry struct doesn't record its instance number")
+	}
+	if !strings.Contains(string(hostContent), `case "Active":`) {
+		t.Error("Generated bindPath doesn't bind the Active parameter")
+	}
+}
+
+func TestGolangBinderRoundTrip(t *testing.T) {
+	model := &models.DataModel{
+		Name: "BinderModel",
+		Objects: []models.Object{
+			{Name: "Device.", Path: "Device."},
+			{
+				Name: "Device.DeviceInfo.",
+				Path: "Device.DeviceInfo.",
+				Parameters: []models.Parameter{
+					{Name: "UpTime", Type: "unsignedInt"},
+					{Name: "FirstUseDate", Type: "dateTime"},
+				},
+			},
+			{
+				Name: "Device.Hosts.",
+				Path: "Device.Hosts.",
+				Parameters: []models.Parameter{
+					{Name: "HostNumberOfEntries", Type: "unsignedInt"},
+				},
+			},
+			{
+				Name: "Device.Hosts.Host.{i}.",
+				Path: "Device.Hosts.Host.{i}.",
+				Parameters: []models.Parameter{
+					{Name: "Active", Type: "boolean"},
+					{Name: "HostName", Type: "string"},
+					{Name: "IPAddress", Type: "IPAddress"},
+					{Name: "DNSServers", Type: "list", IsList: true, ItemType: "IPAddress", Syntax: models.Syntax{List: &models.List{}}},
+				},
+			},
+			{
+				Name: "Device.Hosts.Host.{i}.IPv4Address.{i}.",
+				Path: "Device.Hosts.Host.{i}.IPv4Address.{i}.",
+				Parameters: []models.Parameter{
+					{Name: "Address", Type: "string"},
+				},
+			},
+		},
+	}
+
+	runGeneratedGoTests(t, GenerateGolang, model, map[string]string{"binder_test.go": `package messages
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBindFlatten(t *testing.T) {
+	values := map[string]string{
+		"Device.DeviceInfo.UpTime":                  "4000000000",
+		"Device.DeviceInfo.FirstUseDate":            "2024-01-02T03:04:05Z",
+		"Device.Hosts.HostNumberOfEntries":          "2",
+		"Device.Hosts.Host.10.Active":               "false",
+		"Device.Hosts.Host.10.HostName":             "printer",
+		"Device.Hosts.Host.10.IPAddress":            "192.168.1.10",
+		"Device.Hosts.Host.10.DNSServers":           "",
+		"Device.Hosts.Host.2.Active":                "true",
+		"Device.Hosts.Host.2.HostName":              "laptop",
+		"Device.Hosts.Host.2.IPAddress":             "192.168.1.2",
+		"Device.Hosts.Host.2.DNSServers":            "192.168.1.1,8.8.8.8",
+		"Device.Hosts.Host.2.IPv4Address.3.Address": "192.168.1.2",
+		"Device.Hosts.Host.2.IPv4Address.1.Address": "10.0.0.2",
+	}
+
+	var device Device
+	input := map[string]string{"Other.Parameter": "ignored", "Device.Hosts.Host.2.": ""}
+	for path, value := range values {
+		input[path] = value
+	}
+	if err := device.Bind(input); err != nil {
+		t.Fatalf("Bind returned error: %v", err)
+	}
+
+	hosts := device.Hosts.Host
+	if len(hosts) != 2 || hosts[0].InstanceNumber != 2 || hosts[1].InstanceNumber != 10 {
+		t.Fatalf("Expected hosts 2 and 10 in instance order, got %+v", hosts)
+	}
+	if addresses := hosts[0].IPv4Address; len(addresses) != 2 || addresses[0].InstanceNumber != 1 || addresses[0].Address != "10.0.0.2" {
+		t.Errorf("Expected the nested table in instance order, got %+v", addresses)
+	}
+	if device.DeviceInfo.UpTime != 4000000000 || !reflect.DeepEqual(hosts[0].DNSServers, []string{"192.168.1.1", "8.8.8.8"}) {
+		t.Errorf("Expected typed values, got %d and %v", device.DeviceInfo.UpTime, hosts[0].DNSServers)
+	}
+
+	flattened := map[string]string{}
+	for _, param := range device.Flatten() {
+		flattened[param.Name.Value] = param.Value.Value
+	}
+	if !reflect.DeepEqual(flattened, values) {
+		t.Errorf("Expected Flatten to return the bound values\ngot  %v\nwant %v", flattened, values)
+	}
+}
+
+func TestBindUnknownPaths(t *testing.T) {
+	var device Device
+	err := device.Bind(map[string]string{
+		"Device.Unknown.Parameter":     "1",
+		"Device.Hosts.Host.1.Bogus":    "x",
+		"Device.Hosts.Host.x.Active":   "true",
+		"Device.DeviceInfo.UpTime":     "-1",
+		"Device.Hosts.Host.1.HostName": "kept",
+	})
+	if err == nil {
+		t.Fatal("Expected Bind to report the invalid paths")
+	}
+	for _, expected := range []string{
+		"Device.Unknown.Parameter: unknown object",
+		"Device.Hosts.Host.1.Bogus: unknown parameter",
+		"Device.Hosts.Host.x.Active: invalid instance number",
+		"Device.DeviceInfo.UpTime: invalid unsignedInt",
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected error %q, got %v", expected, err)
+		}
+	}
+	if len(device.Hosts.Host) != 1 || device.Hosts.Host[0].HostName != "kept" {
+		t.Errorf("Expected valid paths to be bound despite errors, got %+v", device.Hosts.Host)
+	}
+}
+`})
+}
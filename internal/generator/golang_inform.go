@@ -0,0 +1,211 @@
+package generator
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/Niceblueman/cwmp-codegen/internal/models"
+)
+
+// defaultForcedInformPaths lists the parameters, relative to the root object,
+// that TR-069 requires in every Inform. They are used for models that predate
+// the forcedInform attribute.
+var defaultForcedInformPaths = []string{
+	"DeviceSummary",
+	"DeviceInfo.SpecVersion",
+	"DeviceInfo.HardwareVersion",
+	"DeviceInfo.SoftwareVersion",
+	"DeviceInfo.ProvisioningCode",
+	"ManagementServer.ConnectionRequestURL",
+	"ManagementServer.ParameterKey",
+	"WANDevice.{i}.WANConnectionDevice.{i}.WANIPConnection.{i}.ExternalIPAddress",
+	"WANDevice.{i}.WANConnectionDevice.{i}.WANPPPConnection.{i}.ExternalIPAddress",
+}
+
+// GoInformParameter is a field of the generated InformParameters struct.
+// Parameters of the same name, such as ExternalIPAddress of IP and PPP
+// connections, share a field and are matched in path order.
+type GoInformParameter struct {
+	GoParameter
+	Paths []string // Parameter paths, {i} matching any instance number
+}
+
+// PathList returns the paths of the parameter for its field comment
+func (p GoInformParameter) PathList() string {
+	return strings.Join(p.Paths, ", ")
+}
+
+// collectGoInformParameters finds the forced inform parameters of a model,
+// falling back to defaultForcedInformPaths when no parameter is marked
+func collectGoInformParameters(model *models.DataModel) []GoInformParameter {
+	forced := []*models.Node{}
+	model.Walk(func(node *models.Node) error {
+		if node.Parameter != nil && node.Parameter.ForcedInform {
+			forced = append(forced, node)
+		}
+		return nil
+	})
+	if len(forced) == 0 {
+		for _, path := range defaultForcedInformPaths {
+			forced = append(forced, model.Match("*."+path)...)
+		}
+	}
+
+	dataTypes := modelDataTypes(model)
+	fields := []GoInformParameter{}
+	index := make(map[string]int)
+	for _, node := range forced {
+		param := *node.Parameter
+		goParam := GoParameter{
+			Name:     param.Name,
+			GoName:   toExportedName(sanitize(param.Name)),
+			GoType:   mapCWMPTypeToGoType(param.Type),
+			FullPath: node.Path,
+		}
+		if param.IsList {
+			applyGoListFacets(&goParam, param, dataTypes)
+		} else {
+			applyGoValueCodec(&goParam, param, dataTypes)
+		}
+
+		i, ok := index[goParam.GoName]
+		if ok && fields[i].GoType != goParam.GoType {
+			// Same name with another type, qualify it with its object
+			goParam.GoName = toExportedName(sanitize(node.Parent.Name())) + goParam.GoName
+			i, ok = index[goParam.GoName]
+		}
+		if ok {
+			fields[i].Paths = append(fields[i].Paths, node.Path)
+			continue
+		}
+		index[goParam.GoName] = len(fields)
+		fields = append(fields, GoInformParameter{GoParameter: goParam, Paths: []string{node.Path}})
+	}
+
+	for i := range fields {
+		sort.Strings(fields[i].Paths)
+	}
+	return fields
+}
+
+// Inform template with the event codes of TR-069 and the forced inform
+// parameters of the model
+const informTemplate = `// Code generated by cwmp-codegen. DO NOT EDIT.
+package {{.PackageName}}
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+{{- if .NeedsTime}}
+	"time"
+{{- end}}
+)
+
+// Event codes reported in the EventStruct of an Inform
+const (
+	EventBootstrap                       = "0 BOOTSTRAP"
+	EventBoot                            = "1 BOOT"
+	EventPeriodic                        = "2 PERIODIC"
+	EventScheduled                       = "3 SCHEDULED"
+	EventValueChange                     = "4 VALUE CHANGE"
+	EventKicked                          = "5 KICKED"
+	EventConnectionRequest               = "6 CONNECTION REQUEST"
+	EventTransferComplete                = "7 TRANSFER COMPLETE"
+	EventDiagnosticsComplete             = "8 DIAGNOSTICS COMPLETE"
+	EventRequestDownload                 = "9 REQUEST DOWNLOAD"
+	EventAutonomousTransferComplete      = "10 AUTONOMOUS TRANSFER COMPLETE"
+	EventDUStateChangeComplete           = "11 DU STATE CHANGE COMPLETE"
+	EventAutonomousDUStateChangeComplete = "12 AUTONOMOUS DU STATE CHANGE COMPLETE"
+	EventWakeup                          = "13 WAKEUP"
+	EventHeartbeat                       = "14 HEARTBEAT"
+	EventMReboot                         = "M Reboot"
+	EventMScheduleInform                 = "M ScheduleInform"
+	EventMDownload                       = "M Download"
+	EventMScheduleDownload               = "M ScheduleDownload"
+	EventMUpload                         = "M Upload"
+	EventMChangeDUState                  = "M ChangeDUState"
+)
+
+// InformParameters holds the forced inform parameters of the data model,
+// which a CPE reports in every Inform
+type InformParameters struct {
+{{- range .Parameters}}
+	{{.GoName}} {{.GoType}} // {{.PathList}}
+{{- end}}
+}
+
+// ParseInformParameters converts the ParameterList of an Inform, keyed by
+// parameter path. Parameters missing from the Inform keep their zero value.
+func ParseInformParameters(params map[string]string) (*InformParameters, error) {
+	out := &InformParameters{}
+{{- range .Parameters}}
+	if name, value, ok := findInformParameter(params, {{printf "%#v" .Paths}}); ok {
+{{- if .IsList}}
+		v, err := UnmarshalList(value, Parse{{.ListCodec}}ListItem)
+{{- else}}
+		v, err := Decode{{.Codec}}(value{{.DecodeArgs}})
+{{- end}}
+		if err != nil {
+			return out, informParameterError(name, err)
+		}
+		out.{{.GoName}} = v
+	}
+{{- end}}
+	return out, nil
+}
+
+// Parameters converts the forced inform parameters of the Inform
+func (inform *SessionInform) Parameters() (*InformParameters, error) {
+	return ParseInformParameters(inform.Params)
+}
+
+// findInformParameter returns the first parameter, in name order, matching
+// one of paths
+func findInformParameter(params map[string]string, paths []string) (string, string, bool) {
+	for _, path := range paths {
+		if !strings.Contains(path, "{i}") {
+			if value, ok := params[path]; ok {
+				return path, value, true
+			}
+			continue
+		}
+		names := []string{}
+		for name := range params {
+			if matchInstancePath(path, name) {
+				names = append(names, name)
+			}
+		}
+		if len(names) > 0 {
+			sort.Strings(names)
+			return names[0], params[names[0]], true
+		}
+	}
+	return "", "", false
+}
+
+// matchInstancePath reports whether name is path with every {i} replaced
+// by an instance number
+func matchInstancePath(path, name string) bool {
+	expected := strings.Split(path, ".")
+	actual := strings.Split(name, ".")
+	if len(expected) != len(actual) {
+		return false
+	}
+	for i, segment := range expected {
+		if segment == "{i}" {
+			if actual[i] == "" || strings.Trim(actual[i], "0123456789") != "" {
+				return false
+			}
+		} else if segment != actual[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// informParameterError reports a forced inform parameter with an invalid value
+func informParameterError(name string, err error) error {
+	return fmt.Errorf("inform parameter %s: %w", name, err)
+}
+`
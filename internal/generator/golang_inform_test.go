@@ -0,0 +1,98 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Niceblueman/cwmp-codegen/internal/models"
+)
+
+func TestGenerateGolangInformParameters(t *testing.T) {
+	model := &models.DataModel{
+		Name: "Device:2.16",
+		Objects: []models.Object{
+			{Name: "Device.", Path: "Device."},
+			{Name: "Device.DeviceInfo.", Path: "Device.DeviceInfo.", Parameters: []models.Parameter{
+				{Name: "HardwareVersion", Type: "string", ForcedInform: true, Syntax: models.Syntax{String: &models.StringCons{Size: &models.Size{Max: 64}}}},
+				{Name: "UpTime", Type: "unsignedInt", ForcedInform: true},
+				{Name: "Manufacturer", Type: "string"},
+			}},
+			{Name: "Device.Time.", Path: "Device.Time.", Parameters: []models.Parameter{
+				{Name: "CurrentLocalTime", Type: "dateTime", ForcedInform: true},
+				{Name: "UpTime", Type: "string", ForcedInform: true},
+			}},
+			{Name: "Device.IP.Interface.{i}.", Path: "Device.IP.Interface.{i}.", Parameters: []models.Parameter{
+				{Name: "ExternalIPAddress", Type: "IPAddress", ForcedInform: true},
+				{Name: "LastChange", Type: "StatsCounter32", ForcedInform: true},
+			}},
+		},
+		DataTypes: []models.DataType{{Name: "StatsCounter32", UnsignedInt: &models.UnsignedInt{}}},
+	}
+
+	tmpDir := t.TempDir()
+	files, err := GenerateGolang(model, tmpDir)
+	if err != nil {
+		t.Fatalf("GenerateGolang returned error: %v", err)
+	}
+	if !contains(files, "inform_params.go") {
+		t.Fatalf("Expected inform_params.go in %v", files)
+	}
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, "inform_params.go"))
+	if err != nil {
+		t.Fatalf("Failed to read inform_params.go: %v", err)
+	}
+
+	for _, expected := range []string{
+		"package messages",
+		"\t\"time\"",
+		"EventBootstrap                       = \"0 BOOTSTRAP\"",
+		"EventHeartbeat                       = \"14 HEARTBEAT\"",
+		"EventMReboot                         = \"M Reboot\"",
+		"HardwareVersion string // Device.DeviceInfo.HardwareVersion",
+		"UpTime uint32 // Device.DeviceInfo.UpTime",
+		"CurrentLocalTime time.Time // Device.Time.CurrentLocalTime",
+		"TimeUpTime string // Device.Time.UpTime",
+		"ExternalIPAddress string // Device.IP.Interface.{i}.ExternalIPAddress",
+		"LastChange uint32 // Device.IP.Interface.{i}.LastChange",
+		"v, err := DecodeString(value, 64)",
+		"v, err := DecodeUnsignedInt(value, 0, 4294967295)",
+		"findInformParameter(params, []string{\"Device.IP.Interface.{i}.ExternalIPAddress\"})",
+		"func (inform *SessionInform) Parameters() (*InformParameters, error) {",
+	} {
+		if !strings.Contains(string(content), expected) {
+			t.Errorf("Expected inform_params.go to contain %q", expected)
+		}
+	}
+	if strings.Contains(string(content), "Manufacturer") {
+		t.Error("Expected parameters without forcedInform to be left out")
+	}
+}
+
+func TestCollectGoInformParametersDefaults(t *testing.T) {
+	objects := []models.Object{
+		{Name: "InternetGatewayDevice.DeviceInfo.", Path: "InternetGatewayDevice.DeviceInfo.", Parameters: []models.Parameter{
+			{Name: "SoftwareVersion", Type: "string"},
+			{Name: "Manufacturer", Type: "string"},
+		}},
+		{Name: "InternetGatewayDevice.WANDevice.{i}.WANConnectionDevice.{i}.WANIPConnection.{i}.", Path: "InternetGatewayDevice.WANDevice.{i}.WANConnectionDevice.{i}.WANIPConnection.{i}.", Parameters: []models.Parameter{
+			{Name: "ExternalIPAddress", Type: "string"},
+		}},
+		{Name: "InternetGatewayDevice.WANDevice.{i}.WANConnectionDevice.{i}.WANPPPConnection.{i}.", Path: "InternetGatewayDevice.WANDevice.{i}.WANConnectionDevice.{i}.WANPPPConnection.{i}.", Parameters: []models.Parameter{
+			{Name: "ExternalIPAddress", Type: "string"},
+		}},
+	}
+
+	params := collectGoInformParameters(&models.DataModel{Objects: objects})
+	if len(params) != 2 {
+		t.Fatalf("Expected SoftwareVersion and ExternalIPAddress, got %+v", params)
+	}
+	if params[0].GoName != "SoftwareVersion" || params[1].GoName != "ExternalIPAddress" {
+		t.Errorf("Unexpected fields %s and %s", params[0].GoName, params[1].GoName)
+	}
+	if len(params[1].Paths) != 2 {
+		t.Errorf("Expected IP and PPP connections to share ExternalIPAddress, got %v", params[1].Paths)
+	}
+}
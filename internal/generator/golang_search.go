@@ -0,0 +1,405 @@
+package generator
+
+// Search path template for evaluating USP/TR-106 search paths against the
+// object trees of the generated structs
+const searchPathTemplate = `// Code generated by cwmp-codegen. DO NOT EDIT.
+package {{.PackageName}}
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// InstanceNode is an object instance or parameter of an InstanceTree
+type InstanceNode struct {
+	Name     string
+	Path     string // Concrete path, ending in a dot for objects
+	Value    string // Value of a parameter
+	IsParam  bool
+	Children []*InstanceNode
+	children map[string]*InstanceNode
+}
+
+// InstanceTree holds the concrete objects and parameter values of a device
+// for evaluating search paths
+type InstanceTree struct {
+	root *InstanceNode
+}
+
+// NewInstanceTree builds an InstanceTree from parameter values keyed by
+// concrete path. Paths ending in a dot add objects without parameters.
+func NewInstanceTree(values map[string]string) *InstanceTree {
+	tree := &InstanceTree{root: &InstanceNode{children: make(map[string]*InstanceNode)}}
+
+	paths := make([]string, 0, len(values))
+	for path := range values {
+		paths = append(paths, path)
+	}
+	sort.Slice(paths, func(i, j int) bool { return lessInstancePath(paths[i], paths[j]) })
+	for _, path := range paths {
+		tree.Set(path, values[path])
+	}
+	return tree
+}
+{{range .Roots}}
+// InstanceTree returns the parameters of the object tree as an InstanceTree
+func (obj *{{.GoName}}) InstanceTree() *InstanceTree {
+	tree := NewInstanceTree(nil)
+	for _, param := range obj.Flatten() {
+		tree.Set(param.Name.Value, param.Value.Value)
+	}
+	return tree
+}
+{{end}}
+// Set adds a parameter value, or an object for a path ending in a dot,
+// together with the objects above it. Children keep their insertion order.
+func (t *InstanceTree) Set(path, value string) {
+	segments := strings.Split(strings.TrimSuffix(path, "."), ".")
+	node := t.root
+	for i, segment := range segments {
+		child, ok := node.children[segment]
+		if !ok {
+			child = &InstanceNode{
+				Name:     segment,
+				Path:     strings.Join(segments[:i+1], ".") + ".",
+				children: make(map[string]*InstanceNode),
+			}
+			node.children[segment] = child
+			node.Children = append(node.Children, child)
+		}
+		node = child
+	}
+	if !strings.HasSuffix(path, ".") {
+		node.Path, node.Value, node.IsParam = path, value, true
+	}
+}
+
+// Lookup returns the object or parameter at a concrete path
+func (t *InstanceTree) Lookup(path string) (*InstanceNode, bool) {
+	return t.root.lookup(strings.TrimSuffix(path, "."))
+}
+
+// lookup returns the node at a path relative to n
+func (n *InstanceNode) lookup(path string) (*InstanceNode, bool) {
+	node := n
+	for _, segment := range strings.Split(path, ".") {
+		child, ok := node.children[segment]
+		if !ok {
+			return nil, false
+		}
+		node = child
+	}
+	return node, true
+}
+
+// instances returns the table entries below a table object
+func (n *InstanceNode) instances() []*InstanceNode {
+	entries := []*InstanceNode{}
+	for _, child := range n.Children {
+		if isInstanceNumber(child.Name) {
+			entries = append(entries, child)
+		}
+	}
+	return entries
+}
+
+// SearchPath is a parsed search path
+type SearchPath struct {
+	Segments []SearchSegment
+	IsObject bool // Whether the path ends in a dot and selects objects
+}
+
+// SearchSegment is a segment of a search path
+type SearchSegment struct {
+	Name       string            // Object or parameter name, or instance number
+	Wildcard   bool              // * or {i}: every entry of a table
+	Conditions []SearchCondition // [expression]: the entries matching every condition
+	Reference  bool              // Name#: follow the paths referenced by parameter Name
+	RefIndex   int               // Name#n: follow the n-th reference only, 0 follows all
+}
+
+// SearchCondition is a comparison in a search expression, such as Enable==true
+type SearchCondition struct {
+	Path     string // Parameter path relative to the table entry
+	Operator string
+	Value    string
+}
+
+// searchOperators lists the operators of search expressions, longest first
+var searchOperators = []string{"==", "!=", "<=", ">=", "<", ">"}
+
+// ParseSearchPath parses a search path such as
+// Device.WiFi.SSID.[Enable==true&&Status=="Up"].LowerLayers#1.Name
+func ParseSearchPath(path string) (*SearchPath, error) {
+	tokens, err := splitOutside(strings.TrimSuffix(path, "."), ".")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	sp := &SearchPath{IsObject: strings.HasSuffix(path, ".")}
+	for _, token := range tokens {
+		segment, err := parseSearchSegment(token)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		sp.Segments = append(sp.Segments, segment)
+	}
+	return sp, nil
+}
+
+// parseSearchSegment parses one segment of a search path
+func parseSearchSegment(token string) (SearchSegment, error) {
+	switch {
+	case token == "":
+		return SearchSegment{}, fmt.Errorf("empty path segment")
+	case token == "*" || token == "{i}":
+		return SearchSegment{Wildcard: true}, nil
+	case strings.HasPrefix(token, "["):
+		if !strings.HasSuffix(token, "]") {
+			return SearchSegment{}, fmt.Errorf("unterminated search expression %q", token)
+		}
+		expressions, err := splitOutside(token[1:len(token)-1], "&&")
+		if err != nil {
+			return SearchSegment{}, err
+		}
+		segment := SearchSegment{Conditions: []SearchCondition{}}
+		for _, expression := range expressions {
+			condition, err := parseSearchCondition(expression)
+			if err != nil {
+				return SearchSegment{}, err
+			}
+			segment.Conditions = append(segment.Conditions, condition)
+		}
+		return segment, nil
+	case strings.Contains(token, "#"):
+		name, index, _ := strings.Cut(token, "#")
+		segment := SearchSegment{Name: name, Reference: true}
+		if index != "" && index != "*" {
+			n, err := strconv.Atoi(index)
+			if err != nil || n < 1 {
+				return SearchSegment{}, fmt.Errorf("invalid reference index %q", token)
+			}
+			segment.RefIndex = n
+		}
+		return segment, nil
+	case strings.ContainsAny(token, "[]\"{}*"):
+		return SearchSegment{}, fmt.Errorf("invalid path segment %q", token)
+	}
+	return SearchSegment{Name: token}, nil
+}
+
+// parseSearchCondition parses a comparison such as Stats.BytesSent>1000 or
+// Alias=="cpe-1"
+func parseSearchCondition(expression string) (SearchCondition, error) {
+	at, op := -1, ""
+	for _, candidate := range searchOperators {
+		if i := strings.Index(expression, candidate); i > 0 && (at < 0 || i < at) {
+			at, op = i, candidate
+		}
+	}
+	if at < 0 {
+		return SearchCondition{}, fmt.Errorf("invalid search expression %q", expression)
+	}
+
+	condition := SearchCondition{
+		Path:     strings.TrimSpace(expression[:at]),
+		Operator: op,
+		Value:    strings.TrimSpace(expression[at+len(op):]),
+	}
+	if strings.HasPrefix(condition.Value, "\"") {
+		value, err := strconv.Unquote(condition.Value)
+		if err != nil {
+			return SearchCondition{}, fmt.Errorf("invalid string in search expression %q", expression)
+		}
+		condition.Value = value
+	}
+	return condition, nil
+}
+
+// splitOutside splits s at every sep that is not inside brackets or quotes
+func splitOutside(s, sep string) ([]string, error) {
+	parts := []string{}
+	depth, quoted, start := 0, false, 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '"':
+			quoted = !quoted
+		case quoted:
+		case s[i] == '[':
+			depth++
+		case s[i] == ']':
+			depth--
+			if depth < 0 {
+				return nil, fmt.Errorf("unbalanced ']'")
+			}
+		case depth == 0 && strings.HasPrefix(s[i:], sep):
+			parts = append(parts, s[start:i])
+			start = i + len(sep)
+			i += len(sep) - 1
+		}
+	}
+	if depth != 0 || quoted {
+		return nil, fmt.Errorf("unterminated search expression")
+	}
+	return append(parts, s[start:]), nil
+}
+
+// Resolve returns the concrete paths matching a search path, in tree order.
+// Object paths, which end in a dot, match objects and other paths match
+// parameters.
+func (t *InstanceTree) Resolve(searchPath string) ([]string, error) {
+	sp, err := ParseSearchPath(searchPath)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := []*InstanceNode{t.root}
+	for _, segment := range sp.Segments {
+		next := []*InstanceNode{}
+		seen := make(map[*InstanceNode]bool)
+		for _, node := range nodes {
+			for _, match := range t.step(node, segment) {
+				if !seen[match] {
+					seen[match] = true
+					next = append(next, match)
+				}
+			}
+		}
+		nodes = next
+	}
+
+	paths := []string{}
+	for _, node := range nodes {
+		if node != t.root && node.IsParam != sp.IsObject {
+			paths = append(paths, node.Path)
+		}
+	}
+	return paths, nil
+}
+
+// step returns the nodes a search path segment selects below node
+func (t *InstanceTree) step(node *InstanceNode, segment SearchSegment) []*InstanceNode {
+	switch {
+	case segment.Wildcard:
+		return node.instances()
+	case segment.Conditions != nil:
+		matches := []*InstanceNode{}
+		for _, entry := range node.instances() {
+			if entry.matches(segment.Conditions) {
+				matches = append(matches, entry)
+			}
+		}
+		return matches
+	case segment.Reference:
+		param, ok := node.children[segment.Name]
+		if !ok || !param.IsParam {
+			return nil
+		}
+		refs := []string{}
+		for _, ref := range strings.Split(param.Value, ",") {
+			if ref = strings.TrimSpace(ref); ref != "" {
+				refs = append(refs, ref)
+			}
+		}
+		if segment.RefIndex > 0 {
+			if segment.RefIndex > len(refs) {
+				return nil
+			}
+			refs = refs[segment.RefIndex-1 : segment.RefIndex]
+		}
+		targets := []*InstanceNode{}
+		for _, ref := range refs {
+			if target, ok := t.Lookup(ref); ok && !target.IsParam {
+				targets = append(targets, target)
+			}
+		}
+		return targets
+	}
+	if child, ok := node.children[segment.Name]; ok {
+		return []*InstanceNode{child}
+	}
+	return nil
+}
+
+// matches reports whether a table entry satisfies every condition
+func (n *InstanceNode) matches(conditions []SearchCondition) bool {
+	for _, condition := range conditions {
+		param, ok := n.lookup(condition.Path)
+		if !ok || !param.IsParam || !compareSearchValue(param.Value, condition.Operator, condition.Value) {
+			return false
+		}
+	}
+	return true
+}
+
+// compareSearchValue compares a parameter value with the value of a search
+// expression, numerically when both are numbers and as booleans when both
+// are booleans
+func compareSearchValue(actual, op, expected string) bool {
+	cmp := strings.Compare(actual, expected)
+	a, aErr := strconv.ParseFloat(actual, 64)
+	e, eErr := strconv.ParseFloat(expected, 64)
+	if aErr == nil && eErr == nil {
+		switch {
+		case a < e:
+			cmp = -1
+		case a > e:
+			cmp = 1
+		default:
+			cmp = 0
+		}
+	} else if a, err := DecodeBoolean(actual); err == nil {
+		if e, err := DecodeBoolean(expected); err == nil {
+			if op != "==" && op != "!=" {
+				return false
+			}
+			cmp = 1
+			if a == e {
+				cmp = 0
+			}
+		}
+	}
+
+	switch op {
+	case "==":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	}
+	return false
+}
+
+// isInstanceNumber reports whether a path segment is an instance number
+func isInstanceNumber(segment string) bool {
+	n, err := strconv.Atoi(segment)
+	return err == nil && n > 0 && strconv.Itoa(n) == segment
+}
+
+// lessInstancePath orders paths segment by segment, comparing instance
+// numbers numerically
+func lessInstancePath(a, b string) bool {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if as[i] == bs[i] {
+			continue
+		}
+		if isInstanceNumber(as[i]) && isInstanceNumber(bs[i]) {
+			an, _ := strconv.Atoi(as[i])
+			bn, _ := strconv.Atoi(bs[i])
+			return an < bn
+		}
+		return as[i] < bs[i]
+	}
+	return len(as) < len(bs)
+}
+`
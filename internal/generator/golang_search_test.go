@@ -0,0 +1,159 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Niceblueman/cwmp-codegen/internal/models"
+)
+
+func TestGenerateGolangSearchPath(t *testing.T) {
+	model := &models.DataModel{
+		Name: "Device:2.16",
+		Objects: []models.Object{
+			{Name: "Device.", Path: "Device."},
+			{Name: "Device.WiFi.SSID.{i}.", Path: "Device.WiFi.SSID.{i}.", Parameters: []models.Parameter{
+				{Name: "Enable", Type: "boolean"},
+			}},
+		},
+	}
+
+	tmpDir := t.TempDir()
+	files, err := GenerateGolang(model, tmpDir)
+	if err != nil {
+		t.Fatalf("GenerateGolang returned error: %v", err)
+	}
+	if !contains(files, "search_path.go") {
+		t.Fatalf("Expected search_path.go in %v", files)
+	}
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, "search_path.go"))
+	if err != nil {
+		t.Fatalf("Failed to read search_path.go: %v", err)
+	}
+
+	for _, expected := range []string{
+		"func (obj *Device) InstanceTree() *InstanceTree {",
+		"func NewInstanceTree(values map[string]string) *InstanceTree {",
+		"func ParseSearchPath(path string) (*SearchPath, error) {",
+		"func (t *InstanceTree) Resolve(searchPath string) ([]string, error) {",
+		`case token == "*" || token == "{i}":`,
+	} {
+		if !strings.Contains(string(content), expected) {
+			t.Errorf("Expected search_path.go to contain %q", expected)
+		}
+	}
+
+	if strings.Contains(string(content), "func (obj *Device_WiFi_SSID_Instance) InstanceTree()") {
+		t.Error("Expected InstanceTree only on root objects")
+	}
+}
+
+func TestGolangSearchPathResolves(t *testing.T) {
+	model := &models.DataModel{
+		Name: "Device:2.16",
+		Objects: []models.Object{
+			{Name: "Device.", Path: "Device."},
+			{Name: "Device.WiFi.SSID.{i}.", Path: "Device.WiFi.SSID.{i}.", Parameters: []models.Parameter{
+				{Name: "Enable", Type: "boolean"},
+			}},
+		},
+	}
+
+	runGeneratedGoTests(t, GenerateGolang, model, map[string]string{"search_test.go": `package messages
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	tree := NewInstanceTree(map[string]string{
+		"Device.WiFi.SSID.1.Enable":      "true",
+		"Device.WiFi.SSID.1.Name":        "a",
+		"Device.WiFi.SSID.1.Stats.Bytes": "20",
+		"Device.WiFi.SSID.1.LowerLayers": "Device.WiFi.Radio.2.",
+		"Device.WiFi.SSID.10.Enable":     "false",
+		"Device.WiFi.SSID.10.Name":       "b.c",
+		"Device.WiFi.SSID.2.Enable":      "1",
+		"Device.WiFi.SSID.2.Name":        "x&&y",
+		"Device.WiFi.SSID.2.Stats.Bytes": "100",
+		"Device.WiFi.SSID.2.LowerLayers": "Device.WiFi.Radio.1.,Device.WiFi.Radio.2.",
+		"Device.WiFi.Radio.1.Name":       "r1",
+		"Device.WiFi.Radio.2.Name":       "r2",
+	})
+
+	tests := map[string][]string{
+		"Device.WiFi.SSID.*.":       {"Device.WiFi.SSID.1.", "Device.WiFi.SSID.2.", "Device.WiFi.SSID.10."},
+		"Device.WiFi.SSID.{i}.Name": {"Device.WiFi.SSID.1.Name", "Device.WiFi.SSID.2.Name", "Device.WiFi.SSID.10.Name"},
+		"Device.WiFi.SSID.1.":       {"Device.WiFi.SSID.1."},
+		"Device.WiFi.SSID.3.":       {},
+
+		// "1" and "true" are both true, "false" is not
+		"Device.WiFi.SSID.[Enable==true].": {"Device.WiFi.SSID.1.", "Device.WiFi.SSID.2."},
+		"Device.WiFi.SSID.[Enable!=1].":    {"Device.WiFi.SSID.10."},
+		"Device.WiFi.SSID.[Enable<true].":  {},
+
+		// 20 < 100 numerically although "20" > "100" as strings
+		"Device.WiFi.SSID.[Stats.Bytes>=100].": {"Device.WiFi.SSID.2."},
+		"Device.WiFi.SSID.[Stats.Bytes<100].":  {"Device.WiFi.SSID.1."},
+
+		"Device.WiFi.SSID.[Stats.Bytes>10&&Enable==true].": {"Device.WiFi.SSID.1.", "Device.WiFi.SSID.2."},
+		"Device.WiFi.SSID.[Stats.Bytes>10&&Name==\"a\"].":  {"Device.WiFi.SSID.1."},
+
+		// Quoted values may contain dots and operators
+		"Device.WiFi.SSID.[Name==\"b.c\"].Enable": {"Device.WiFi.SSID.10.Enable"},
+		"Device.WiFi.SSID.[Name==\"x&&y\"].":      {"Device.WiFi.SSID.2."},
+
+		"Device.WiFi.SSID.*.LowerLayers#.Name": {"Device.WiFi.Radio.2.Name", "Device.WiFi.Radio.1.Name"},
+		"Device.WiFi.SSID.2.LowerLayers#1.":    {"Device.WiFi.Radio.1."},
+		"Device.WiFi.SSID.2.LowerLayers#2.":    {"Device.WiFi.Radio.2."},
+		"Device.WiFi.SSID.2.LowerLayers#3.":    {},
+	}
+	for path, want := range tests {
+		got, err := tree.Resolve(path)
+		if err != nil {
+			t.Errorf("%s: %v", path, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %v, want %v", path, got, want)
+		}
+	}
+
+	for _, path := range []string{
+		"Device.WiFi.SSID.[Enable==true",
+		"Device..Enable",
+		"Device.WiFi.SSID.[Enable].",
+		"Device.WiFi.SSID.2.LowerLayers#x.",
+	} {
+		if _, err := tree.Resolve(path); err == nil {
+			t.Errorf("%s: expected an error", path)
+		}
+	}
+}
+
+func TestLessInstancePath(t *testing.T) {
+	tests := []struct {
+		a, b string
+		less bool
+	}{
+		{"Device.WiFi.SSID.2.", "Device.WiFi.SSID.10.", true},
+		{"Device.WiFi.SSID.10.", "Device.WiFi.SSID.2.", false},
+		{"Device.WiFi.SSID.2.Name", "Device.WiFi.SSID.10.Enable", true},
+		{"Device.WiFi.Radio.", "Device.WiFi.SSID.", true},
+		{"Device.WiFi.SSID.1.", "Device.WiFi.SSID.1.Name", true},
+		{"Device.WiFi.SSID.1.", "Device.WiFi.SSID.1.", false},
+		// Leading zeros are not instance numbers and compare as strings
+		{"Device.A.02.", "Device.A.1.", true},
+	}
+	for _, tt := range tests {
+		if got := lessInstancePath(tt.a, tt.b); got != tt.less {
+			t.Errorf("lessInstancePath(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.less)
+		}
+	}
+}
+`})
+}
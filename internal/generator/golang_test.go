@@ -2,6 +2,7 @@ package generator
 
 import (
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -44,8 +45,8 @@ func TestGenerateGolang(t *testing.T) {
 		t.Fatalf("GenerateGolang returned error: %v", err)
 	}
 
-	// Check that we got the expected files (common_types.go + one per message)
-	expectedFileCount := 1 + len(model.Objects) // common_types.go + one file per object
+	// Check that we got the expected files (shared files + one per message)
+	expectedFileCount := 8 + len(model.Objects) // common_types.go, tr069_helper.go, value_codec.go, binder.go, search_path.go, acs_session.go, cwmp_auth.go, inform_params.go + one file per object
 	if len(files) != expectedFileCount {
 		t.Fatalf("Expected %d files, got %d", expectedFileCount, len(files))
 	}
@@ -145,7 +146,7 @@ func TestConvertObjectToGoStruct(t *testing.T) {
 		},
 	}
 
-	goStruct := convertObjectToGoStruct(obj)
+	goStruct := convertObjectToGoStruct(obj, nil)
 
 	if goStruct.Name != "SimpleObject" {
 		t.Errorf("Expected struct name 'SimpleObject', got '%s'", goStruct.Name)
@@ -168,6 +169,119 @@ func TestConvertObjectToGoStruct(t *testing.T) {
 	}
 }
 
+func TestConvertObjectToGoStructList(t *testing.T) {
+	obj := models.Object{
+		Name: "ListObject",
+		Parameters: []models.Parameter{
+			{
+				Name:     "Ports",
+				Type:     "list",
+				IsList:   true,
+				ItemType: "unsignedInt",
+				Syntax: models.Syntax{
+					List: &models.List{MaxItems: "4", Size: &models.Size{Max: 32}},
+				},
+			},
+			{
+				Name:     "DNSServers",
+				Type:     "list",
+				IsList:   true,
+				ItemType: "IPAddress",
+				Syntax:   models.Syntax{List: &models.List{}},
+			},
+			{
+				Name:     "Counters",
+				Type:     "list",
+				IsList:   true,
+				ItemType: "StatsCounter32",
+				Syntax:   models.Syntax{List: &models.List{}},
+			},
+		},
+	}
+	dataTypes := map[string]models.DataType{
+		"StatsCounter32": {Name: "StatsCounter32", UnsignedInt: &models.UnsignedInt{}},
+	}
+
+	goStruct := convertObjectToGoStruct(obj, dataTypes)
+
+	ports := goStruct.Parameters[0]
+	if ports.GoType != "[]uint32" || ports.ListCodec != "UnsignedInt" {
+		t.Errorf("Expected []uint32 with UnsignedInt codec, got '%s' with '%s'", ports.GoType, ports.ListCodec)
+	}
+	if ports.MaxItems != 4 || ports.MaxLength != 32 {
+		t.Errorf("Expected maxItems 4 and maxLength 32, got %d and %d", ports.MaxItems, ports.MaxLength)
+	}
+
+	if goStruct.Parameters[1].GoType != "[]string" {
+		t.Errorf("Expected named dataType list to be []string, got '%s'", goStruct.Parameters[1].GoType)
+	}
+	if goStruct.Parameters[2].GoType != "[]uint32" || goStruct.Parameters[2].ListCodec != "UnsignedInt" {
+		t.Errorf("Expected unsignedInt based dataType list to be []uint32, got '%s' with '%s'",
+			goStruct.Parameters[2].GoType, goStruct.Parameters[2].ListCodec)
+	}
+
+	tmpDir := t.TempDir()
+	model := &models.DataModel{Name: "ListModel", Objects: []models.Object{obj}}
+	if _, err := GenerateGolang(model, tmpDir); err != nil {
+		t.Fatalf("GenerateGolang returned error: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, "ListObject.go"))
+	if err != nil {
+		t.Fatalf("Failed to read generated file: %v", err)
+	}
+
+	if !strings.Contains(string(content), "func (msg *ListObject) UnmarshalPorts(value string) error") {
+		t.Error("Generated code doesn't contain expected list unmarshal helper")
+	}
+	if !strings.Contains(string(content), "CheckList(value, len(msg.Ports), 0, 4, 32)") {
+		t.Error("Generated code doesn't enforce the list facets")
+	}
+}
+
+func TestGolangListItemsRun(t *testing.T) {
+	model := &models.DataModel{
+		Name: "ListModel",
+		Objects: []models.Object{
+			{
+				Name: "Device.DeviceInfo.",
+				Path: "Device.DeviceInfo.",
+				Parameters: []models.Parameter{
+					{Name: "Flags", Type: "list", IsList: true, ItemType: "boolean", FullPath: "Device.DeviceInfo.Flags"},
+					{Name: "Offsets", Type: "list", IsList: true, ItemType: "int", FullPath: "Device.DeviceInfo.Offsets"},
+				},
+			},
+		},
+	}
+
+	runGeneratedGoTests(t, GenerateGolang, model, map[string]string{"list_test.go": `package messages
+
+import "testing"
+
+func TestListItems(t *testing.T) {
+	if v, err := ParseBoolListItem("1"); err != nil || !v {
+		t.Errorf("Expected 1 to decode to true, got %v %v", v, err)
+	}
+	for _, s := range []string{"t", "TRUE", "yes"} {
+		if _, err := ParseBoolListItem(s); err == nil {
+			t.Errorf("Expected %q to be rejected", s)
+		}
+	}
+	if v, err := ParseIntListItem("-2147483648"); err != nil || v != -2147483648 {
+		t.Errorf("Expected the int minimum to decode, got %d %v", v, err)
+	}
+	if _, err := ParseIntListItem("2147483648"); err == nil {
+		t.Error("Expected values above 2^31-1 to fail")
+	}
+
+	var info Device_DeviceInfo
+	if err := info.UnmarshalFlags("true,0"); err != nil || len(info.Flags) != 2 || !info.Flags[0] || info.Flags[1] {
+		t.Errorf("Expected Flags to hold [true false], got %v %v", info.Flags, err)
+	}
+}
+`})
+}
+
 // Helper function to check if a slice contains a string
 func contains(slice []string, str string) bool {
 	for _, item := range slice {
@@ -177,3 +291,89 @@ func contains(slice []string, str string) bool {
 	}
 	return false
 }
+
+// xmlxStub stands in for github.com/jteeuwen/go-pkg-xmlx, which the
+// generated messages import, so that generated packages build offline
+const xmlxStub = `package xmlx
+
+import "io"
+
+type CharsetFunc func(charset string, input io.Reader) (io.Reader, error)
+
+type Node struct{ Children []*Node }
+
+func (n *Node) SelectNode(namespace, name string) *Node    { return nil }
+func (n *Node) SelectNodes(namespace, name string) []*Node { return nil }
+func (n *Node) GetValue() string                           { return "" }
+func (n *Node) GetAttr(namespace, name string) string      { return "" }
+func (n *Node) String() string                             { return "" }
+
+type Document struct{ Root *Node }
+
+func New() *Document                                                 { return &Document{} }
+func (d *Document) LoadBytes(data []byte, charset CharsetFunc) error { return nil }
+func (d *Document) SelectNode(namespace, name string) *Node          { return nil }
+func (d *Document) SelectNodes(namespace, name string) []*Node       { return nil }
+`
+
+// runGeneratedGoTests generates a Go package into a temporary module and runs
+// the given test files, keyed by file name, against the generated code
+func runGeneratedGoTests(t *testing.T, generate func(*models.DataModel, string) ([]string, error), model *models.DataModel, tests map[string]string) {
+	t.Helper()
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("no Go toolchain available")
+	}
+
+	moduleDir := t.TempDir()
+	packageDir := filepath.Join(moduleDir, "messages")
+	xmlxDir := filepath.Join(moduleDir, "xmlx")
+	for _, dir := range []string{packageDir, xmlxDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("Failed to create %s: %v", dir, err)
+		}
+	}
+	if _, err := generate(model, packageDir); err != nil {
+		t.Fatalf("Generating Go code returned error: %v", err)
+	}
+
+	// The TR-069 helpers and ParseXML refer to RPC message types that are only
+	// generated for models declaring them, so they are left out along with the
+	// regexp import common_types.go carries for the helpers
+	if err := os.Remove(filepath.Join(packageDir, "tr069_helper.go")); err != nil {
+		t.Fatalf("Failed to remove tr069_helper.go: %v", err)
+	}
+	commonTypes := filepath.Join(packageDir, "common_types.go")
+	content, err := os.ReadFile(commonTypes)
+	if err != nil {
+		t.Fatalf("Failed to read common_types.go: %v", err)
+	}
+	if i := strings.Index(string(content), "// ParseXML parses"); i >= 0 {
+		content = content[:i]
+	}
+	content = []byte(strings.Replace(string(content), "\t\"regexp\"\n", "", 1))
+	if err := os.WriteFile(commonTypes, content, 0644); err != nil {
+		t.Fatalf("Failed to write common_types.go: %v", err)
+	}
+
+	files := map[string]string{
+		filepath.Join(moduleDir, "go.mod"): "module generated\n\ngo 1.23\n\nrequire github.com/jteeuwen/go-pkg-xmlx v0.0.0\n\nreplace github.com/jteeuwen/go-pkg-xmlx => ./xmlx\n",
+		filepath.Join(xmlxDir, "go.mod"):   "module github.com/jteeuwen/go-pkg-xmlx\n\ngo 1.23\n",
+		filepath.Join(xmlxDir, "xmlx.go"):  xmlxStub,
+	}
+	for name, content := range tests {
+		files[filepath.Join(packageDir, name)] = content
+	}
+	for file, content := range files {
+		if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", file, err)
+		}
+	}
+
+	cmd := exec.Command(goTool, "test", "./messages")
+	cmd.Dir = moduleDir
+	cmd.Env = append(os.Environ(), "GOWORK=off", "GOFLAGS=-mod=mod", "GOPROXY=off")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("Tests of the generated code failed: %v\n%s", err, output)
+	}
+}
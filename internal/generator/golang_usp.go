@@ -0,0 +1,546 @@
+package generator
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/Niceblueman/cwmp-codegen/internal/models"
+)
+
+// USP template for command and event arguments and the supported data model
+const uspTemplate = `// Code generated by cwmp-codegen. DO NOT EDIT.
+package {{.PackageName}}
+
+import (
+{{- if .ArgStructs}}
+	"fmt"
+{{- end}}
+	"strings"
+{{- if .UsesTime}}
+	"time"
+{{- end}}
+)
+
+// ObjAccessType is the access of an object in a GetSupportedDM response
+type ObjAccessType int
+
+// Object access types, numbered as in the USP protobuf schema
+const (
+	ObjReadOnly ObjAccessType = iota
+	ObjAddDelete
+	ObjAddOnly
+	ObjDeleteOnly
+)
+
+// ParamAccessType is the access of a parameter in a GetSupportedDM response
+type ParamAccessType int
+
+// Parameter access types, numbered as in the USP protobuf schema
+const (
+	ParamReadOnly ParamAccessType = iota
+	ParamReadWrite
+	ParamWriteOnly
+)
+
+// ParamValueType is the value type of a parameter in a GetSupportedDM response
+type ParamValueType int
+
+// Parameter value types, numbered as in the USP protobuf schema
+const (
+	ParamUnknown ParamValueType = iota
+	ParamBase64
+	ParamBoolean
+	ParamDateTime
+	ParamDecimal
+	ParamHexBinary
+	ParamInt
+	ParamLong
+	ParamString
+	ParamUnsignedInt
+	ParamUnsignedLong
+)
+
+// ValueChangeType tells whether a parameter supports value change notifications
+type ValueChangeType int
+
+// Value change types, numbered as in the USP protobuf schema
+const (
+	ValueChangeUnknown ValueChangeType = iota
+	ValueChangeAllowed
+	ValueChangeWillIgnore
+)
+
+// CmdType tells whether a command is synchronous or asynchronous
+type CmdType int
+
+// Command types, numbered as in the USP protobuf schema
+const (
+	CmdUnknown CmdType = iota
+	CmdSync
+	CmdAsync
+)
+
+// SupportedObject describes an object as reported in a GetSupportedDM response
+type SupportedObject struct {
+	Path            string
+	Access          ObjAccessType
+	IsMultiInstance bool
+	Commands        []SupportedCommand
+	Events          []SupportedEvent
+	Params          []SupportedParam
+}
+
+// SupportedParam describes a parameter of a SupportedObject
+type SupportedParam struct {
+	Name        string
+	Access      ParamAccessType
+	ValueType   ParamValueType
+	ValueChange ValueChangeType
+}
+
+// SupportedCommand describes a command of a SupportedObject
+type SupportedCommand struct {
+	Name           string
+	InputArgNames  []string
+	OutputArgNames []string
+	Type           CmdType
+}
+
+// SupportedEvent describes an event of a SupportedObject
+type SupportedEvent struct {
+	Name     string
+	ArgNames []string
+}
+
+// SupportedDataModel lists the objects of the data model with {i} in place
+// of instance numbers
+var SupportedDataModel = []SupportedObject{
+{{- range .Objects}}
+	{
+		Path:            "{{.Path}}",
+		Access:          {{.Access}},
+		IsMultiInstance: {{.IsMultiInstance}},
+{{- if .Commands}}
+		Commands: []SupportedCommand{
+{{- range .Commands}}
+			{Name: "{{.Name}}", InputArgNames: {{.InputArgNames}}, OutputArgNames: {{.OutputArgNames}}, Type: {{.Type}}},
+{{- end}}
+		},
+{{- end}}
+{{- if .Events}}
+		Events: []SupportedEvent{
+{{- range .Events}}
+			{Name: "{{.Name}}", ArgNames: {{.ArgNames}}},
+{{- end}}
+		},
+{{- end}}
+{{- if .Params}}
+		Params: []SupportedParam{
+{{- range .Params}}
+			{Name: "{{.Name}}", Access: {{.Access}}, ValueType: {{.ValueType}}, ValueChange: {{.ValueChange}}},
+{{- end}}
+		},
+{{- end}}
+	},
+{{- end}}
+}
+
+// LookupSupportedObject returns the supported object with the given path.
+// Concrete instance numbers are matched against {i} placeholders.
+func LookupSupportedObject(path string) (*SupportedObject, bool) {
+	if !strings.HasSuffix(path, ".") {
+		path += "."
+	}
+	for instanceNumberPattern.MatchString(path) {
+		path = instanceNumberPattern.ReplaceAllString(path, ".{i}.")
+	}
+	for i := range SupportedDataModel {
+		if SupportedDataModel[i].Path == path {
+			return &SupportedDataModel[i], true
+		}
+	}
+	return nil, false
+}
+
+// USPArguments is implemented by the input and output arguments of commands
+// and by the arguments of events
+type USPArguments interface {
+	// Args returns the arguments keyed by their path relative to the
+	// command or event, as carried by Operate responses and Notify messages
+	Args() map[string]string
+	// SetArgs populates the arguments from values keyed by relative path
+	SetArgs(args map[string]string) error
+}
+{{range .ArgStructs}}
+// {{.GoName}} {{.Doc}}
+type {{.GoName}} struct {
+{{- if .IsTable}}
+	InstanceNumber int // Instance number of this table entry
+{{- end}}
+{{- range .Parameters}}
+	{{.GoName}} {{.GoType}}{{if .Description}} // {{.Description | lineComment}}{{end}}
+{{- end}}
+{{- range .ChildObjects}}
+	{{.GoName}} {{.GoType}}
+{{- end}}
+}
+{{if .Path}}
+// {{.Kind}} returns the path of the {{.Kind | lower}}, with {i} in place of instance numbers
+func (a *{{.GoName}}) {{.Kind}}() string {
+	return "{{.Path}}"
+}
+
+// Args returns the arguments keyed by their path relative to the {{.Kind | lower}}
+func (a *{{.GoName}}) Args() map[string]string {
+	out := make(map[string]string)
+	a.flattenArgs("", out)
+	return out
+}
+
+// SetArgs populates the arguments from values keyed by relative path
+func (a *{{.GoName}}) SetArgs(args map[string]string) error {
+	return bindTree(args, "", a.bindArg)
+}
+{{end}}
+// bindArg assigns an argument at a path relative to {{.GoName}}
+func (a *{{.GoName}}) bindArg(path []string, value string) error {
+	if len(path) == 0 {
+		return fmt.Errorf("missing argument name")
+	}
+	if len(path) == 1 {
+		switch path[0] {
+{{- range .Parameters}}
+		case "{{.Name}}":
+{{- if .IsList}}
+			items, err := UnmarshalList(value, Parse{{.ListCodec}}ListItem)
+			if err != nil {
+				return err
+			}
+			if err := CheckList(value, len(items), {{.MinItems}}, {{.MaxItems}}, {{.MaxLength}}); err != nil {
+				return err
+			}
+			a.{{.GoName}} = items
+{{- else if .Codec}}
+			v, err := Decode{{.Codec}}(value{{.DecodeArgs}})
+			if err != nil {
+				return err
+			}
+			a.{{.GoName}} = v
+{{- else}}
+			a.{{.GoName}} = value
+{{- end}}
+			return nil
+{{- end}}
+		}
+		return fmt.Errorf("unknown argument %q", path[0])
+	}
+
+	switch path[0] {
+{{- range .ChildObjects}}
+	case "{{.Name}}":
+{{- if .IsMultiInstance}}
+		entry, err := bindInstance(&a.{{.GoName}}, path[1], func(e *{{.StructName}}) *int { return &e.InstanceNumber })
+		if err != nil {
+			return err
+		}
+		return entry.bindArg(path[2:], value)
+{{- else}}
+		return a.{{.GoName}}.bindArg(path[1:], value)
+{{- end}}
+{{- end}}
+	}
+	return fmt.Errorf("unknown argument object %q", path[0])
+}
+
+// flattenArgs adds the arguments of {{.GoName}} and its children under prefix to out
+func (a *{{.GoName}}) flattenArgs(prefix string, out map[string]string) {
+{{- range .Parameters}}
+{{- if .IsList}}
+	out[prefix+"{{.Name}}"] = MarshalList(a.{{.GoName}}, Format{{.ListCodec}}ListItem)
+{{- else if .Codec}}
+	out[prefix+"{{.Name}}"] = Encode{{.Codec}}(a.{{.GoName}})
+{{- else}}
+	out[prefix+"{{.Name}}"] = rawValue(a.{{.GoName}})
+{{- end}}
+{{- end}}
+{{- range .ChildObjects}}
+{{- if .IsMultiInstance}}
+	for i := range a.{{.GoName}} {
+		a.{{.GoName}}[i].flattenArgs(instancePath(prefix, "{{.Name}}", a.{{.GoName}}[i].InstanceNumber), out)
+	}
+{{- else}}
+	a.{{.GoName}}.flattenArgs(prefix+"{{.Name}}.", out)
+{{- end}}
+{{- end}}
+}
+{{end}}`
+
+// USPTemplate contains data for the USP template
+type USPTemplate struct {
+	PackageName string
+	Objects     []USPSupportedObject
+	ArgStructs  []USPArgStruct
+	UsesTime    bool
+}
+
+// USPSupportedObject is an entry of the generated SupportedDataModel. The
+// enum fields hold the names of the generated constants.
+type USPSupportedObject struct {
+	Path            string
+	Access          string
+	IsMultiInstance bool
+	Commands        []USPSupportedCommand
+	Events          []USPSupportedEvent
+	Params          []USPSupportedParam
+}
+
+// USPSupportedCommand is a command of a USPSupportedObject
+type USPSupportedCommand struct {
+	Name           string
+	InputArgNames  string // Go []string literal
+	OutputArgNames string // Go []string literal
+	Type           string
+}
+
+// USPSupportedEvent is an event of a USPSupportedObject
+type USPSupportedEvent struct {
+	Name     string
+	ArgNames string // Go []string literal
+}
+
+// USPSupportedParam is a parameter of a USPSupportedObject
+type USPSupportedParam struct {
+	Name        string
+	Access      string
+	ValueType   string
+	ValueChange string
+}
+
+// USPArgStruct is a Go struct holding command or event arguments, or an
+// argument object nested in them
+type USPArgStruct struct {
+	GoName       string
+	Doc          string
+	Kind         string // Command or Event for the top-level structs
+	Path         string // Command or event path, empty for nested structs
+	Parameters   []GoParameter
+	ChildObjects []GoChildObject
+	IsTable      bool
+}
+
+// GenerateGolangUSP generates the Go code of GenerateGolang together with
+// usp.go, which holds the USP (TR-369) command and event argument structs
+// and the supported data model in the shape of a GetSupportedDM response,
+// and usp_msg.go, which builds USP requests and decodes their responses
+func GenerateGolangUSP(model *models.DataModel, outputDir string) ([]string, error) {
+	outputFiles, err := GenerateGolang(model, outputDir)
+	if err != nil {
+		return outputFiles, err
+	}
+
+	funcMap := template.FuncMap{
+		"lineComment": func(s string) string { return strings.TrimPrefix(formatComment(s), "// ") },
+		"lower":       strings.ToLower,
+	}
+	tmpl, err := template.New("usp").Funcs(funcMap).Parse(uspTemplate)
+	if err != nil {
+		return outputFiles, err
+	}
+
+	tmplData := convertModelToUSP(model)
+	tmplData.PackageName = "messages"
+	if err := writeTemplate(tmpl, filepath.Join(outputDir, "usp.go"), tmplData); err != nil {
+		return outputFiles, err
+	}
+	outputFiles = append(outputFiles, "usp.go")
+
+	// Generate the USP message bindings
+	tmpl, err = template.New("usp_msg").Parse(uspMsgTemplate)
+	if err != nil {
+		return outputFiles, err
+	}
+	if err := writeTemplate(tmpl, filepath.Join(outputDir, "usp_msg.go"), tmplData); err != nil {
+		return outputFiles, err
+	}
+	return append(outputFiles, "usp_msg.go"), nil
+}
+
+// convertModelToUSP collects the supported objects and argument structs of a
+// data model
+func convertModelToUSP(model *models.DataModel) USPTemplate {
+	tmplData := USPTemplate{
+		Objects:    []USPSupportedObject{},
+		ArgStructs: []USPArgStruct{},
+	}
+
+	dataTypes := make(map[string]models.DataType)
+	for _, dataType := range model.DataTypes {
+		dataTypes[dataType.Name] = dataType
+	}
+
+	for _, obj := range model.Objects {
+		path := objectPathPrefix(obj)
+		supported := USPSupportedObject{
+			Path:            path,
+			Access:          "ObjReadOnly",
+			IsMultiInstance: strings.HasSuffix(path, ".{i}."),
+		}
+		if supported.IsMultiInstance && obj.Access == "readWrite" {
+			supported.Access = "ObjAddDelete"
+		}
+
+		for _, param := range obj.Parameters {
+			supported.Params = append(supported.Params, convertParameterToUSP(param, dataTypes))
+		}
+
+		for _, cmd := range obj.Commands {
+			cmdPath := path + cmd.Name
+			structName := toExportedName(sanitize(strings.TrimSuffix(cmdPath, "()")))
+			supportedCmd := USPSupportedCommand{
+				Name:           cmd.Name,
+				InputArgNames:  uspArgNamesLiteral(cmd.Input),
+				OutputArgNames: uspArgNamesLiteral(cmd.Output),
+				Type:           "CmdSync",
+			}
+			if cmd.Async {
+				supportedCmd.Type = "CmdAsync"
+			}
+			supported.Commands = append(supported.Commands, supportedCmd)
+
+			if cmd.Input != nil {
+				tmplData.ArgStructs = append(tmplData.ArgStructs,
+					convertArgumentsToGo(structName+"Input", "holds the input arguments of "+cmdPath, "Command", cmdPath, *cmd.Input, dataTypes)...)
+			}
+			if cmd.Output != nil {
+				tmplData.ArgStructs = append(tmplData.ArgStructs,
+					convertArgumentsToGo(structName+"Output", "holds the output arguments of "+cmdPath, "Command", cmdPath, *cmd.Output, dataTypes)...)
+			}
+		}
+
+		for _, event := range obj.Events {
+			eventPath := path + event.Name
+			args := models.Arguments{Parameters: event.Parameters, Objects: event.Objects}
+			supported.Events = append(supported.Events, USPSupportedEvent{
+				Name:     event.Name,
+				ArgNames: uspArgNamesLiteral(&args),
+			})
+
+			structName := toExportedName(sanitize(strings.TrimSuffix(eventPath, "!"))) + "Event"
+			tmplData.ArgStructs = append(tmplData.ArgStructs,
+				convertArgumentsToGo(structName, "holds the arguments of "+eventPath, "Event", eventPath, args, dataTypes)...)
+		}
+
+		tmplData.Objects = append(tmplData.Objects, supported)
+	}
+
+	for _, argStruct := range tmplData.ArgStructs {
+		for _, param := range argStruct.Parameters {
+			if strings.Contains(param.GoType, "time.Time") {
+				tmplData.UsesTime = true
+			}
+		}
+	}
+	return tmplData
+}
+
+// convertParameterToUSP describes a parameter for GetSupportedDM. Lists are
+// strings on the wire, and parameters that may deny active notification
+// will not report value changes.
+func convertParameterToUSP(param models.Parameter, dataTypes map[string]models.DataType) USPSupportedParam {
+	supported := USPSupportedParam{
+		Name:        param.Name,
+		Access:      "ParamReadOnly",
+		ValueType:   "ParamString",
+		ValueChange: "ValueChangeAllowed",
+	}
+	if param.Access == "readWrite" {
+		supported.Access = "ParamReadWrite"
+	}
+	if !param.IsList {
+		supported.ValueType = mapCWMPTypeToUSPValueType(models.ResolveSyntax(param, dataTypes).Type)
+	}
+	if param.ActiveNotify == "canDeny" {
+		supported.ValueChange = "ValueChangeWillIgnore"
+	}
+	return supported
+}
+
+// mapCWMPTypeToUSPValueType maps CWMP types to the generated ParamValueType constants
+func mapCWMPTypeToUSPValueType(cwmpType string) string {
+	switch strings.ToLower(cwmpType) {
+	case "base64":
+		return "ParamBase64"
+	case "boolean", "bool":
+		return "ParamBoolean"
+	case "datetime":
+		return "ParamDateTime"
+	case "decimal":
+		return "ParamDecimal"
+	case "hexbinary":
+		return "ParamHexBinary"
+	case "int", "integer":
+		return "ParamInt"
+	case "long":
+		return "ParamLong"
+	case "unsignedint", "unsignedinteger":
+		return "ParamUnsignedInt"
+	case "unsignedlong":
+		return "ParamUnsignedLong"
+	default:
+		return "ParamString"
+	}
+}
+
+// uspArgNamesLiteral returns the argument names of a command or event as a Go
+// []string literal. Parameters of argument objects are named by their
+// relative path, such as RouteHops.{i}.Host.
+func uspArgNamesLiteral(args *models.Arguments) string {
+	if args == nil {
+		return "nil"
+	}
+	names := []string{}
+	for _, param := range args.Parameters {
+		names = append(names, strconv.Quote(param.Name))
+	}
+	for _, obj := range args.Objects {
+		for _, param := range obj.Parameters {
+			names = append(names, strconv.Quote(objectPathPrefix(obj)+param.Name))
+		}
+	}
+	if len(names) == 0 {
+		return "nil"
+	}
+	return "[]string{" + strings.Join(names, ", ") + "}"
+}
+
+// convertArgumentsToGo converts command or event arguments to a top-level
+// struct named structName and one struct per argument object. The argument
+// objects are linked into a tree below the top-level struct by their paths.
+func convertArgumentsToGo(structName, doc, kind, path string, args models.Arguments, dataTypes map[string]models.DataType) []USPArgStruct {
+	objects := []models.Object{{Name: structName + ".", Path: structName + ".", Parameters: args.Parameters}}
+	for _, obj := range args.Objects {
+		argPath := structName + "." + objectPathPrefix(obj)
+		obj.Name, obj.Path = argPath, argPath
+		objects = append(objects, obj)
+	}
+
+	tree := linkGoObjectTree(&models.DataModel{Objects: objects})
+	argStructs := []USPArgStruct{}
+	for i, obj := range objects {
+		goObj := convertObjectToGoStruct(obj, dataTypes)
+		argStruct := USPArgStruct{
+			GoName:       goObj.GoName,
+			Doc:          "holds the " + strings.TrimPrefix(objectPathPrefix(obj), structName+".") + " arguments of " + path,
+			Parameters:   goObj.Parameters,
+			ChildObjects: tree[objectPathPrefix(obj)],
+			IsTable:      strings.HasSuffix(objectPathPrefix(obj), ".{i}."),
+		}
+		if i == 0 {
+			argStruct.Doc, argStruct.Kind, argStruct.Path = doc, kind, path
+		}
+		argStructs = append(argStructs, argStruct)
+	}
+	return argStructs
+}
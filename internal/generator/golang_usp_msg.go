@@ -0,0 +1,912 @@
+package generator
+
+// USP message template. The generated code encodes USP requests and decodes
+// USP responses in the protobuf wire format of usp-msg-1-x.proto without
+// depending on a protobuf runtime.
+const uspMsgTemplate = `// Code generated by cwmp-codegen. DO NOT EDIT.
+package {{.PackageName}}
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// USPMsgType is the type of a USP message, numbered as in usp-msg-1-x.proto
+type USPMsgType int32
+
+// USP message types
+const (
+	USPMsgError USPMsgType = iota
+	USPMsgGet
+	USPMsgGetResp
+	USPMsgNotify
+	USPMsgSet
+	USPMsgSetResp
+	USPMsgOperate
+	USPMsgOperateResp
+	USPMsgAdd
+	USPMsgAddResp
+	USPMsgDelete
+	USPMsgDeleteResp
+	USPMsgGetSupportedDM
+	USPMsgGetSupportedDMResp
+	USPMsgGetInstances
+	USPMsgGetInstancesResp
+	USPMsgNotifyResp
+	USPMsgGetSupportedProto
+	USPMsgGetSupportedProtoResp
+)
+
+// USPMsg is a USP message. Exactly one of Request, Response and Error is set.
+type USPMsg struct {
+	MsgID    string
+	MsgType  USPMsgType
+	Request  *USPRequest
+	Response *USPResponse
+	Error    *USPError
+}
+
+// USPRequest is the request body of a USP message. Exactly one field is set.
+type USPRequest struct {
+	Get     *USPGet
+	Set     *USPSet
+	Add     *USPAdd
+	Delete  *USPDelete
+	Operate *USPOperate
+}
+
+// USPResponse is the response body of a USP message. Responses other than
+// GetResp, SetResp and OperateResp are left undecoded.
+type USPResponse struct {
+	GetResp     *USPGetResp
+	SetResp     *USPSetResp
+	OperateResp *USPOperateResp
+}
+
+// USPError is the error body of a USP message
+type USPError struct {
+	ErrCode   uint32
+	ErrMsg    string
+	ParamErrs []USPParamError
+}
+
+// Error implements the error interface
+func (e *USPError) Error() string {
+	return fmt.Sprintf("USP error %d: %s", e.ErrCode, e.ErrMsg)
+}
+
+// USPParamError is the error of a single parameter
+type USPParamError struct {
+	Param   string
+	ErrCode uint32
+	ErrMsg  string
+}
+
+// USPGet requests the values of parameters and objects
+type USPGet struct {
+	ParamPaths []string
+	MaxDepth   uint32
+}
+
+// USPSet requests parameter updates
+type USPSet struct {
+	AllowPartial bool
+	UpdateObjs   []USPObjectParams
+}
+
+// USPAdd requests the creation of table entries
+type USPAdd struct {
+	AllowPartial bool
+	CreateObjs   []USPObjectParams
+}
+
+// USPObjectParams is an object path with parameter settings, as carried by
+// Set and Add requests
+type USPObjectParams struct {
+	ObjPath       string
+	ParamSettings []USPParamSetting
+}
+
+// USPParamSetting is the value of a parameter relative to its object
+type USPParamSetting struct {
+	Param    string
+	Value    string
+	Required bool
+}
+
+// USPDelete requests the deletion of table entries
+type USPDelete struct {
+	AllowPartial bool
+	ObjPaths     []string
+}
+
+// USPOperate requests the execution of a command
+type USPOperate struct {
+	Command    string
+	CommandKey string
+	SendResp   bool
+	InputArgs  map[string]string
+}
+
+// USPGetResp is the response to a Get request
+type USPGetResp struct {
+	ReqPathResults []USPRequestedPathResult
+}
+
+// USPRequestedPathResult is the result of one requested path
+type USPRequestedPathResult struct {
+	RequestedPath       string
+	ErrCode             uint32
+	ErrMsg              string
+	ResolvedPathResults []USPResolvedPathResult
+}
+
+// USPResolvedPathResult holds the parameters of one resolved object, keyed
+// by their path relative to ResolvedPath
+type USPResolvedPathResult struct {
+	ResolvedPath string
+	ResultParams map[string]string
+}
+
+// USPSetResp is the response to a Set request
+type USPSetResp struct {
+	UpdatedObjResults []USPUpdatedObjectResult
+}
+
+// USPUpdatedObjectResult is the result of one updated object path. Exactly
+// one of Failure and Success is set.
+type USPUpdatedObjectResult struct {
+	RequestedPath string
+	Failure       *USPSetFailure
+	Success       *USPSetSuccess
+}
+
+// USPSetFailure is a failed update of an object path
+type USPSetFailure struct {
+	ErrCode             uint32
+	ErrMsg              string
+	UpdatedInstFailures []USPUpdatedInstance
+}
+
+// USPSetSuccess is a successful update of an object path
+type USPSetSuccess struct {
+	UpdatedInstResults []USPUpdatedInstance
+}
+
+// USPUpdatedInstance is the result of the update of one object instance
+type USPUpdatedInstance struct {
+	AffectedPath  string
+	ParamErrs     []USPParamError
+	UpdatedParams map[string]string
+}
+
+// USPOperateResp is the response to an Operate request
+type USPOperateResp struct {
+	OperationResults []USPOperationResult
+}
+
+// USPOperationResult is the result of one executed command. ReqObjPath is
+// set for asynchronous commands, OutputArgs for synchronous ones and Failure
+// on error.
+type USPOperationResult struct {
+	ExecutedCommand string
+	ReqObjPath      string
+	OutputArgs      map[string]string
+	Failure         *USPError
+}
+
+// ParameterBinder is implemented by the root objects of the data model
+type ParameterBinder interface {
+	Bind(values map[string]string) error
+}
+
+// uspSearchPattern matches instance numbers, wildcards and search
+// expressions in a USP path
+var uspSearchPattern = regexp.MustCompile(` + "`\\.([0-9]+|\\*|\\[[^\\]]*\\])\\.`" + `)
+
+// uspTemplatePath replaces the instance numbers, wildcards and search
+// expressions of a USP path with {i}
+func uspTemplatePath(path string) string {
+	for uspSearchPattern.MatchString(path) {
+		path = uspSearchPattern.ReplaceAllString(path, ".{i}.")
+	}
+	return path
+}
+
+// splitUSPPath splits a parameter or command path into its object path and name
+func splitUSPPath(path string) (string, string) {
+	dot := strings.LastIndex(path, ".")
+	return path[:dot+1], path[dot+1:]
+}
+
+// checkUSPPath checks that an object, parameter or command path is part of
+// the data model. Paths that follow references are not checked.
+func checkUSPPath(path string) error {
+	if strings.Contains(path, "#") {
+		return nil
+	}
+	if strings.HasSuffix(path, ".") {
+		// Tables such as Device.IP.Interface. are addressed without {i}
+		template := uspTemplatePath(path)
+		if _, ok := LookupSupportedObject(template); ok {
+			return nil
+		}
+		if _, ok := LookupSupportedObject(template + "{i}."); ok {
+			return nil
+		}
+		return fmt.Errorf("%s: object is not part of the data model", path)
+	}
+
+	objPath, name := splitUSPPath(uspTemplatePath(path))
+	if obj, ok := LookupSupportedObject(objPath); ok {
+		for _, param := range obj.Params {
+			if param.Name == name {
+				return nil
+			}
+		}
+		for _, cmd := range obj.Commands {
+			if cmd.Name == name {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("%s: parameter is not part of the data model", path)
+}
+
+// NewGetMsg builds a Get request for the given paths. A maxDepth of 0
+// returns the full sub-trees of object paths.
+func NewGetMsg(msgID string, maxDepth uint32, paths ...string) (*USPMsg, error) {
+	for _, path := range paths {
+		if err := checkUSPPath(path); err != nil {
+			return nil, err
+		}
+	}
+	get := &USPGet{ParamPaths: paths, MaxDepth: maxDepth}
+	return &USPMsg{MsgID: msgID, MsgType: USPMsgGet, Request: &USPRequest{Get: get}}, nil
+}
+
+// NewSetMsg builds a Set request from values keyed by parameter path. Each
+// value is encoded using the type the data model declares for its path, and
+// only writable parameters may be set.
+func NewSetMsg(msgID string, allowPartial bool, values map[string]any) (*USPMsg, error) {
+	paths := make([]string, 0, len(values))
+	for path := range values {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	set := &USPSet{AllowPartial: allowPartial}
+	objIndex := make(map[string]int)
+	for _, path := range paths {
+		objPath, name := splitUSPPath(path)
+		value, err := encodeUSPValue(path, values[path], true)
+		if err != nil {
+			return nil, err
+		}
+		i, ok := objIndex[objPath]
+		if !ok {
+			i = len(set.UpdateObjs)
+			objIndex[objPath] = i
+			set.UpdateObjs = append(set.UpdateObjs, USPObjectParams{ObjPath: objPath})
+		}
+		set.UpdateObjs[i].ParamSettings = append(set.UpdateObjs[i].ParamSettings, USPParamSetting{Param: name, Value: value})
+	}
+	return &USPMsg{MsgID: msgID, MsgType: USPMsgSet, Request: &USPRequest{Set: set}}, nil
+}
+
+// NewAddMsg builds an Add request creating an entry of the table at objPath,
+// such as Device.IP.Interface., with parameter values keyed by name
+func NewAddMsg(msgID string, allowPartial bool, objPath string, values map[string]any) (*USPMsg, error) {
+	obj, ok := LookupSupportedObject(uspTemplatePath(objPath) + "{i}.")
+	if !ok || (obj.Access != ObjAddDelete && obj.Access != ObjAddOnly) {
+		return nil, fmt.Errorf("%s: object does not support adding entries", objPath)
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	create := USPObjectParams{ObjPath: objPath}
+	for _, name := range names {
+		value, err := encodeUSPValue(obj.Path+name, values[name], false)
+		if err != nil {
+			return nil, err
+		}
+		create.ParamSettings = append(create.ParamSettings, USPParamSetting{Param: name, Value: value})
+	}
+
+	add := &USPAdd{AllowPartial: allowPartial, CreateObjs: []USPObjectParams{create}}
+	return &USPMsg{MsgID: msgID, MsgType: USPMsgAdd, Request: &USPRequest{Add: add}}, nil
+}
+
+// NewDeleteMsg builds a Delete request for table entries such as
+// Device.IP.Interface.2.
+func NewDeleteMsg(msgID string, allowPartial bool, objPaths ...string) (*USPMsg, error) {
+	for _, objPath := range objPaths {
+		obj, ok := LookupSupportedObject(uspTemplatePath(objPath))
+		if !ok || !obj.IsMultiInstance || (obj.Access != ObjAddDelete && obj.Access != ObjDeleteOnly) {
+			return nil, fmt.Errorf("%s: object does not support deleting entries", objPath)
+		}
+	}
+	del := &USPDelete{AllowPartial: allowPartial, ObjPaths: objPaths}
+	return &USPMsg{MsgID: msgID, MsgType: USPMsgDelete, Request: &USPRequest{Delete: del}}, nil
+}
+
+// NewOperateMsg builds an Operate request for a command such as
+// Device.IP.Diagnostics.TraceRoute(). The input arguments may be nil.
+func NewOperateMsg(msgID, command, commandKey string, sendResp bool, input USPArguments) (*USPMsg, error) {
+	if !strings.HasSuffix(command, "()") {
+		return nil, fmt.Errorf("%s: not a command path", command)
+	}
+	if err := checkUSPPath(command); err != nil {
+		return nil, err
+	}
+
+	operate := &USPOperate{Command: command, CommandKey: commandKey, SendResp: sendResp}
+	if input != nil {
+		operate.InputArgs = input.Args()
+	}
+	return &USPMsg{MsgID: msgID, MsgType: USPMsgOperate, Request: &USPRequest{Operate: operate}}, nil
+}
+
+// encodeUSPValue encodes the value of a parameter using the type the data
+// model declares for its path
+func encodeUSPValue(path string, v any, writable bool) (string, error) {
+	objPath, name := splitUSPPath(uspTemplatePath(path))
+	obj, ok := LookupSupportedObject(objPath)
+	if ok {
+		for _, param := range obj.Params {
+			if param.Name != name {
+				continue
+			}
+			if writable && param.Access == ParamReadOnly {
+				return "", fmt.Errorf("%s: parameter is not writable", path)
+			}
+			xsdType, _ := XsdTypeOf(objPath + name)
+			value, err := EncodeValue(xsdType, v)
+			if err != nil {
+				return "", fmt.Errorf("%s: %w", path, err)
+			}
+			return value, nil
+		}
+	}
+	return "", fmt.Errorf("%s: parameter is not part of the data model", path)
+}
+
+// Err returns the Error body of the message, or nil
+func (m *USPMsg) Err() error {
+	if m.Error != nil {
+		return m.Error
+	}
+	return nil
+}
+
+// Values returns the resolved parameters of a GetResp keyed by full path.
+// Failed requested paths are returned as a joined error.
+func (r *USPGetResp) Values() (map[string]string, error) {
+	values := make(map[string]string)
+	var errs []error
+	for _, result := range r.ReqPathResults {
+		if result.ErrCode != 0 {
+			errs = append(errs, fmt.Errorf("%s: USP error %d: %s", result.RequestedPath, result.ErrCode, result.ErrMsg))
+		}
+		for _, resolved := range result.ResolvedPathResults {
+			for name, value := range resolved.ResultParams {
+				values[resolved.ResolvedPath+name] = value
+			}
+		}
+	}
+	return values, errors.Join(errs...)
+}
+
+// BindGetResp binds the resolved parameters of a GetResp to the object tree
+// of root, such as a *Device
+func BindGetResp(resp *USPGetResp, root ParameterBinder) error {
+	values, err := resp.Values()
+	return errors.Join(err, root.Bind(values))
+}
+
+// BindSetResp binds the updated parameters of a SetResp to the object tree
+// of root. Failed updates are returned as a joined error.
+func BindSetResp(resp *USPSetResp, root ParameterBinder) error {
+	values := make(map[string]string)
+	var errs []error
+	for _, result := range resp.UpdatedObjResults {
+		if f := result.Failure; f != nil {
+			errs = append(errs, fmt.Errorf("%s: USP error %d: %s", result.RequestedPath, f.ErrCode, f.ErrMsg))
+			continue
+		}
+		if result.Success == nil {
+			continue
+		}
+		for _, inst := range result.Success.UpdatedInstResults {
+			for name, value := range inst.UpdatedParams {
+				values[inst.AffectedPath+name] = value
+			}
+			for _, paramErr := range inst.ParamErrs {
+				errs = append(errs, fmt.Errorf("%s%s: USP error %d: %s", inst.AffectedPath, paramErr.Param, paramErr.ErrCode, paramErr.ErrMsg))
+			}
+		}
+	}
+	errs = append(errs, root.Bind(values))
+	return errors.Join(errs...)
+}
+
+// BindOutputArgs binds the output arguments of a synchronous command to
+// output, such as a *Device_IP_Diagnostics_TraceRouteOutput
+func (r *USPOperationResult) BindOutputArgs(output USPArguments) error {
+	if r.Failure != nil {
+		return fmt.Errorf("%s: %w", r.ExecutedCommand, r.Failure)
+	}
+	if r.OutputArgs == nil {
+		return fmt.Errorf("%s: no output arguments", r.ExecutedCommand)
+	}
+	return output.SetArgs(r.OutputArgs)
+}
+
+// Marshal encodes the message in the protobuf wire format. Response bodies
+// are not encoded.
+func (m *USPMsg) Marshal() []byte {
+	var e protoEncoder
+	e.message(1, func(e *protoEncoder) {
+		e.string(1, m.MsgID)
+		e.varint(2, uint64(m.MsgType))
+	})
+	e.message(2, func(e *protoEncoder) {
+		switch {
+		case m.Request != nil:
+			e.message(1, m.Request.marshal)
+		case m.Error != nil:
+			e.message(3, m.Error.marshal)
+		}
+	})
+	return e.buf
+}
+
+func (r *USPRequest) marshal(e *protoEncoder) {
+	switch {
+	case r.Get != nil:
+		e.message(1, func(e *protoEncoder) {
+			for _, path := range r.Get.ParamPaths {
+				e.repeatedString(1, path)
+			}
+			e.fixed32(2, r.Get.MaxDepth)
+		})
+	case r.Set != nil:
+		e.message(4, func(e *protoEncoder) {
+			e.bool(1, r.Set.AllowPartial)
+			for i := range r.Set.UpdateObjs {
+				e.message(2, r.Set.UpdateObjs[i].marshal)
+			}
+		})
+	case r.Add != nil:
+		e.message(5, func(e *protoEncoder) {
+			e.bool(1, r.Add.AllowPartial)
+			for i := range r.Add.CreateObjs {
+				e.message(2, r.Add.CreateObjs[i].marshal)
+			}
+		})
+	case r.Delete != nil:
+		e.message(6, func(e *protoEncoder) {
+			e.bool(1, r.Delete.AllowPartial)
+			for _, path := range r.Delete.ObjPaths {
+				e.repeatedString(2, path)
+			}
+		})
+	case r.Operate != nil:
+		e.message(7, func(e *protoEncoder) {
+			e.string(1, r.Operate.Command)
+			e.string(2, r.Operate.CommandKey)
+			e.bool(3, r.Operate.SendResp)
+			e.stringMap(4, r.Operate.InputArgs)
+		})
+	}
+}
+
+func (o *USPObjectParams) marshal(e *protoEncoder) {
+	e.string(1, o.ObjPath)
+	for _, setting := range o.ParamSettings {
+		e.message(2, func(e *protoEncoder) {
+			e.string(1, setting.Param)
+			e.string(2, setting.Value)
+			e.bool(3, setting.Required)
+		})
+	}
+}
+
+func (r *USPError) marshal(e *protoEncoder) {
+	e.fixed32(1, r.ErrCode)
+	e.string(2, r.ErrMsg)
+	for _, paramErr := range r.ParamErrs {
+		e.message(3, paramErr.marshal)
+	}
+}
+
+func (p USPParamError) marshal(e *protoEncoder) {
+	e.string(1, p.Param)
+	e.fixed32(2, p.ErrCode)
+	e.string(3, p.ErrMsg)
+}
+
+// UnmarshalUSPMsg decodes a message in the protobuf wire format. Request
+// bodies are not decoded.
+func UnmarshalUSPMsg(data []byte) (*USPMsg, error) {
+	m := &USPMsg{}
+	err := decodeProto(data, func(f protoField) error {
+		switch f.num {
+		case 1:
+			return decodeProto(f.bytes, func(f protoField) error {
+				switch f.num {
+				case 1:
+					m.MsgID = string(f.bytes)
+				case 2:
+					m.MsgType = USPMsgType(f.value)
+				}
+				return nil
+			})
+		case 2:
+			return decodeProto(f.bytes, func(f protoField) error {
+				switch f.num {
+				case 2:
+					m.Response = &USPResponse{}
+					return m.Response.unmarshal(f.bytes)
+				case 3:
+					m.Error = &USPError{}
+					return m.Error.unmarshal(f.bytes)
+				}
+				return nil
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (r *USPResponse) unmarshal(data []byte) error {
+	return decodeProto(data, func(f protoField) error {
+		switch f.num {
+		case 1:
+			r.GetResp = &USPGetResp{}
+			return r.GetResp.unmarshal(f.bytes)
+		case 4:
+			r.SetResp = &USPSetResp{}
+			return r.SetResp.unmarshal(f.bytes)
+		case 7:
+			r.OperateResp = &USPOperateResp{}
+			return r.OperateResp.unmarshal(f.bytes)
+		}
+		return nil
+	})
+}
+
+func (r *USPError) unmarshal(data []byte) error {
+	return decodeProto(data, func(f protoField) error {
+		switch f.num {
+		case 1:
+			r.ErrCode = uint32(f.value)
+		case 2:
+			r.ErrMsg = string(f.bytes)
+		case 3:
+			var paramErr USPParamError
+			if err := paramErr.unmarshal(f.bytes); err != nil {
+				return err
+			}
+			r.ParamErrs = append(r.ParamErrs, paramErr)
+		}
+		return nil
+	})
+}
+
+func (p *USPParamError) unmarshal(data []byte) error {
+	return decodeProto(data, func(f protoField) error {
+		switch f.num {
+		case 1:
+			p.Param = string(f.bytes)
+		case 2:
+			p.ErrCode = uint32(f.value)
+		case 3:
+			p.ErrMsg = string(f.bytes)
+		}
+		return nil
+	})
+}
+
+func (r *USPGetResp) unmarshal(data []byte) error {
+	return decodeProto(data, func(f protoField) error {
+		if f.num != 1 {
+			return nil
+		}
+		var result USPRequestedPathResult
+		err := decodeProto(f.bytes, func(f protoField) error {
+			switch f.num {
+			case 1:
+				result.RequestedPath = string(f.bytes)
+			case 2:
+				result.ErrCode = uint32(f.value)
+			case 3:
+				result.ErrMsg = string(f.bytes)
+			case 4:
+				resolved := USPResolvedPathResult{ResultParams: make(map[string]string)}
+				err := decodeProto(f.bytes, func(f protoField) error {
+					switch f.num {
+					case 1:
+						resolved.ResolvedPath = string(f.bytes)
+					case 2:
+						return decodeStringMapEntry(f.bytes, resolved.ResultParams)
+					}
+					return nil
+				})
+				if err != nil {
+					return err
+				}
+				result.ResolvedPathResults = append(result.ResolvedPathResults, resolved)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		r.ReqPathResults = append(r.ReqPathResults, result)
+		return nil
+	})
+}
+
+func (r *USPSetResp) unmarshal(data []byte) error {
+	return decodeProto(data, func(f protoField) error {
+		if f.num != 1 {
+			return nil
+		}
+		var result USPUpdatedObjectResult
+		err := decodeProto(f.bytes, func(f protoField) error {
+			switch f.num {
+			case 1:
+				result.RequestedPath = string(f.bytes)
+			case 2:
+				return decodeProto(f.bytes, func(f protoField) error {
+					switch f.num {
+					case 1:
+						result.Failure = &USPSetFailure{}
+						return result.Failure.unmarshal(f.bytes)
+					case 2:
+						result.Success = &USPSetSuccess{}
+						return decodeProto(f.bytes, func(f protoField) error {
+							if f.num != 1 {
+								return nil
+							}
+							var inst USPUpdatedInstance
+							if err := inst.unmarshal(f.bytes); err != nil {
+								return err
+							}
+							result.Success.UpdatedInstResults = append(result.Success.UpdatedInstResults, inst)
+							return nil
+						})
+					}
+					return nil
+				})
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		r.UpdatedObjResults = append(r.UpdatedObjResults, result)
+		return nil
+	})
+}
+
+func (r *USPSetFailure) unmarshal(data []byte) error {
+	return decodeProto(data, func(f protoField) error {
+		switch f.num {
+		case 1:
+			r.ErrCode = uint32(f.value)
+		case 2:
+			r.ErrMsg = string(f.bytes)
+		case 3:
+			var inst USPUpdatedInstance
+			if err := inst.unmarshal(f.bytes); err != nil {
+				return err
+			}
+			r.UpdatedInstFailures = append(r.UpdatedInstFailures, inst)
+		}
+		return nil
+	})
+}
+
+func (r *USPUpdatedInstance) unmarshal(data []byte) error {
+	return decodeProto(data, func(f protoField) error {
+		switch f.num {
+		case 1:
+			r.AffectedPath = string(f.bytes)
+		case 2:
+			var paramErr USPParamError
+			if err := paramErr.unmarshal(f.bytes); err != nil {
+				return err
+			}
+			r.ParamErrs = append(r.ParamErrs, paramErr)
+		case 3:
+			if r.UpdatedParams == nil {
+				r.UpdatedParams = make(map[string]string)
+			}
+			return decodeStringMapEntry(f.bytes, r.UpdatedParams)
+		}
+		return nil
+	})
+}
+
+func (r *USPOperateResp) unmarshal(data []byte) error {
+	return decodeProto(data, func(f protoField) error {
+		if f.num != 1 {
+			return nil
+		}
+		var result USPOperationResult
+		err := decodeProto(f.bytes, func(f protoField) error {
+			switch f.num {
+			case 1:
+				result.ExecutedCommand = string(f.bytes)
+			case 2:
+				result.ReqObjPath = string(f.bytes)
+			case 3:
+				result.OutputArgs = make(map[string]string)
+				return decodeProto(f.bytes, func(f protoField) error {
+					if f.num != 1 {
+						return nil
+					}
+					return decodeStringMapEntry(f.bytes, result.OutputArgs)
+				})
+			case 4:
+				result.Failure = &USPError{}
+				return result.Failure.unmarshal(f.bytes)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		r.OperationResults = append(r.OperationResults, result)
+		return nil
+	})
+}
+
+// protoEncoder appends protobuf wire format fields to buf, omitting proto3
+// default values
+type protoEncoder struct {
+	buf []byte
+}
+
+func (e *protoEncoder) tag(num, wireType int) {
+	e.buf = binary.AppendUvarint(e.buf, uint64(num<<3|wireType))
+}
+
+func (e *protoEncoder) varint(num int, v uint64) {
+	if v != 0 {
+		e.tag(num, 0)
+		e.buf = binary.AppendUvarint(e.buf, v)
+	}
+}
+
+func (e *protoEncoder) bool(num int, v bool) {
+	if v {
+		e.varint(num, 1)
+	}
+}
+
+func (e *protoEncoder) fixed32(num int, v uint32) {
+	if v != 0 {
+		e.tag(num, 5)
+		e.buf = binary.LittleEndian.AppendUint32(e.buf, v)
+	}
+}
+
+func (e *protoEncoder) string(num int, v string) {
+	if v != "" {
+		e.repeatedString(num, v)
+	}
+}
+
+// repeatedString appends a string even when it is empty
+func (e *protoEncoder) repeatedString(num int, v string) {
+	e.tag(num, 2)
+	e.buf = binary.AppendUvarint(e.buf, uint64(len(v)))
+	e.buf = append(e.buf, v...)
+}
+
+func (e *protoEncoder) message(num int, marshal func(*protoEncoder)) {
+	var inner protoEncoder
+	marshal(&inner)
+	e.tag(num, 2)
+	e.buf = binary.AppendUvarint(e.buf, uint64(len(inner.buf)))
+	e.buf = append(e.buf, inner.buf...)
+}
+
+// stringMap appends a map<string, string> field with its keys in order
+func (e *protoEncoder) stringMap(num int, m map[string]string) {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		e.message(num, func(e *protoEncoder) {
+			e.string(1, key)
+			e.string(2, m[key])
+		})
+	}
+}
+
+// protoField is a decoded protobuf field. Varint and fixed values are held
+// in value, length-delimited ones in bytes.
+type protoField struct {
+	num   int
+	value uint64
+	bytes []byte
+}
+
+// decodeProto calls fn for each field of a message in the protobuf wire format
+func decodeProto(data []byte, fn func(protoField) error) error {
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return errors.New("invalid protobuf field key")
+		}
+		data = data[n:]
+
+		f := protoField{num: int(key >> 3)}
+		switch key & 7 {
+		case 0:
+			f.value, n = binary.Uvarint(data)
+			if n <= 0 {
+				return errors.New("invalid protobuf varint")
+			}
+			data = data[n:]
+		case 1:
+			if len(data) < 8 {
+				return errors.New("truncated protobuf fixed64")
+			}
+			f.value, data = binary.LittleEndian.Uint64(data), data[8:]
+		case 2:
+			length, n := binary.Uvarint(data)
+			if n <= 0 || uint64(len(data)-n) < length {
+				return errors.New("truncated protobuf field")
+			}
+			f.bytes, data = data[n:n+int(length)], data[n+int(length):]
+		case 5:
+			if len(data) < 4 {
+				return errors.New("truncated protobuf fixed32")
+			}
+			f.value, data = uint64(binary.LittleEndian.Uint32(data)), data[4:]
+		default:
+			return fmt.Errorf("unsupported protobuf wire type %d", key&7)
+		}
+
+		if err := fn(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeStringMapEntry decodes a map<string, string> entry into m
+func decodeStringMapEntry(data []byte, m map[string]string) error {
+	var key, value string
+	err := decodeProto(data, func(f protoField) error {
+		switch f.num {
+		case 1:
+			key = string(f.bytes)
+		case 2:
+			value = string(f.bytes)
+		}
+		return nil
+	})
+	m[key] = value
+	return err
+}
+`
@@ -0,0 +1,490 @@
+package generator
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/Niceblueman/cwmp-codegen/internal/models"
+)
+
+// Value codec template for encoding and decoding TR-069 wire values
+const valueCodecTemplate = `// Code generated by cwmp-codegen. DO NOT EDIT.
+package {{.PackageName}}
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// UnknownDateTime is the dateTime value TR-069 uses for an unknown time
+const UnknownDateTime = "0001-01-01T00:00:00Z"
+
+// parameterXsdTypes maps each modelled parameter path to its xsi:type
+var parameterXsdTypes = map[string]string{
+{{range .Parameters}}	"{{.Path}}": {{.XsdType}},
+{{end}}}
+
+// instanceNumberPattern matches instance numbers in a concrete parameter path
+var instanceNumberPattern = regexp.MustCompile(` + "`\\.\\d+\\.`" + `)
+
+// XsdTypeOf returns the xsi:type declared by the data model for a parameter.
+// Concrete instance numbers are matched against {i} placeholders.
+func XsdTypeOf(path string) (string, bool) {
+	if xsdType, ok := parameterXsdTypes[path]; ok {
+		return xsdType, true
+	}
+	pattern := path
+	for instanceNumberPattern.MatchString(pattern) {
+		pattern = instanceNumberPattern.ReplaceAllString(pattern, ".{i}.")
+	}
+	xsdType, ok := parameterXsdTypes[pattern]
+	return xsdType, ok
+}
+
+// ParameterValues holds encoded values keyed by parameter path, ready to be
+// sent in a SetParameterValues request
+type ParameterValues map[string]ValueStruct
+
+// SetValue encodes v using the xsi:type the data model declares for path
+func (p ParameterValues) SetValue(path string, v any) error {
+	xsdType, ok := XsdTypeOf(path)
+	if !ok {
+		return fmt.Errorf("%s: parameter is not part of the data model", path)
+	}
+	value, err := EncodeValue(xsdType, v)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	p[path] = ValueStruct{Type: xsdType, Value: value}
+	return nil
+}
+
+// EncodeValue encodes v as a wire value of the given xsi:type. String values
+// are taken as already encoded and are validated against the type.
+func EncodeValue(xsdType string, v any) (string, error) {
+	if s, ok := v.(string); ok {
+		_, err := DecodeValue(xsdType, s)
+		return s, err
+	}
+
+	switch xsdType {
+	case XsdBoolean:
+		if b, ok := v.(bool); ok {
+			return EncodeBoolean(b), nil
+		}
+	case XsdDateTime:
+		if t, ok := v.(time.Time); ok {
+			return EncodeDateTime(t), nil
+		}
+	case XsdUnsignedInt:
+		if n, ok := toInt64(v); ok && n >= 0 && n <= math.MaxUint32 {
+			return strconv.FormatInt(n, 10), nil
+		}
+	case XsdInt:
+		if n, ok := toInt64(v); ok && n >= math.MinInt32 && n <= math.MaxInt32 {
+			return strconv.FormatInt(n, 10), nil
+		}
+	case XsdLong:
+		if n, ok := toInt64(v); ok {
+			return EncodeLong(n), nil
+		}
+	case XsdUnsignedLong:
+		if n, ok := v.(uint64); ok {
+			return EncodeUnsignedLong(n), nil
+		}
+		if n, ok := toInt64(v); ok && n >= 0 {
+			return EncodeUnsignedLong(uint64(n)), nil
+		}
+	case XsdBase64:
+		if b, ok := v.([]byte); ok {
+			return EncodeBase64(b), nil
+		}
+	case XsdHexBinary:
+		if b, ok := v.([]byte); ok {
+			return EncodeHexBinary(b), nil
+		}
+	case XsdString:
+		switch value := v.(type) {
+		case []string:
+			return MarshalList(value, FormatStringListItem), nil
+		case fmt.Stringer:
+			return value.String(), nil
+		}
+	}
+
+	return "", fmt.Errorf("cannot encode %T as %s", v, xsdType)
+}
+
+// DecodeValue decodes a wire value of the given xsi:type into its Go
+// representation, using the type's full value range
+func DecodeValue(xsdType, s string) (any, error) {
+	switch xsdType {
+	case XsdBoolean:
+		return DecodeBoolean(s)
+	case XsdDateTime:
+		return DecodeDateTime(s)
+	case XsdUnsignedInt:
+		return DecodeUnsignedInt(s, 0, math.MaxUint32)
+	case XsdInt:
+		return DecodeInt(s, math.MinInt32, math.MaxInt32)
+	case XsdLong:
+		return DecodeLong(s, math.MinInt64, math.MaxInt64)
+	case XsdUnsignedLong:
+		return DecodeUnsignedLong(s, 0, math.MaxUint64)
+	case XsdBase64:
+		return DecodeBase64(s, 0)
+	case XsdHexBinary:
+		return DecodeHexBinary(s, 0)
+	default:
+		return DecodeString(s, 0)
+	}
+}
+
+// EncodeString encodes a string value
+func EncodeString(v string) string {
+	return v
+}
+
+// DecodeString decodes a string value, enforcing maxLength when non-zero
+func DecodeString(s string, maxLength int) (string, error) {
+	if maxLength > 0 && len(s) > maxLength {
+		return "", fmt.Errorf("string is %d characters long, maximum is %d", len(s), maxLength)
+	}
+	return s, nil
+}
+
+// EncodeBoolean encodes a boolean value
+func EncodeBoolean(v bool) string {
+	if v {
+		return "true"
+	}
+	return "false"
+}
+
+// DecodeBoolean decodes a boolean value, accepting "0", "1", "true" and "false"
+func DecodeBoolean(s string) (bool, error) {
+	switch strings.TrimSpace(s) {
+	case "1", "true":
+		return true, nil
+	case "0", "false":
+		return false, nil
+	}
+	return false, fmt.Errorf("invalid boolean %q", s)
+}
+
+// EncodeDateTime encodes a dateTime value. The zero time is encoded as
+// UnknownDateTime.
+func EncodeDateTime(v time.Time) string {
+	if v.IsZero() {
+		return UnknownDateTime
+	}
+	return v.Format(time.RFC3339)
+}
+
+// DecodeDateTime decodes a dateTime value. UnknownDateTime decodes to the
+// zero time; other times in the year 0001 are relative times (such as time
+// since boot) and keep their value. Values without a time zone are
+// interpreted as UTC.
+func DecodeDateTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		t, err = time.Parse("2006-01-02T15:04:05.999999999", s)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid dateTime %q", s)
+		}
+	}
+	if t.Equal(time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC)) {
+		return time.Time{}, nil
+	}
+	return t, nil
+}
+
+// EncodeUnsignedInt encodes an unsignedInt value
+func EncodeUnsignedInt(v uint32) string {
+	return strconv.FormatUint(uint64(v), 10)
+}
+
+// DecodeUnsignedInt decodes an unsignedInt value within [min, max]
+func DecodeUnsignedInt(s string, min, max uint32) (uint32, error) {
+	n, err := strconv.ParseUint(strings.TrimSpace(s), 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid unsignedInt %q", s)
+	}
+	if uint32(n) < min || uint32(n) > max {
+		return 0, fmt.Errorf("unsignedInt %d out of range [%d, %d]", n, min, max)
+	}
+	return uint32(n), nil
+}
+
+// EncodeInt encodes an int value
+func EncodeInt(v int) string {
+	return strconv.Itoa(v)
+}
+
+// DecodeInt decodes an int value within [min, max]
+func DecodeInt(s string, min, max int64) (int, error) {
+	n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid int %q", s)
+	}
+	if n < min || n > max {
+		return 0, fmt.Errorf("int %d out of range [%d, %d]", n, min, max)
+	}
+	return int(n), nil
+}
+
+// EncodeLong encodes a long value
+func EncodeLong(v int64) string {
+	return strconv.FormatInt(v, 10)
+}
+
+// DecodeLong decodes a long value within [min, max]
+func DecodeLong(s string, min, max int64) (int64, error) {
+	n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid long %q", s)
+	}
+	if n < min || n > max {
+		return 0, fmt.Errorf("long %d out of range [%d, %d]", n, min, max)
+	}
+	return n, nil
+}
+
+// EncodeUnsignedLong encodes an unsignedLong value
+func EncodeUnsignedLong(v uint64) string {
+	return strconv.FormatUint(v, 10)
+}
+
+// DecodeUnsignedLong decodes an unsignedLong value within [min, max]
+func DecodeUnsignedLong(s string, min, max uint64) (uint64, error) {
+	n, err := strconv.ParseUint(strings.TrimSpace(s), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid unsignedLong %q", s)
+	}
+	if n < min || n > max {
+		return 0, fmt.Errorf("unsignedLong %d out of range [%d, %d]", n, min, max)
+	}
+	return n, nil
+}
+
+// EncodeBase64 encodes a base64 value
+func EncodeBase64(v []byte) string {
+	return base64.StdEncoding.EncodeToString(v)
+}
+
+// DecodeBase64 decodes a base64 value, enforcing a maximum decoded length
+// when maxLength is non-zero
+func DecodeBase64(s string, maxLength int) ([]byte, error) {
+	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64: %w", err)
+	}
+	if maxLength > 0 && len(b) > maxLength {
+		return nil, fmt.Errorf("base64 value is %d bytes long, maximum is %d", len(b), maxLength)
+	}
+	return b, nil
+}
+
+// EncodeHexBinary encodes a hexBinary value
+func EncodeHexBinary(v []byte) string {
+	return hex.EncodeToString(v)
+}
+
+// DecodeHexBinary decodes a hexBinary value, enforcing a maximum decoded
+// length when maxLength is non-zero
+func DecodeHexBinary(s string, maxLength int) ([]byte, error) {
+	b, err := hex.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("invalid hexBinary: %w", err)
+	}
+	if maxLength > 0 && len(b) > maxLength {
+		return nil, fmt.Errorf("hexBinary value is %d bytes long, maximum is %d", len(b), maxLength)
+	}
+	return b, nil
+}
+
+// toInt64 converts any Go integer type to int64
+func toInt64(v any) (int64, bool) {
+	switch n := v.(type) {
+	case int:
+		return int64(n), true
+	case int8:
+		return int64(n), true
+	case int16:
+		return int64(n), true
+	case int32:
+		return int64(n), true
+	case int64:
+		return n, true
+	case uint:
+		return int64(n), n <= math.MaxInt64
+	case uint8:
+		return int64(n), true
+	case uint16:
+		return int64(n), true
+	case uint32:
+		return int64(n), true
+	case uint64:
+		return int64(n), n <= math.MaxInt64
+	}
+	return 0, false
+}
+`
+
+// GoParameterType describes the wire type of a parameter path
+type GoParameterType struct {
+	Path    string
+	XsdType string
+}
+
+// collectGoParameterTypes lists the xsi:type of every parameter in the model
+func collectGoParameterTypes(model *models.DataModel) []GoParameterType {
+	dataTypes := modelDataTypes(model)
+	types := []GoParameterType{}
+	for _, param := range model.Parameters {
+		types = append(types, GoParameterType{Path: param.GetFullPath(), XsdType: goXsdType(param, dataTypes)})
+	}
+
+	var walk func(objects []models.Object)
+	walk = func(objects []models.Object) {
+		for _, obj := range objects {
+			for _, param := range obj.Parameters {
+				path := param.FullPath
+				if path == "" {
+					path = obj.GetPath() + param.Name
+				}
+				types = append(types, GoParameterType{Path: path, XsdType: goXsdType(param, dataTypes)})
+			}
+			walk(obj.Objects)
+		}
+	}
+	walk(model.Objects)
+
+	return types
+}
+
+// goXsdType returns the xsi:type constant of a parameter. Lists are strings.
+func goXsdType(param models.Parameter, dataTypes map[string]models.DataType) string {
+	if param.IsList {
+		return "XsdString"
+	}
+	return mapCWMPTypeToXsdConst(models.ResolveSyntax(param, dataTypes).Type)
+}
+
+// mapCWMPTypeToXsdConst maps CWMP types to the generated xsi:type constants
+func mapCWMPTypeToXsdConst(cwmpType string) string {
+	switch strings.ToLower(cwmpType) {
+	case "boolean", "bool":
+		return "XsdBoolean"
+	case "datetime":
+		return "XsdDateTime"
+	case "unsignedint", "unsignedinteger":
+		return "XsdUnsignedInt"
+	case "int", "integer":
+		return "XsdInt"
+	case "long":
+		return "XsdLong"
+	case "unsignedlong":
+		return "XsdUnsignedLong"
+	case "base64":
+		return "XsdBase64"
+	case "hexbinary":
+		return "XsdHexBinary"
+	default:
+		return "XsdString"
+	}
+}
+
+// applyGoValueCodec types a scalar parameter by its built-in type, selects
+// the Encode/Decode helpers and renders its facets as the trailing Decode
+// arguments
+func applyGoValueCodec(goParam *GoParameter, param models.Parameter, dataTypes map[string]models.DataType) {
+	value := models.ResolveSyntax(param, dataTypes)
+	goParam.GoType = mapCWMPTypeToGoType(value.Type)
+	goParam.XsdType = mapCWMPTypeToXsdConst(value.Type)
+
+	maxLength := 0
+	if value.Size != nil {
+		maxLength = value.Size.Max
+	}
+
+	switch value.Type {
+	case "string":
+		goParam.Codec = "String"
+		goParam.DecodeArgs = fmt.Sprintf(", %d", maxLength)
+	case "boolean":
+		goParam.Codec = "Boolean"
+	case "dateTime":
+		goParam.Codec = "DateTime"
+	case "unsignedInt":
+		goParam.Codec = "UnsignedInt"
+		min, max := rangeBounds(value.Range, 0, math.MaxUint32)
+		goParam.DecodeArgs = fmt.Sprintf(", %d, %d", min, max)
+	case "int":
+		goParam.Codec = "Int"
+		min, max := rangeBounds(value.Range, math.MinInt32, math.MaxInt32)
+		goParam.DecodeArgs = fmt.Sprintf(", %d, %d", min, max)
+	case "long":
+		goParam.Codec = "Long"
+		min, max := rangeBounds(value.Range, math.MinInt64, math.MaxInt64)
+		goParam.DecodeArgs = fmt.Sprintf(", %d, %d", min, max)
+	case "unsignedLong":
+		goParam.Codec = "UnsignedLong"
+		min, max := uint64(0), uint64(math.MaxUint64)
+		if value.Range != nil {
+			if n, err := strconv.ParseUint(rangeMin(value.Range), 10, 64); err == nil {
+				min = n
+			}
+			if n, err := strconv.ParseUint(rangeMax(value.Range), 10, 64); err == nil {
+				max = n
+			}
+		}
+		goParam.DecodeArgs = fmt.Sprintf(", %d, %d", min, max)
+	case "base64":
+		goParam.Codec = "Base64"
+		goParam.DecodeArgs = fmt.Sprintf(", %d", maxLength)
+	case "hexBinary":
+		goParam.Codec = "HexBinary"
+		goParam.DecodeArgs = fmt.Sprintf(", %d", maxLength)
+	}
+}
+
+// rangeBounds returns the inclusive bounds of a range facet, falling back to
+// the bounds of the underlying type
+func rangeBounds(r *models.Range, min, max int64) (int64, int64) {
+	if r == nil {
+		return min, max
+	}
+	if n, err := strconv.ParseInt(rangeMin(r), 10, 64); err == nil && n > min {
+		min = n
+	}
+	if n, err := strconv.ParseInt(rangeMax(r), 10, 64); err == nil && n < max {
+		max = n
+	}
+	return min, max
+}
+
+// rangeMin returns the lower bound of a range, whichever attribute spells it
+func rangeMin(r *models.Range) string {
+	if r.MinInclusive != "" {
+		return r.MinInclusive
+	}
+	return r.Min
+}
+
+// rangeMax returns the upper bound of a range, whichever attribute spells it
+func rangeMax(r *models.Range) string {
+	if r.MaxInclusive != "" {
+		return r.MaxInclusive
+	}
+	return r.Max
+}
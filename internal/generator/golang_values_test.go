@@ -0,0 +1,188 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Niceblueman/cwmp-codegen/internal/models"
+)
+
+func TestApplyGoValueCodec(t *testing.T) {
+	dataTypes := map[string]models.DataType{
+		"IPAddress":      {Name: "IPAddress", String: &models.StringType{Size: &models.Size{Max: 45}}},
+		"StatsCounter32": {Name: "StatsCounter32", UnsignedInt: &models.UnsignedInt{}},
+		"PacketCounter":  {Name: "PacketCounter", Base: "StatsCounter32"},
+	}
+	tests := []struct {
+		param      models.Parameter
+		codec      string
+		decodeArgs string
+	}{
+		{
+			param: models.Parameter{Name: "Enable", Type: "boolean"},
+			codec: "Boolean",
+		},
+		{
+			param: models.Parameter{
+				Name:   "PeriodicInformInterval",
+				Type:   "unsignedInt",
+				Syntax: models.Syntax{UnsignedInt: &models.UnsignedInt{Range: &models.Range{MinInclusive: "1"}}},
+			},
+			codec:      "UnsignedInt",
+			decodeArgs: ", 1, 4294967295",
+		},
+		{
+			param: models.Parameter{
+				Name:   "URL",
+				Type:   "string",
+				Syntax: models.Syntax{String: &models.StringCons{Size: &models.Size{Max: 256}}},
+			},
+			codec:      "String",
+			decodeArgs: ", 256",
+		},
+		{
+			param: models.Parameter{Name: "Certificate", Type: "hexBinary"},
+			codec: "HexBinary",
+			// No size facet means unbounded
+			decodeArgs: ", 0",
+		},
+		{
+			param:      models.Parameter{Name: "Address", Type: "IPAddress"},
+			codec:      "String",
+			decodeArgs: ", 45",
+		},
+		{
+			param:      models.Parameter{Name: "BytesSent", Type: "StatsCounter32"},
+			codec:      "UnsignedInt",
+			decodeArgs: ", 0, 4294967295",
+		},
+		{
+			param:      models.Parameter{Name: "PacketsSent", Type: "PacketCounter"},
+			codec:      "UnsignedInt",
+			decodeArgs: ", 0, 4294967295",
+		},
+	}
+
+	for _, tt := range tests {
+		goParam := GoParameter{}
+		applyGoValueCodec(&goParam, tt.param, dataTypes)
+		if goParam.Codec != tt.codec || goParam.DecodeArgs != tt.decodeArgs {
+			t.Errorf("%s: expected codec %q args %q, got %q args %q",
+				tt.param.Name, tt.codec, tt.decodeArgs, goParam.Codec, goParam.DecodeArgs)
+		}
+	}
+}
+
+func TestGenerateGolangValueCodec(t *testing.T) {
+	model := &models.DataModel{
+		Name: "CodecModel",
+		Objects: []models.Object{
+			{
+				Name: "Device.ManagementServer.",
+				Path: "Device.ManagementServer.",
+				Parameters: []models.Parameter{
+					{Name: "PeriodicInformEnable", Type: "boolean", FullPath: "Device.ManagementServer.PeriodicInformEnable"},
+					{Name: "PeriodicInformTime", Type: "datetime", FullPath: "Device.ManagementServer.PeriodicInformTime"},
+					{Name: "InformCount", Type: "StatsCounter32", FullPath: "Device.ManagementServer.InformCount"},
+				},
+			},
+		},
+		DataTypes: []models.DataType{{Name: "StatsCounter32", UnsignedInt: &models.UnsignedInt{}}},
+	}
+
+	tmpDir := t.TempDir()
+	if _, err := GenerateGolang(model, tmpDir); err != nil {
+		t.Fatalf("GenerateGolang returned error: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, "value_codec.go"))
+	if err != nil {
+		t.Fatalf("Failed to read value_codec.go: %v", err)
+	}
+
+	contentStr := string(content)
+	if !strings.Contains(contentStr, `"Device.ManagementServer.PeriodicInformEnable": XsdBoolean,`) {
+		t.Error("value_codec.go doesn't map the boolean parameter to xsd:boolean")
+	}
+
+	if !strings.Contains(contentStr, `"Device.ManagementServer.InformCount": XsdUnsignedInt,`) {
+		t.Error("value_codec.go doesn't map the StatsCounter32 parameter to xsd:unsignedInt")
+	}
+
+	if !strings.Contains(contentStr, "func (p ParameterValues) SetValue(path string, v any) error") {
+		t.Error("value_codec.go doesn't contain SetValue")
+	}
+
+	objContent, err := os.ReadFile(filepath.Join(tmpDir, "Device_ManagementServer.go"))
+	if err != nil {
+		t.Fatalf("Failed to read generated object file: %v", err)
+	}
+
+	if !strings.Contains(string(objContent), "v, err := DecodeDateTime(value)") {
+		t.Error("Generated code doesn't decode the dateTime parameter")
+	}
+	if !strings.Contains(string(objContent), "v, err := DecodeUnsignedInt(value, 0, 4294967295)") {
+		t.Error("Generated code doesn't decode the StatsCounter32 parameter as an unsignedInt")
+	}
+}
+
+func TestGolangValueCodecRuns(t *testing.T) {
+	model := &models.DataModel{
+		Name: "CodecModel",
+		Objects: []models.Object{
+			{
+				Name: "Device.DeviceInfo.",
+				Path: "Device.DeviceInfo.",
+				Parameters: []models.Parameter{
+					{Name: "UpTime", Type: "unsignedInt", FullPath: "Device.DeviceInfo.UpTime"},
+					{Name: "FirstUseDate", Type: "datetime", FullPath: "Device.DeviceInfo.FirstUseDate"},
+				},
+			},
+		},
+	}
+
+	runGeneratedGoTests(t, GenerateGolang, model, map[string]string{"codec_test.go": `package messages
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDateTime(t *testing.T) {
+	unknown, err := DecodeDateTime(UnknownDateTime)
+	if err != nil || !unknown.IsZero() {
+		t.Errorf("Expected the unknown time to decode to the zero time, got %v %v", unknown, err)
+	}
+	relative, err := DecodeDateTime("0001-01-01T00:05:00Z")
+	if err != nil || relative.IsZero() || relative.Sub(time.Time{}) != 5*time.Minute {
+		t.Errorf("Expected a relative time to keep its value, got %v %v", relative, err)
+	}
+	if EncodeDateTime(relative) != "0001-01-01T00:05:00Z" || EncodeDateTime(time.Time{}) != UnknownDateTime {
+		t.Errorf("Unexpected encodings %s and %s", EncodeDateTime(relative), EncodeDateTime(time.Time{}))
+	}
+}
+
+func TestUnsignedInt(t *testing.T) {
+	n, err := DecodeUnsignedInt("4294967295", 0, 4294967295)
+	if err != nil || n != 4294967295 || EncodeUnsignedInt(n) != "4294967295" {
+		t.Errorf("Expected the full unsignedInt range, got %d %v", n, err)
+	}
+	if _, err := DecodeUnsignedInt("4294967296", 0, 4294967295); err == nil {
+		t.Error("Expected values above 2^32-1 to fail")
+	}
+	if _, err := DecodeUnsignedInt("0", 1, 10); err == nil {
+		t.Error("Expected values below the range to fail")
+	}
+
+	var info Device_DeviceInfo
+	if err := info.DecodeUpTime("3000000000"); err != nil || info.UpTime != 3000000000 || info.EncodeUpTime() != "3000000000" {
+		t.Errorf("Expected UpTime to hold 3000000000, got %d %v", info.UpTime, err)
+	}
+	if v, err := DecodeValue(XsdUnsignedInt, "7"); err != nil || v != uint32(7) {
+		t.Errorf("Expected DecodeValue to return a uint32, got %T %v", v, err)
+	}
+}
+`})
+}
@@ -0,0 +1,338 @@
+package generator
+
+import (
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/Niceblueman/cwmp-codegen/internal/models"
+)
+
+// GraphQL SDL template
+const graphQLTemplate = `# Code generated by cwmp-codegen. DO NOT EDIT.
+# Data model {{.ModelName}}
+{{range .Scalars}}
+{{.Description}}scalar {{.Name}}
+{{end}}{{range .Enums}}
+"""
+Values of {{.Path}}
+"""
+enum {{.Name}} {
+{{- range .Values}}
+{{.Description}}  {{.Name}}
+{{- end}}
+}
+{{end}}{{range .Types}}
+{{.Description}}type {{.Name}} {
+{{- range .Fields}}
+{{.Description}}  {{.Name}}{{.Args}}: {{.Type}}
+{{- end}}
+}
+{{end}}
+type Query {
+{{- range .Queries}}
+{{.Description}}  {{.Name}}{{.Args}}: {{.Type}}
+{{- end}}
+}
+{{- if .Mutations}}
+{{range .Inputs}}
+"""
+Writable parameters of {{.Path}}
+"""
+input {{.Name}} {
+{{- range .Fields}}
+{{.Description}}  {{.Name}}: {{.Type}}
+{{- end}}
+}
+{{end}}
+type Mutation {
+{{- range .Mutations}}
+{{.Description}}  {{.Name}}{{.Args}}: {{.Type}}
+{{- end}}
+}
+{{- end}}
+`
+
+// GraphQLTemplate contains data for the GraphQL template
+type GraphQLTemplate struct {
+	ModelName string
+	Scalars   []GraphQLType
+	Enums     []GraphQLEnum
+	Types     []GraphQLType
+	Inputs    []GraphQLType
+	Queries   []GraphQLField
+	Mutations []GraphQLField
+}
+
+// GraphQLType is an object, input or scalar type
+type GraphQLType struct {
+	Name        string
+	Description string
+	Path        string
+	Fields      []GraphQLField
+}
+
+// GraphQLEnum is the enum of an enumerated parameter
+type GraphQLEnum struct {
+	Name   string
+	Path   string
+	Values []GraphQLField
+}
+
+// GraphQLField is a field, or an enum value when Type is empty
+type GraphQLField struct {
+	Name        string
+	Description string
+	Args        string
+	Type        string
+}
+
+// graphQLScalars describes the custom scalars of the CWMP built-in types
+// that have no GraphQL equivalent
+var graphQLScalars = map[string]string{
+	"DateTime":     "ISO 8601 date and time",
+	"UnsignedInt":  "Unsigned 32-bit integer",
+	"Long":         "Signed 64-bit integer",
+	"UnsignedLong": "Unsigned 64-bit integer",
+	"Base64":       "Base64-encoded binary",
+	"HexBinary":    "Hex-encoded binary",
+}
+
+// GenerateGraphQL generates a GraphQL schema from a data model. Root objects
+// are queried by device ID, and writable parameters are set through one
+// mutation per object.
+func GenerateGraphQL(model *models.DataModel, outputDir string) ([]string, error) {
+	fileName := strings.ToLower(sanitize(model.Name)) + ".graphql"
+
+	tmplData := GraphQLTemplate{
+		ModelName: model.Name,
+		Scalars:   []GraphQLType{},
+		Enums:     []GraphQLEnum{},
+		Types:     []GraphQLType{},
+		Inputs:    []GraphQLType{},
+		Queries:   []GraphQLField{},
+		Mutations: []GraphQLField{},
+	}
+
+	// Named dataTypes are custom scalars, such as IPAddress and MACAddress
+	dataTypes := make(map[string]models.DataType)
+	scalars := make(map[string]string)
+	for _, dataType := range model.DataTypes {
+		dataTypes[dataType.Name] = dataType
+		scalars[upperCamelCase(dataType.Name)] = dataType.Description
+	}
+
+	tree := linkGoObjectTree(model)
+	for _, obj := range model.Objects {
+		gqlType, input, enums := convertObjectToGraphQLType(obj, dataTypes, scalars)
+		addGraphQLChildFields(&gqlType, tree[objectPathPrefix(obj)])
+		tmplData.Types = append(tmplData.Types, gqlType)
+		tmplData.Enums = append(tmplData.Enums, enums...)
+
+		if len(input.Fields) > 0 {
+			tmplData.Inputs = append(tmplData.Inputs, input)
+			tmplData.Mutations = append(tmplData.Mutations, GraphQLField{
+				Name:        "set" + gqlType.Name,
+				Description: graphQLDescription("Sets parameters of "+objectPathPrefix(obj)+" and returns the object", "  "),
+				Args:        graphQLArgs(objectPathPrefix(obj), "input: "+input.Name+"!"),
+				Type:        gqlType.Name,
+			})
+		}
+	}
+
+	for _, root := range collectGoRootObjects(model.Objects, tree) {
+		name := strings.TrimSuffix(root.Path, ".")
+		tmplData.Queries = append(tmplData.Queries, GraphQLField{
+			Name:        lowerCamelCase(name),
+			Description: graphQLDescription("The "+root.Path+" object of a device", "  "),
+			Args:        "(deviceId: ID!)",
+			Type:        upperCamelCase(sanitize(root.Path)),
+		})
+	}
+
+	names := make([]string, 0, len(scalars))
+	for name := range scalars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		tmplData.Scalars = append(tmplData.Scalars, GraphQLType{
+			Name:        name,
+			Description: graphQLDescription(scalars[name], ""),
+		})
+	}
+
+	tmpl, err := template.New("graphql").Parse(graphQLTemplate)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeTemplate(tmpl, filepath.Join(outputDir, fileName), tmplData); err != nil {
+		return nil, err
+	}
+
+	return []string{fileName}, nil
+}
+
+// convertObjectToGraphQLType converts a CWMP object to a type, the input
+// type of its writable parameters and the enums of its enumerated
+// parameters. The custom scalars used are added to scalars.
+func convertObjectToGraphQLType(obj models.Object, dataTypes map[string]models.DataType, scalars map[string]string) (GraphQLType, GraphQLType, []GraphQLEnum) {
+	gqlType := GraphQLType{
+		Name:        upperCamelCase(sanitize(obj.Name)),
+		Description: graphQLDescription(obj.Description, ""),
+		Path:        objectPathPrefix(obj),
+		Fields:      []GraphQLField{},
+	}
+	input := GraphQLType{
+		Name:   gqlType.Name + "Input",
+		Path:   objectPathPrefix(obj),
+		Fields: []GraphQLField{},
+	}
+	enums := []GraphQLEnum{}
+
+	if isTableEntry(obj) {
+		gqlType.Fields = append(gqlType.Fields, GraphQLField{
+			Name:        "instanceNumber",
+			Description: graphQLDescription(instanceNumberDescription, "  "),
+			Type:        "Int!",
+		})
+	}
+
+	for _, param := range obj.Parameters {
+		itemType := param.Type
+		if param.IsList {
+			itemType = param.ItemType
+		}
+		gqlValueType := graphQLValueType(itemType, dataTypes, scalars)
+
+		value := models.ResolveSyntax(param, dataTypes)
+		if !param.IsList && value.Type == "string" && len(value.Enumeration) > 0 {
+			enum := convertEnumerationToGraphQL(gqlType.Name+upperCamelCase(param.Name), objectPathPrefix(obj)+param.Name, value.Enumeration)
+			enums = append(enums, enum)
+			gqlValueType = enum.Name
+		}
+		if param.IsList {
+			gqlValueType = "[" + gqlValueType + "!]"
+		}
+
+		field := GraphQLField{
+			Name:        lowerCamelCase(param.Name),
+			Description: graphQLDescription(param.Description, "  "),
+			Type:        gqlValueType,
+		}
+		gqlType.Fields = append(gqlType.Fields, field)
+		if param.Access == "readWrite" {
+			input.Fields = append(input.Fields, field)
+		}
+	}
+
+	return gqlType, input, enums
+}
+
+// addGraphQLChildFields adds fields for the child objects derived from the
+// object paths. Tables become lists that can be narrowed to one instance.
+func addGraphQLChildFields(gqlType *GraphQLType, children []GoChildObject) {
+	for _, child := range children {
+		field := GraphQLField{
+			Name: lowerCamelCase(child.Name),
+			Type: upperCamelCase(child.StructName),
+		}
+		if child.IsMultiInstance {
+			field.Args = "(instanceNumber: Int)"
+			field.Type = "[" + field.Type + "!]!"
+		}
+		gqlType.Fields = append(gqlType.Fields, field)
+	}
+}
+
+// convertEnumerationToGraphQL converts enumeration values to enum values.
+// Values that are not just upper-cased keep their string value as description.
+func convertEnumerationToGraphQL(name, path string, enumeration []models.Enumeration) GraphQLEnum {
+	enum := GraphQLEnum{Name: name, Path: path}
+	used := make(map[string]bool)
+	for _, value := range enumeration {
+		valueName := enumValueName(value.Value)
+		for n := 2; used[valueName]; n++ {
+			valueName = enumValueName(value.Value) + "_" + strconv.Itoa(n)
+		}
+		used[valueName] = true
+
+		field := GraphQLField{Name: valueName}
+		if valueName != strings.ToUpper(value.Value) {
+			field.Description = graphQLDescription(strconv.Quote(value.Value), "  ")
+		}
+		enum.Values = append(enum.Values, field)
+	}
+	return enum
+}
+
+// graphQLArgs returns the arguments selecting an object: the device ID, an
+// instance number per {i} in the path and any extra arguments
+func graphQLArgs(objectPath string, extra ...string) string {
+	args := []string{"deviceId: ID!"}
+	segments := strings.Split(strings.TrimSuffix(objectPath, "."), ".")
+	used := make(map[string]bool)
+	for i, segment := range segments {
+		if segment != "{i}" || i == 0 {
+			continue
+		}
+		name := lowerCamelCase(segments[i-1]) + "Instance"
+		for n := 2; used[name]; n++ {
+			name = lowerCamelCase(segments[i-1]) + "Instance" + strconv.Itoa(n)
+		}
+		used[name] = true
+		args = append(args, name+": Int!")
+	}
+	return "(" + strings.Join(append(args, extra...), ", ") + ")"
+}
+
+// graphQLValueType maps a CWMP type to a GraphQL type, adding the custom
+// scalars used to scalars
+func graphQLValueType(cwmpType string, dataTypes map[string]models.DataType, scalars map[string]string) string {
+	if _, ok := dataTypes[cwmpType]; ok {
+		return upperCamelCase(cwmpType)
+	}
+
+	var name string
+	switch strings.ToLower(cwmpType) {
+	case "int", "integer":
+		return "Int"
+	case "boolean", "bool":
+		return "Boolean"
+	case "unsignedint", "unsignedinteger":
+		name = "UnsignedInt"
+	case "long":
+		name = "Long"
+	case "unsignedlong":
+		name = "UnsignedLong"
+	case "datetime":
+		name = "DateTime"
+	case "base64":
+		name = "Base64"
+	case "hexbinary":
+		name = "HexBinary"
+	default:
+		return "String"
+	}
+	if _, ok := scalars[name]; !ok {
+		scalars[name] = graphQLScalars[name]
+	}
+	return name
+}
+
+// graphQLDescription formats a description as a block string on the lines
+// preceding a definition, or returns "" for an empty description
+func graphQLDescription(description, indent string) string {
+	lines := []string{}
+	for _, line := range strings.Split(strings.TrimSpace(description), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, indent+strings.ReplaceAll(line, `"""`, `\"""`))
+		}
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return indent + `"""` + "\n" + strings.Join(lines, "\n") + "\n" + indent + `"""` + "\n"
+}
@@ -0,0 +1,111 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Niceblueman/cwmp-codegen/internal/models"
+)
+
+func TestGenerateGraphQL(t *testing.T) {
+	model := &models.DataModel{
+		Name: "Device:2.1",
+		DataTypes: []models.DataType{
+			{Name: "IPAddress", Description: "An IP address", String: &models.StringType{}},
+			{Name: "MACAddress", String: &models.StringType{}},
+		},
+		Objects: []models.Object{
+			{Name: "Device.", Path: "Device.", Description: "The root object"},
+			{
+				Name: "Device.Host.{i}.",
+				Path: "Device.Host.{i}.",
+				Parameters: []models.Parameter{
+					{Name: "IPAddress", Type: "IPAddress", Access: "readOnly", Description: "Address of the host"},
+					{Name: "PhysAddress", Type: "MACAddress", Access: "readOnly"},
+					{Name: "Alias", Type: "string", Access: "readWrite"},
+					{Name: "DNSServers", Type: "list", IsList: true, ItemType: "IPAddress", Access: "readWrite"},
+					{Name: "Type", Type: "string", Access: "readOnly", Syntax: models.Syntax{String: &models.StringCons{
+						Enumeration: []models.Enumeration{{Value: "Ethernet"}, {Value: "10BASE-T"}},
+					}}},
+					{Name: "LeaseTimeRemaining", Type: "int", Access: "readOnly"},
+					{Name: "LastChange", Type: "dateTime", Access: "readOnly"},
+				},
+			},
+			{
+				Name: "Device.Host.{i}.IPv4Address.{i}.",
+				Path: "Device.Host.{i}.IPv4Address.{i}.",
+				Parameters: []models.Parameter{
+					{Name: "Enable", Type: "boolean", Access: "readWrite"},
+				},
+			},
+		},
+	}
+
+	tmpDir := t.TempDir()
+	files, err := GenerateGraphQL(model, tmpDir)
+	if err != nil {
+		t.Fatalf("GenerateGraphQL returned error: %v", err)
+	}
+	if len(files) != 1 || files[0] != "device_2_1.graphql" {
+		t.Fatalf("Expected device_2_1.graphql, got %v", files)
+	}
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, files[0]))
+	if err != nil {
+		t.Fatalf("Failed to read generated file: %v", err)
+	}
+
+	for _, expected := range []string{
+		"\"\"\"\nAn IP address\n\"\"\"\nscalar IPAddress",
+		"scalar MACAddress",
+		"scalar DateTime",
+		"enum DeviceHostInstanceType {\n  ETHERNET\n  \"\"\"\n  \"10BASE-T\"\n  \"\"\"\n  V_10BASE_T\n}",
+		"\"\"\"\nThe root object\n\"\"\"\ntype Device {\n  host(instanceNumber: Int): [DeviceHostInstance!]!\n}",
+		"  instanceNumber: Int!",
+		"  \"\"\"\n  Address of the host\n  \"\"\"\n  ipAddress: IPAddress",
+		"  physAddress: MACAddress",
+		"  dnsServers: [IPAddress!]",
+		"  type: DeviceHostInstanceType",
+		"  leaseTimeRemaining: Int",
+		"  lastChange: DateTime",
+		"  ipv4Address(instanceNumber: Int): [DeviceHostInstanceIPv4AddressInstance!]!",
+		"type Query {\n  \"\"\"\n  The Device. object of a device\n  \"\"\"\n  device(deviceId: ID!): Device\n}",
+		"input DeviceHostInstanceInput {\n  alias: String\n  dnsServers: [IPAddress!]\n}",
+		"setDeviceHostInstance(deviceId: ID!, hostInstance: Int!, input: DeviceHostInstanceInput!): DeviceHostInstance",
+		"setDeviceHostInstanceIPv4AddressInstance(deviceId: ID!, hostInstance: Int!, ipv4AddressInstance: Int!, input: DeviceHostInstanceIPv4AddressInstanceInput!)",
+	} {
+		if !strings.Contains(string(content), expected) {
+			t.Errorf("Expected generated schema to contain %q", expected)
+		}
+	}
+
+	if strings.Contains(string(content), "scalar UnsignedLong") {
+		t.Errorf("Expected only the custom scalars in use to be declared")
+	}
+}
+
+func TestGenerateGraphQLWithoutWritableParameters(t *testing.T) {
+	model := &models.DataModel{
+		Name: "Device:2.1",
+		Objects: []models.Object{
+			{Name: "Device.", Path: "Device.", Parameters: []models.Parameter{
+				{Name: "RootDataModelVersion", Type: "string", Access: "readOnly"},
+			}},
+		},
+	}
+
+	tmpDir := t.TempDir()
+	files, err := GenerateGraphQL(model, tmpDir)
+	if err != nil {
+		t.Fatalf("GenerateGraphQL returned error: %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(tmpDir, files[0]))
+	if err != nil {
+		t.Fatalf("Failed to read generated file: %v", err)
+	}
+	if strings.Contains(string(content), "Mutation") || strings.Contains(string(content), "input ") {
+		t.Errorf("Expected no Mutation type without writable parameters, got:\n%s", content)
+	}
+}
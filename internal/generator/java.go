@@ -0,0 +1,164 @@
+package generator
+
+import (
+	"path/filepath"
+	"text/template"
+
+	"github.com/Niceblueman/cwmp-codegen/internal/models"
+)
+
+// Java record template, one file per object
+const javaRecordTemplate = `// Code generated by cwmp-codegen. DO NOT EDIT.
+package {{.Package}};
+
+import com.fasterxml.jackson.annotation.JsonIgnoreProperties;
+import com.fasterxml.jackson.annotation.JsonInclude;
+import com.fasterxml.jackson.annotation.JsonProperty;
+import java.util.List;
+
+/**
+ * {{if .Class.Description}}{{.Class.Description}}{{else}}{{.Class.Name}}{{end}}
+{{- range .Class.Fields}}{{if .Description}}
+ *
+ * @param {{.JavaName}} {{.Description}}{{end}}{{end}}
+ */
+@JsonInclude(JsonInclude.Include.NON_NULL)
+@JsonIgnoreProperties(ignoreUnknown = true)
+public record {{.Class.Name}}(
+{{- range $i, $f := .Class.Fields}}{{if $i}},{{end}}
+        {{if $f.JSONName}}@JsonProperty({{$f.JSONName}}) {{end}}{{$f.JavaType}} {{$f.JavaName}}
+{{- end}}) {
+}
+`
+
+// Java enum template, one file per enumerated parameter
+const javaEnumTemplate = `// Code generated by cwmp-codegen. DO NOT EDIT.
+package {{.Package}};
+
+import com.fasterxml.jackson.annotation.JsonCreator;
+import com.fasterxml.jackson.annotation.JsonValue;
+
+/** Values of {{.Enum.Path}} */
+public enum {{.Enum.Name}} {
+{{- range $i, $c := .Enum.Constants}}{{if $i}},{{end}}
+    {{$c.Name}}({{$c.JavaLiteral}})
+{{- end}};
+
+    private final String value;
+
+    {{.Enum.Name}}(String value) {
+        this.value = value;
+    }
+
+    /** Returns the string value used on the wire */
+    @JsonValue
+    public String value() {
+        return value;
+    }
+
+    /** Returns the constant with the given string value */
+    @JsonCreator
+    public static {{.Enum.Name}} fromValue(String value) {
+        for ({{.Enum.Name}} constant : values()) {
+            if (constant.value.equals(value)) {
+                return constant;
+            }
+        }
+        throw new IllegalArgumentException("invalid {{.Enum.Name}} value: " + value);
+    }
+}
+`
+
+// Java path constants template
+const javaPathsTemplate = `// Code generated by cwmp-codegen. DO NOT EDIT.
+package {{.Package}};
+
+/** Paths of the objects and parameters of the data model, with {i} in place of instance numbers */
+public final class Paths {
+    private Paths() {
+    }
+{{range .Paths}}
+    public static final String {{.Name}} = {{.Literal}};
+{{- end}}
+}
+`
+
+// GenerateJava generates Java records from a data model, one file per type.
+// The types are the same as those of GenerateKotlin.
+func GenerateJava(model *models.DataModel, outputDir string, options KotlinOptions) ([]string, error) {
+	tmplData := convertModelToJVM(model, options)
+	recordTmpl, err := template.New("javaRecord").Parse(javaRecordTemplate)
+	if err != nil {
+		return nil, err
+	}
+	enumTmpl, err := template.New("javaEnum").Parse(javaEnumTemplate)
+	if err != nil {
+		return nil, err
+	}
+	pathsTmpl, err := template.New("javaPaths").Parse(javaPathsTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	outputFiles := []string{}
+	for _, enum := range tmplData.Enums {
+		fileName := enum.Name + ".java"
+		data := struct {
+			Package string
+			Enum    JVMEnum
+		}{tmplData.Package, enum}
+		if err := writeTemplate(enumTmpl, filepath.Join(outputDir, fileName), data); err != nil {
+			return outputFiles, err
+		}
+		outputFiles = append(outputFiles, fileName)
+	}
+
+	for _, jvmClass := range tmplData.Classes {
+		fileName := jvmClass.Name + ".java"
+		data := struct {
+			Package string
+			Class   JVMClass
+		}{tmplData.Package, jvmClass}
+		if err := writeTemplate(recordTmpl, filepath.Join(outputDir, fileName), data); err != nil {
+			return outputFiles, err
+		}
+		outputFiles = append(outputFiles, fileName)
+	}
+
+	if err := writeTemplate(pathsTmpl, filepath.Join(outputDir, "Paths.java"), tmplData); err != nil {
+		return outputFiles, err
+	}
+	outputFiles = append(outputFiles, "Paths.java")
+
+	return outputFiles, nil
+}
+
+// javaIdentifier appends an underscore to Java keywords and to names whose
+// record accessors would clash with the methods of Object
+func javaIdentifier(name string) string {
+	if javaReservedNames[name] {
+		return name + "_"
+	}
+	return name
+}
+
+// javaReservedNames lists the Java keywords and final or inherited methods of
+// Object that record components must avoid
+var javaReservedNames = map[string]bool{
+	"abstract": true, "assert": true, "boolean": true, "break": true,
+	"byte": true, "case": true, "catch": true, "char": true, "class": true,
+	"const": true, "continue": true, "default": true, "do": true,
+	"double": true, "else": true, "enum": true, "extends": true,
+	"false": true, "final": true, "finally": true, "float": true, "for": true,
+	"goto": true, "if": true, "implements": true, "import": true,
+	"instanceof": true, "int": true, "interface": true, "long": true,
+	"native": true, "new": true, "null": true, "package": true,
+	"private": true, "protected": true, "public": true, "return": true,
+	"short": true, "static": true, "strictfp": true, "super": true,
+	"switch": true, "synchronized": true, "this": true, "throw": true,
+	"throws": true, "transient": true, "true": true, "try": true,
+	"void": true, "volatile": true, "while": true, "record": true,
+	"yield": true, "var": true, "hashCode": true, "toString": true,
+	"getClass": true, "notify": true, "notifyAll": true, "wait": true,
+	"clone": true, "finalize": true,
+}
@@ -0,0 +1,267 @@
+package generator
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Niceblueman/cwmp-codegen/internal/models"
+)
+
+// jsonSchemaDialect is the JSON Schema draft of the generated schemas
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// jsonSchemaDefsRef prefixes references to named dataTypes
+const jsonSchemaDefsRef = "#/$defs/"
+
+// JSONSchema is the subset of a JSON Schema used by the generated schemas.
+// Fields are declared in the order they are best read in.
+type JSONSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	Ref                  string                 `json:"$ref,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Format               string                 `json:"format,omitempty"`
+	ContentEncoding      string                 `json:"contentEncoding,omitempty"`
+	ReadOnly             bool                   `json:"readOnly,omitempty"`
+	Enum                 []string               `json:"enum,omitempty"`
+	MinLength            *int                   `json:"minLength,omitempty"`
+	MaxLength            *int                   `json:"maxLength,omitempty"`
+	Pattern              string                 `json:"pattern,omitempty"`
+	Minimum              json.Number            `json:"minimum,omitempty"`
+	Maximum              json.Number            `json:"maximum,omitempty"`
+	Items                *JSONSchema            `json:"items,omitempty"`
+	MinItems             *int                   `json:"minItems,omitempty"`
+	MaxItems             *int                   `json:"maxItems,omitempty"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	PatternProperties    map[string]*JSONSchema `json:"patternProperties,omitempty"`
+	PropertyNames        *JSONSchema            `json:"propertyNames,omitempty"`
+	AdditionalProperties interface{}            `json:"additionalProperties,omitempty"` // false or a *JSONSchema
+	MinProperties        *int                   `json:"minProperties,omitempty"`
+	MaxProperties        *int                   `json:"maxProperties,omitempty"`
+	Defs                 map[string]*JSONSchema `json:"$defs,omitempty"`
+}
+
+// GenerateJSONSchema generates a JSON Schema (draft 2020-12) describing JSON
+// snapshots of a data model. Objects are nested object schemas, tables are
+// maps keyed by instance number and named dataTypes are $defs. Besides the
+// modelled names, objects accept vendor extensions named X_<VENDOR>_.
+func GenerateJSONSchema(model *models.DataModel, outputDir string) ([]string, error) {
+	fileName := strings.ToLower(sanitize(model.Name)) + ".schema.json"
+
+	dataTypes := make(map[string]models.DataType)
+	for _, dataType := range model.DataTypes {
+		dataTypes[dataType.Name] = dataType
+	}
+
+	schema := &JSONSchema{
+		Schema:      jsonSchemaDialect,
+		Title:       model.Name,
+		Description: jsonDescription(model.Description),
+		Type:        "object",
+		Properties:  make(map[string]*JSONSchema),
+	}
+
+	// Convert each named dataType to a definition
+	if len(model.DataTypes) > 0 {
+		schema.Defs = make(map[string]*JSONSchema)
+	}
+	for _, dataType := range model.DataTypes {
+		schema.Defs[dataType.Name] = convertDataTypeToJSONSchema(dataType, dataTypes, jsonSchemaDefsRef)
+	}
+
+	// Nest the objects under the root objects
+	objects := make(map[string]models.Object)
+	for _, obj := range model.Objects {
+		objects[objectPathPrefix(obj)] = obj
+	}
+	tree := linkGoObjectTree(model)
+	for _, root := range collectGoRootObjects(model.Objects, tree) {
+		schema.Properties[strings.TrimSuffix(root.Path, ".")] = convertObjectToJSONSchema(objects[root.Path], objects, tree, dataTypes)
+	}
+
+	if err := writeJSON(filepath.Join(outputDir, fileName), schema); err != nil {
+		return nil, err
+	}
+	return []string{fileName}, nil
+}
+
+// convertObjectToJSONSchema converts a CWMP object and its descendants to a
+// nested object schema
+func convertObjectToJSONSchema(obj models.Object, objects map[string]models.Object, tree map[string][]GoChildObject, dataTypes map[string]models.DataType) *JSONSchema {
+	schema := convertParametersToJSONSchema(obj, dataTypes, jsonSchemaDefsRef)
+	for _, child := range tree[objectPathPrefix(obj)] {
+		childObj := objects[child.FullPath]
+		childSchema := convertObjectToJSONSchema(childObj, objects, tree, dataTypes)
+		if child.IsMultiInstance {
+			entry := childSchema
+			childSchema = &JSONSchema{
+				Description:          entry.Description,
+				Type:                 "object",
+				PropertyNames:        &JSONSchema{Pattern: "^[1-9][0-9]*$"},
+				AdditionalProperties: entry,
+				MinProperties:        jsonEntryCount(childObj.MinEntries),
+				MaxProperties:        jsonEntryCount(childObj.MaxEntries),
+			}
+			entry.Description = ""
+		}
+		schema.Properties[child.Name] = childSchema
+	}
+
+	return schema
+}
+
+// convertParametersToJSONSchema converts the parameters of a CWMP object to
+// an object schema, referencing named dataTypes under refPrefix
+func convertParametersToJSONSchema(obj models.Object, dataTypes map[string]models.DataType, refPrefix string) *JSONSchema {
+	schema := &JSONSchema{
+		Description: jsonDescription(obj.Description),
+		Type:        "object",
+		Properties:  make(map[string]*JSONSchema),
+		PatternProperties: map[string]*JSONSchema{
+			"^X_": {},
+		},
+		AdditionalProperties: false,
+	}
+	for _, param := range obj.Parameters {
+		schema.Properties[param.Name] = convertParameterToJSONSchema(param, dataTypes, refPrefix)
+	}
+	return schema
+}
+
+// convertParameterToJSONSchema converts a parameter to the schema of its
+// value. Named dataTypes are referenced under refPrefix, with the parameter's own facets
+// applied alongside the reference.
+func convertParameterToJSONSchema(param models.Parameter, dataTypes map[string]models.DataType, refPrefix string) *JSONSchema {
+	itemType := param.Type
+	if param.IsList {
+		itemType = param.ItemType
+	}
+
+	var schema *JSONSchema
+	if _, ok := dataTypes[itemType]; ok {
+		schema = &JSONSchema{Ref: refPrefix + itemType}
+		if hasSyntaxFacets(param.Syntax) {
+			jsonValueFacets(schema, models.ResolveSyntax(param, nil))
+		}
+	} else {
+		schema = &JSONSchema{}
+		jsonValueFacets(schema, models.ResolveSyntax(param, dataTypes))
+	}
+
+	if param.IsList {
+		schema = &JSONSchema{Type: "array", Items: schema}
+		if list := param.Syntax.List; list != nil {
+			schema.MinItems = jsonEntryCount(list.MinItems)
+			schema.MaxItems = jsonEntryCount(list.MaxItems)
+		}
+	}
+
+	schema.Description = jsonDescription(param.Description)
+	schema.ReadOnly = param.Access == "readOnly"
+	return schema
+}
+
+// convertDataTypeToJSONSchema converts a named dataType to a definition,
+// referencing its base dataType if any
+func convertDataTypeToJSONSchema(dataType models.DataType, dataTypes map[string]models.DataType, refPrefix string) *JSONSchema {
+	schema := &JSONSchema{Description: jsonDescription(dataType.Description)}
+	if _, ok := dataTypes[dataType.Base]; ok {
+		schema.Ref = refPrefix + dataType.Base
+	}
+
+	// Only the facets declared by the dataType itself; the base adds its own
+	own := map[string]models.DataType{dataType.Name: {
+		String:       dataType.String,
+		Boolean:      dataType.Boolean,
+		DateTime:     dataType.DateTime,
+		UnsignedInt:  dataType.UnsignedInt,
+		Int:          dataType.Int,
+		Long:         dataType.Long,
+		UnsignedLong: dataType.UnsignedLong,
+		Base64:       dataType.Base64,
+		HexBinary:    dataType.HexBinary,
+	}}
+	if schema.Ref == "" || own[dataType.Name] != (models.DataType{}) {
+		jsonValueFacets(schema, models.ResolveSyntax(models.Parameter{Type: dataType.Name}, own))
+	}
+	return schema
+}
+
+// jsonValueFacets sets the type and facets of a single value
+func jsonValueFacets(schema *JSONSchema, value models.ValueSyntax) {
+	switch strings.ToLower(value.Type) {
+	case "boolean", "bool":
+		schema.Type = "boolean"
+	case "datetime":
+		schema.Type, schema.Format = "string", "date-time"
+	case "int", "integer", "unsignedint", "unsignedinteger", "long", "unsignedlong":
+		schema.Type = "integer"
+		minimum, maximum := integerBounds(value)
+		schema.Minimum, schema.Maximum = json.Number(minimum), json.Number(maximum)
+	case "base64":
+		schema.Type, schema.ContentEncoding = "string", "base64"
+		if value.Size != nil && value.Size.Max > 0 {
+			// Four characters per three bytes
+			maxLength := (value.Size.Max + 2) / 3 * 4
+			schema.MaxLength = &maxLength
+		}
+	case "hexbinary":
+		schema.Type, schema.Pattern = "string", "^(?:[0-9A-Fa-f]{2})*$"
+		if value.Size != nil && value.Size.Max > 0 {
+			maxLength := value.Size.Max * 2
+			schema.MaxLength = &maxLength
+		}
+	default:
+		schema.Type = "string"
+		if value.Size != nil {
+			if value.Size.Min > 0 {
+				schema.MinLength = &value.Size.Min
+			}
+			if value.Size.Max > 0 {
+				schema.MaxLength = &value.Size.Max
+			}
+		}
+		// XML Schema patterns match the whole value, any of them may match
+		if len(value.Patterns) > 0 {
+			patterns := make([]string, len(value.Patterns))
+			for i, pattern := range value.Patterns {
+				patterns[i] = pattern.Value
+			}
+			schema.Pattern = "^(?:" + strings.Join(patterns, "|") + ")$"
+		}
+		for _, enum := range value.Enumeration {
+			schema.Enum = append(schema.Enum, enum.Value)
+		}
+	}
+}
+
+// hasSyntaxFacets reports whether a syntax declares a value element of its own
+func hasSyntaxFacets(syntax models.Syntax) bool {
+	return syntax.String != nil || syntax.Boolean != nil || syntax.DateTime != nil ||
+		syntax.UnsignedInt != nil || syntax.Int != nil || syntax.Long != nil ||
+		syntax.UnsignedLong != nil || syntax.Base64 != nil || syntax.HexBinary != nil
+}
+
+// jsonEntryCount parses a minEntries, maxEntries, minItems or maxItems
+// value, returning nil when it is absent, zero or "unbounded"
+func jsonEntryCount(value string) *int {
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		return nil
+	}
+	return &n
+}
+
+// jsonDescription joins the lines of a description
+func jsonDescription(description string) string {
+	lines := []string{}
+	for _, line := range strings.Split(strings.TrimSpace(description), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,115 @@
+package generator
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Niceblueman/cwmp-codegen/internal/models"
+)
+
+func TestGenerateJSONSchema(t *testing.T) {
+	model := &models.DataModel{
+		Name: "Device:2.1",
+		DataTypes: []models.DataType{
+			{Name: "IPAddress", Description: "An IP address", String: &models.StringType{Size: &models.Size{Max: 45}}},
+			{Name: "IPv4Address", Base: "IPAddress", String: &models.StringType{Size: &models.Size{Max: 15}}},
+		},
+		Objects: []models.Object{
+			{Name: "Device.", Path: "Device.", Description: "The root object"},
+			{
+				Name:        "Device.Host.{i}.",
+				Path:        "Device.Host.{i}.",
+				Description: "A host",
+				MaxEntries:  "16",
+				Parameters: []models.Parameter{
+					{Name: "IPAddress", Type: "IPv4Address", Access: "readOnly", Description: "Address of the host"},
+					{Name: "Alias", Type: "string", Syntax: models.Syntax{String: &models.StringCons{
+						Size:    &models.Size{Max: 64},
+						Pattern: []models.Pattern{{Value: ""}, {Value: "[a-z]+"}},
+					}}},
+					{Name: "Status", Type: "string", Syntax: models.Syntax{String: &models.StringCons{
+						Enumeration: []models.Enumeration{{Value: "Up"}, {Value: "Down"}},
+					}}},
+					{Name: "LeaseTime", Type: "int", Syntax: models.Syntax{Int: &models.Int{Range: &models.Range{MinInclusive: "-1"}}}},
+					{Name: "DNSServers", Type: "list", IsList: true, ItemType: "IPAddress", Syntax: models.Syntax{List: &models.List{MaxItems: "4"}}},
+					{Name: "Key", Type: "base64", Syntax: models.Syntax{Base64: &models.Base64{Size: &models.Size{Max: 16}}}},
+				},
+			},
+		},
+	}
+
+	tmpDir := t.TempDir()
+	files, err := GenerateJSONSchema(model, tmpDir)
+	if err != nil {
+		t.Fatalf("GenerateJSONSchema returned error: %v", err)
+	}
+	if len(files) != 1 || files[0] != "device_2_1.schema.json" {
+		t.Fatalf("Expected device_2_1.schema.json, got %v", files)
+	}
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, files[0]))
+	if err != nil {
+		t.Fatalf("Failed to read generated file: %v", err)
+	}
+	for _, expected := range []string{
+		`"$schema": "https://json-schema.org/draft/2020-12/schema"`,
+		`"title": "Device:2.1"`,
+		`"additionalProperties": false`,
+		`"minimum": -1,`,
+		`"maximum": 2147483647`,
+		`"pattern": "^(?:|[a-z]+)$"`,
+	} {
+		if !strings.Contains(string(content), expected) {
+			t.Errorf("Expected generated schema to contain %s", expected)
+		}
+	}
+
+	var schema JSONSchema
+	if err := json.Unmarshal(content, &schema); err != nil {
+		t.Fatalf("Generated schema is not valid JSON: %v", err)
+	}
+
+	ipv4 := schema.Defs["IPv4Address"]
+	if ipv4 == nil || ipv4.Ref != "#/$defs/IPAddress" || *ipv4.MaxLength != 15 {
+		t.Errorf("Expected IPv4Address to reference IPAddress with maxLength 15, got %+v", ipv4)
+	}
+	if ipAddress := schema.Defs["IPAddress"]; ipAddress == nil || ipAddress.Description != "An IP address" || ipAddress.Type != "string" {
+		t.Errorf("Expected the described IPAddress string definition, got %+v", ipAddress)
+	}
+
+	device := schema.Properties["Device"]
+	if device == nil || device.Description != "The root object" {
+		t.Fatalf("Expected the Device object schema, got %+v", device)
+	}
+	host := device.Properties["Host"]
+	if host == nil || host.Description != "A host" || host.PropertyNames.Pattern != "^[1-9][0-9]*$" || *host.MaxProperties != 16 {
+		t.Fatalf("Expected Host to be a map keyed by instance number, got %+v", host)
+	}
+
+	// Decoded as a generic value since additionalProperties is false or a schema
+	entryJSON, _ := json.Marshal(host.AdditionalProperties)
+	var entry JSONSchema
+	if err := json.Unmarshal(entryJSON, &entry); err != nil {
+		t.Fatalf("Failed to decode the Host entry schema: %v", err)
+	}
+
+	ipAddress := entry.Properties["IPAddress"]
+	if ipAddress.Ref != "#/$defs/IPv4Address" || !ipAddress.ReadOnly || ipAddress.Description != "Address of the host" {
+		t.Errorf("Expected IPAddress to reference IPv4Address, got %+v", ipAddress)
+	}
+	if status := entry.Properties["Status"]; len(status.Enum) != 2 || status.Enum[1] != "Down" {
+		t.Errorf("Expected the Status enumeration, got %+v", status)
+	}
+	if dns := entry.Properties["DNSServers"]; dns.Type != "array" || dns.Items.Ref != "#/$defs/IPAddress" || *dns.MaxItems != 4 {
+		t.Errorf("Expected DNSServers to be an array of IPAddress, got %+v", dns)
+	}
+	if key := entry.Properties["Key"]; key.ContentEncoding != "base64" || *key.MaxLength != 24 {
+		t.Errorf("Expected Key to be base64 of at most 24 characters, got %+v", key)
+	}
+	if entry.PatternProperties["^X_"] == nil {
+		t.Errorf("Expected vendor extensions to be allowed")
+	}
+}
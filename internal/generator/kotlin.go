@@ -0,0 +1,311 @@
+package generator
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/Niceblueman/cwmp-codegen/internal/models"
+)
+
+// Kotlin source template. The generated classes depend on
+// jackson-annotations, and deserializing them on jackson-module-kotlin.
+const kotlinTemplate = `// Code generated by cwmp-codegen. DO NOT EDIT.
+// Data model {{.ModelName}}
+//
+// Deserializing the classes with Jackson requires jackson-module-kotlin.
+package {{.Package}}
+
+import com.fasterxml.jackson.annotation.JsonCreator
+import com.fasterxml.jackson.annotation.JsonIgnoreProperties
+import com.fasterxml.jackson.annotation.JsonInclude
+import com.fasterxml.jackson.annotation.JsonProperty
+import com.fasterxml.jackson.annotation.JsonValue
+{{range .Enums}}
+/** Values of {{.Path}} */
+enum class {{.Name}}(@get:JsonValue val value: String) {
+{{- range $i, $c := .Constants}}{{if $i}},{{end}}
+    {{$c.Name}}({{$c.KotlinLiteral}})
+{{- end}};
+
+    companion object {
+        /** Returns the constant with the given string value */
+        @JvmStatic
+        @JsonCreator
+        fun fromValue(value: String): {{.Name}} =
+            values().firstOrNull { it.value == value }
+                ?: throw IllegalArgumentException("invalid {{.Name}} value: " + value)
+    }
+}
+{{end}}{{range .Classes}}
+{{.Doc}}@JsonInclude(JsonInclude.Include.NON_NULL)
+@JsonIgnoreProperties(ignoreUnknown = true)
+{{if .Fields}}data {{end}}class {{.Name}}{{if .Fields}}(
+{{- range .Fields}}
+{{.Doc}}{{if .JSONName}}    @param:JsonProperty({{.JSONName}}) @get:JsonProperty({{.JSONName}})
+{{end}}    val {{.KotlinName}}: {{.KotlinType}} = {{.KotlinDefault}},
+{{- end}}
+){{end}}
+{{end}}
+/** Paths of the objects and parameters of the data model, with {i} in place of instance numbers */
+object Paths {
+{{- range .Paths}}
+    const val {{.Name}} = {{.Literal}}
+{{- end}}
+}
+`
+
+// KotlinOptions controls the generated Kotlin and Java sources
+type KotlinOptions struct {
+	Package string // Kotlin or Java package, derived from the model name when empty
+}
+
+// JVMTemplate contains data for the Kotlin and Java templates
+type JVMTemplate struct {
+	ModelName string
+	Package   string
+	Enums     []JVMEnum
+	Classes   []JVMClass
+	Paths     []PathConstant
+}
+
+// JVMEnum is the enum class of an enumerated parameter
+type JVMEnum struct {
+	Name      string
+	Path      string
+	Constants []JVMConstant
+}
+
+// JVMConstant is a constant of a JVMEnum
+type JVMConstant struct {
+	Name          string
+	KotlinLiteral string
+	JavaLiteral   string
+}
+
+// JVMClass is a Kotlin data class or Java record
+type JVMClass struct {
+	Name        string
+	Doc         string
+	Description string // Javadoc text of the record
+	Fields      []JVMField
+}
+
+// JVMField is a property of a JVMClass
+type JVMField struct {
+	KotlinName    string
+	JavaName      string
+	JSONName      string // Quoted CWMP name, empty for the instance number
+	Doc           string
+	Description   string // Javadoc text for the record's @param tag
+	KotlinType    string
+	KotlinDefault string
+	JavaType      string
+}
+
+// GenerateKotlin generates Kotlin data classes from a data model
+func GenerateKotlin(model *models.DataModel, outputDir string, options KotlinOptions) ([]string, error) {
+	fileName := sanitize(model.Name) + ".kt"
+
+	tmplData := convertModelToJVM(model, options)
+	tmpl, err := template.New("kotlin").Parse(kotlinTemplate)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeTemplate(tmpl, filepath.Join(outputDir, fileName), tmplData); err != nil {
+		return nil, err
+	}
+
+	return []string{fileName}, nil
+}
+
+// convertModelToJVM converts the objects of a data model the same way as the
+// Go generator, so that the JVM types carry the Go struct names
+func convertModelToJVM(model *models.DataModel, options KotlinOptions) JVMTemplate {
+	tmplData := JVMTemplate{
+		ModelName: model.Name,
+		Package:   options.Package,
+		Enums:     []JVMEnum{},
+		Classes:   []JVMClass{},
+		Paths:     []PathConstant{},
+	}
+	if tmplData.Package == "" {
+		tmplData.Package = strings.ToLower(sanitize(model.Name))
+	}
+
+	dataTypes := make(map[string]models.DataType)
+	for _, dataType := range model.DataTypes {
+		dataTypes[dataType.Name] = dataType
+	}
+
+	tree := linkGoObjectTree(model)
+	for _, obj := range model.Objects {
+		goObj := convertObjectToGoStruct(obj, dataTypes)
+		goObj.ChildObjects = tree[objectPathPrefix(obj)]
+
+		jvmClass, enums := convertGoObjectToJVM(goObj, obj, dataTypes)
+		tmplData.Classes = append(tmplData.Classes, jvmClass)
+		tmplData.Enums = append(tmplData.Enums, enums...)
+		tmplData.Paths = append(tmplData.Paths, objectPathConstants(obj)...)
+	}
+	return tmplData
+}
+
+// convertGoObjectToJVM converts a Go struct to a JVM class and the enums of
+// its enumerated parameters. The CWMP object supplies the parameter syntax.
+func convertGoObjectToJVM(goObj GoObject, obj models.Object, dataTypes map[string]models.DataType) (JVMClass, []JVMEnum) {
+	jvmClass := JVMClass{
+		Name:        goObj.GoName,
+		Doc:         jvmDoc(goObj.Description, ""),
+		Description: jvmCommentText(goObj.Description),
+		Fields:      []JVMField{},
+	}
+	enums := []JVMEnum{}
+
+	params := make(map[string]models.Parameter)
+	for _, param := range obj.Parameters {
+		if _, ok := params[param.Name]; !ok {
+			params[param.Name] = param
+		}
+	}
+
+	if isTableEntry(obj) {
+		jvmClass.Fields = append(jvmClass.Fields, JVMField{
+			KotlinName:    "instanceNumber",
+			JavaName:      "instanceNumber",
+			Doc:           jvmDoc(instanceNumberDescription, "    "),
+			Description:   instanceNumberDescription,
+			KotlinType:    "Int",
+			KotlinDefault: "0",
+			JavaType:      "int",
+		})
+	}
+
+	for _, goParam := range goObj.Parameters {
+		param := params[goParam.Name]
+		value := models.ResolveSyntax(param, dataTypes)
+		kotlinType, javaType := mapCWMPTypeToJVMTypes(value.Type)
+
+		if !param.IsList && value.Type == "string" && len(value.Enumeration) > 0 {
+			enum := convertEnumerationToJVM(goObj.GoName+"_"+goParam.GoName, objectPathPrefix(obj)+param.Name, value.Enumeration)
+			enums = append(enums, enum)
+			kotlinType, javaType = enum.Name, enum.Name
+		}
+		if param.IsList {
+			kotlinType, javaType = "List<"+kotlinType+">", "List<"+javaType+">"
+		}
+
+		jvmClass.Fields = append(jvmClass.Fields, JVMField{
+			KotlinName:    kotlinIdentifier(lowerCamelCase(goParam.Name)),
+			JavaName:      javaIdentifier(lowerCamelCase(goParam.Name)),
+			JSONName:      strconv.Quote(goParam.Name),
+			Doc:           jvmDoc(goParam.Description, "    "),
+			Description:   jvmCommentText(goParam.Description),
+			KotlinType:    kotlinType + "?",
+			KotlinDefault: "null",
+			JavaType:      javaType,
+		})
+	}
+
+	for _, child := range goObj.ChildObjects {
+		field := JVMField{
+			KotlinName:    kotlinIdentifier(lowerCamelCase(child.Name)),
+			JavaName:      javaIdentifier(lowerCamelCase(child.Name)),
+			JSONName:      strconv.Quote(child.Name),
+			KotlinType:    child.StructName + "?",
+			KotlinDefault: "null",
+			JavaType:      child.StructName,
+		}
+		if child.IsMultiInstance {
+			field.KotlinType = "List<" + child.StructName + ">"
+			field.KotlinDefault = "emptyList()"
+			field.JavaType = "List<" + child.StructName + ">"
+		}
+		jvmClass.Fields = append(jvmClass.Fields, field)
+	}
+
+	return jvmClass, enums
+}
+
+// convertEnumerationToJVM converts enumeration values to enum constants,
+// keeping the constant names unique
+func convertEnumerationToJVM(name, path string, enumeration []models.Enumeration) JVMEnum {
+	enum := JVMEnum{Name: name, Path: path}
+	used := make(map[string]bool)
+	for _, value := range enumeration {
+		constant := enumValueName(value.Value)
+		for n := 2; used[constant]; n++ {
+			constant = enumValueName(value.Value) + "_" + strconv.Itoa(n)
+		}
+		used[constant] = true
+		enum.Constants = append(enum.Constants, JVMConstant{
+			Name:          constant,
+			KotlinLiteral: kotlinStringLiteral(value.Value),
+			JavaLiteral:   strconv.Quote(value.Value),
+		})
+	}
+	return enum
+}
+
+// mapCWMPTypeToJVMTypes maps CWMP types to nullable Kotlin and boxed Java
+// types. Unsigned types are widened so that every value fits.
+func mapCWMPTypeToJVMTypes(cwmpType string) (string, string) {
+	switch strings.ToLower(cwmpType) {
+	case "int", "integer":
+		return "Int", "Integer"
+	case "unsignedint", "unsignedinteger", "long":
+		return "Long", "Long"
+	case "unsignedlong":
+		return "java.math.BigInteger", "java.math.BigInteger"
+	case "boolean", "bool":
+		return "Boolean", "Boolean"
+	case "base64":
+		// Jackson encodes byte arrays as base64
+		return "ByteArray", "byte[]"
+	default:
+		// Strings, ISO 8601 dateTimes and hex-encoded binaries
+		return "String", "String"
+	}
+}
+
+// kotlinIdentifier quotes Kotlin hard keywords with backticks
+func kotlinIdentifier(name string) string {
+	if kotlinKeywords[name] {
+		return "`" + name + "`"
+	}
+	return name
+}
+
+// kotlinKeywords lists the Kotlin hard keywords
+var kotlinKeywords = map[string]bool{
+	"as": true, "break": true, "class": true, "continue": true, "do": true,
+	"else": true, "false": true, "for": true, "fun": true, "if": true,
+	"in": true, "interface": true, "is": true, "null": true, "object": true,
+	"package": true, "return": true, "super": true, "this": true,
+	"throw": true, "true": true, "try": true, "typealias": true,
+	"typeof": true, "val": true, "var": true, "when": true, "while": true,
+}
+
+// kotlinStringLiteral quotes a string for Kotlin sources, escaping dollar
+// signs that Kotlin would interpolate
+func kotlinStringLiteral(value string) string {
+	return strings.ReplaceAll(strconv.Quote(value), "$", `\$`)
+}
+
+// jvmDoc formats a description as a single-line KDoc or Javadoc comment
+func jvmDoc(description, indent string) string {
+	comment := jvmCommentText(description)
+	if comment == "" {
+		return ""
+	}
+	return indent + "/** " + comment + " */\n"
+}
+
+// jvmCommentText joins the lines of a description and escapes comment
+// delimiters; Kotlin also nests /* comments
+func jvmCommentText(description string) string {
+	comment := strings.Join(strings.Fields(description), " ")
+	comment = strings.ReplaceAll(comment, "*/", "*&#47;")
+	return strings.ReplaceAll(comment, "/*", "/&#42;")
+}
@@ -0,0 +1,122 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Niceblueman/cwmp-codegen/internal/models"
+)
+
+// jvmTestModel returns a model exercising the Kotlin and Java generators
+func jvmTestModel() *models.DataModel {
+	return &models.DataModel{
+		Name: "Device:2.1",
+		Objects: []models.Object{
+			{Name: "Device.", Path: "Device.", Description: "The root */ object"},
+			{
+				Name: "Device.Host.{i}.",
+				Path: "Device.Host.{i}.",
+				Parameters: []models.Parameter{
+					{Name: "Alias", Type: "string", Description: "Alias of the host"},
+					{Name: "DNSServers", Type: "list", IsList: true, ItemType: "string"},
+					{Name: "Status", Type: "string", Syntax: models.Syntax{String: &models.StringCons{
+						Enumeration: []models.Enumeration{{Value: "Up"}, {Value: "10BASE-T"}, {Value: "$Cost"}},
+					}}},
+					{Name: "LeaseTime", Type: "unsignedInt"},
+					{Name: "Default", Type: "boolean"},
+					{Name: "Class", Type: "int"},
+					{Name: "Key", Type: "base64"},
+				},
+			},
+		},
+	}
+}
+
+func TestGenerateKotlin(t *testing.T) {
+	tmpDir := t.TempDir()
+	files, err := GenerateKotlin(jvmTestModel(), tmpDir, KotlinOptions{Package: "com.example.tr181"})
+	if err != nil {
+		t.Fatalf("GenerateKotlin returned error: %v", err)
+	}
+	if len(files) != 1 || files[0] != "Device_2_1.kt" {
+		t.Fatalf("Expected Device_2_1.kt, got %v", files)
+	}
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, files[0]))
+	if err != nil {
+		t.Fatalf("Failed to read generated file: %v", err)
+	}
+
+	for _, expected := range []string{
+		"package com.example.tr181",
+		"enum class Device_Host_Instance_Status(@get:JsonValue val value: String) {\n    UP(\"Up\"),\n    V_10BASE_T(\"10BASE-T\"),\n    COST(\"\\$Cost\");",
+		"fun fromValue(value: String): Device_Host_Instance_Status =",
+		"/** The root *&#47; object */\n@JsonInclude(JsonInclude.Include.NON_NULL)\n@JsonIgnoreProperties(ignoreUnknown = true)\ndata class Device(",
+		"    @param:JsonProperty(\"Host\") @get:JsonProperty(\"Host\")\n    val host: List<Device_Host_Instance> = emptyList(),",
+		"    val instanceNumber: Int = 0,",
+		"    /** Alias of the host */\n    @param:JsonProperty(\"Alias\") @get:JsonProperty(\"Alias\")\n    val alias: String? = null,",
+		"val dnsServers: List<String>? = null,",
+		"val status: Device_Host_Instance_Status? = null,",
+		"val leaseTime: Long? = null,",
+		"val default: Boolean? = null,",
+		"val `class`: Int? = null,",
+		"val key: ByteArray? = null,",
+		"const val DEVICE_HOST_INSTANCE_LEASE_TIME = \"Device.Host.{i}.LeaseTime\"",
+	} {
+		if !strings.Contains(string(content), expected) {
+			t.Errorf("Expected generated code to contain %q", expected)
+		}
+	}
+}
+
+func TestGenerateJava(t *testing.T) {
+	tmpDir := t.TempDir()
+	files, err := GenerateJava(jvmTestModel(), tmpDir, KotlinOptions{})
+	if err != nil {
+		t.Fatalf("GenerateJava returned error: %v", err)
+	}
+
+	expectedFiles := []string{"Device_Host_Instance_Status.java", "Device.java", "Device_Host_Instance.java", "Paths.java"}
+	if strings.Join(files, " ") != strings.Join(expectedFiles, " ") {
+		t.Fatalf("Expected files %v, got %v", expectedFiles, files)
+	}
+
+	expected := map[string][]string{
+		"Device_Host_Instance_Status.java": {
+			"package device_2_1;",
+			"public enum Device_Host_Instance_Status {\n    UP(\"Up\"),\n    V_10BASE_T(\"10BASE-T\"),\n    COST(\"$Cost\");",
+			"@JsonCreator\n    public static Device_Host_Instance_Status fromValue(String value) {",
+		},
+		"Device.java": {
+			" * The root *&#47; object\n */",
+			"public record Device(\n        @JsonProperty(\"Host\") List<Device_Host_Instance> host) {",
+		},
+		"Device_Host_Instance.java": {
+			" * @param instanceNumber Instance number of this entry\n *\n * @param alias Alias of the host\n */",
+			"        int instanceNumber,",
+			"        @JsonProperty(\"Alias\") String alias,",
+			"        @JsonProperty(\"DNSServers\") List<String> dnsServers,",
+			"        @JsonProperty(\"Status\") Device_Host_Instance_Status status,",
+			"        @JsonProperty(\"LeaseTime\") Long leaseTime,",
+			"        @JsonProperty(\"Default\") Boolean default_,",
+			"        @JsonProperty(\"Class\") Integer class_,",
+			"        @JsonProperty(\"Key\") byte[] key) {",
+		},
+		"Paths.java": {
+			"public static final String DEVICE_HOST_INSTANCE_ALIAS = \"Device.Host.{i}.Alias\";",
+		},
+	}
+	for fileName, snippets := range expected {
+		content, err := os.ReadFile(filepath.Join(tmpDir, fileName))
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", fileName, err)
+		}
+		for _, snippet := range snippets {
+			if !strings.Contains(string(content), snippet) {
+				t.Errorf("Expected %s to contain %q", fileName, snippet)
+			}
+		}
+	}
+}
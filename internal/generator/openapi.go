@@ -0,0 +1,301 @@
+package generator
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Niceblueman/cwmp-codegen/internal/models"
+)
+
+// openAPISchemasRef prefixes references to component schemas
+const openAPISchemasRef = "#/components/schemas/"
+
+// OpenAPIDocument is the subset of an OpenAPI 3.1 document used by the
+// generated REST facade
+type OpenAPIDocument struct {
+	OpenAPI    string                      `json:"openapi"`
+	Info       OpenAPIInfo                 `json:"info"`
+	Paths      map[string]*OpenAPIPathItem `json:"paths"`
+	Components OpenAPIComponents           `json:"components"`
+}
+
+// OpenAPIInfo describes the API
+type OpenAPIInfo struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// OpenAPIPathItem holds the operations of a path
+type OpenAPIPathItem struct {
+	Summary    string              `json:"summary,omitempty"`
+	Parameters []*OpenAPIParameter `json:"parameters,omitempty"`
+	Get        *OpenAPIOperation   `json:"get,omitempty"`
+	Post       *OpenAPIOperation   `json:"post,omitempty"`
+	Patch      *OpenAPIOperation   `json:"patch,omitempty"`
+	Delete     *OpenAPIOperation   `json:"delete,omitempty"`
+}
+
+// OpenAPIOperation is an operation on a path
+type OpenAPIOperation struct {
+	OperationID string                      `json:"operationId"`
+	Summary     string                      `json:"summary,omitempty"`
+	RequestBody *OpenAPIRequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*OpenAPIResponse `json:"responses"`
+}
+
+// OpenAPIParameter is a path parameter, or a reference to one
+type OpenAPIParameter struct {
+	Ref         string      `json:"$ref,omitempty"`
+	Name        string      `json:"name,omitempty"`
+	In          string      `json:"in,omitempty"`
+	Description string      `json:"description,omitempty"`
+	Required    bool        `json:"required,omitempty"`
+	Schema      *JSONSchema `json:"schema,omitempty"`
+}
+
+// OpenAPIRequestBody is the body of a request
+type OpenAPIRequestBody struct {
+	Required bool                        `json:"required,omitempty"`
+	Content  map[string]OpenAPIMediaType `json:"content"`
+}
+
+// OpenAPIResponse is a response, or a reference to one
+type OpenAPIResponse struct {
+	Ref         string                       `json:"$ref,omitempty"`
+	Description string                       `json:"description,omitempty"`
+	Headers     map[string]*OpenAPIParameter `json:"headers,omitempty"`
+	Content     map[string]OpenAPIMediaType  `json:"content,omitempty"`
+}
+
+// OpenAPIMediaType holds the schema of a body
+type OpenAPIMediaType struct {
+	Schema *JSONSchema `json:"schema"`
+}
+
+// OpenAPIComponents holds the objects reused across paths
+type OpenAPIComponents struct {
+	Schemas    map[string]*JSONSchema       `json:"schemas"`
+	Parameters map[string]*OpenAPIParameter `json:"parameters,omitempty"`
+	Responses  map[string]*OpenAPIResponse  `json:"responses"`
+}
+
+// GenerateOpenAPI generates an OpenAPI 3.1 document for a REST facade over a
+// data model. Every object path gets GET, and PATCH when it has writable
+// parameters. Tables get a collection path, with POST (AddObject) and DELETE
+// (DeleteObject) when the table is readWrite.
+func GenerateOpenAPI(model *models.DataModel, outputDir string) ([]string, error) {
+	fileName := strings.ToLower(sanitize(model.Name)) + ".openapi.json"
+
+	doc := &OpenAPIDocument{
+		OpenAPI: "3.1.0",
+		Info: OpenAPIInfo{
+			Title:       model.Name,
+			Description: jsonDescription(model.Description),
+			Version:     openAPIVersion(model),
+		},
+		Paths: make(map[string]*OpenAPIPathItem),
+		Components: OpenAPIComponents{
+			Schemas:    make(map[string]*JSONSchema),
+			Parameters: make(map[string]*OpenAPIParameter),
+			Responses:  openAPIResponses(),
+		},
+	}
+
+	// CWMP faults are the error bodies
+	doc.Components.Schemas["Fault"] = &JSONSchema{
+		Description: "A CWMP fault",
+		Type:        "object",
+		Properties: map[string]*JSONSchema{
+			"FaultCode":   {Type: "integer", Description: "CWMP fault code, e.g. 9005 for an invalid parameter name"},
+			"FaultString": {Type: "string"},
+		},
+	}
+	doc.Components.Schemas["AddObjectResponse"] = &JSONSchema{
+		Description: "Result of adding a table entry",
+		Type:        "object",
+		Properties: map[string]*JSONSchema{
+			"InstanceNumber": {Type: "integer", Minimum: "1"},
+		},
+	}
+
+	dataTypes := make(map[string]models.DataType)
+	for _, dataType := range model.DataTypes {
+		dataTypes[dataType.Name] = dataType
+	}
+	for _, dataType := range model.DataTypes {
+		doc.Components.Schemas[dataType.Name] = convertDataTypeToJSONSchema(dataType, dataTypes, openAPISchemasRef)
+	}
+
+	for _, obj := range model.Objects {
+		name := upperCamelCase(sanitize(obj.Name))
+		doc.Components.Schemas[name] = convertParametersToJSONSchema(obj, dataTypes, openAPISchemasRef)
+		addOpenAPIPaths(doc, obj, name)
+	}
+
+	if err := writeJSON(filepath.Join(outputDir, fileName), doc); err != nil {
+		return nil, err
+	}
+	return []string{fileName}, nil
+}
+
+// addOpenAPIPaths adds the paths and operations of an object
+func addOpenAPIPaths(doc *OpenAPIDocument, obj models.Object, name string) {
+	path, parameters := openAPIPath(doc, objectPathPrefix(obj))
+	ref := &JSONSchema{Ref: openAPISchemasRef + name}
+	writable := false
+	for _, param := range obj.Parameters {
+		if param.Access == "readWrite" {
+			writable = true
+		}
+	}
+
+	item := &OpenAPIPathItem{
+		Summary:    strings.TrimSuffix(objectPathPrefix(obj), "."),
+		Parameters: parameters,
+		Get: &OpenAPIOperation{
+			OperationID: "get" + name,
+			Summary:     "Get the parameters of " + objectPathPrefix(obj),
+			Responses: map[string]*OpenAPIResponse{
+				"200":     {Description: "The parameter values", Content: openAPIJSON(ref)},
+				"default": {Ref: "#/components/responses/Fault"},
+			},
+		},
+	}
+	if writable {
+		item.Patch = &OpenAPIOperation{
+			OperationID: "update" + name,
+			Summary:     "Set writable parameters of " + objectPathPrefix(obj),
+			RequestBody: &OpenAPIRequestBody{Required: true, Content: openAPIJSON(ref)},
+			Responses: map[string]*OpenAPIResponse{
+				"204":     {Description: "The parameters were set"},
+				"default": {Ref: "#/components/responses/Fault"},
+			},
+		}
+	}
+	doc.Paths[path] = item
+
+	if !isTableEntry(obj) {
+		return
+	}
+
+	// Table entries are also listed, added and deleted through the table
+	addable := obj.Access == "readWrite"
+	collection := strings.TrimSuffix(objectPathPrefix(obj), "{i}.")
+	collectionPath, collectionParameters := openAPIPath(doc, collection)
+	collectionName := upperCamelCase(sanitize(collection))
+	item.Get.Responses["404"] = &OpenAPIResponse{Ref: "#/components/responses/NotFound"}
+	if item.Patch != nil {
+		item.Patch.Responses["404"] = &OpenAPIResponse{Ref: "#/components/responses/NotFound"}
+	}
+	if addable {
+		item.Delete = &OpenAPIOperation{
+			OperationID: "delete" + name,
+			Summary:     "Delete an entry of " + collection + " (DeleteObject)",
+			Responses: map[string]*OpenAPIResponse{
+				"204":     {Description: "The entry was deleted"},
+				"404":     {Ref: "#/components/responses/NotFound"},
+				"default": {Ref: "#/components/responses/Fault"},
+			},
+		}
+	}
+
+	collectionItem := &OpenAPIPathItem{
+		Summary:    strings.TrimSuffix(collection, "."),
+		Parameters: collectionParameters,
+		Get: &OpenAPIOperation{
+			OperationID: "list" + collectionName,
+			Summary:     "List the entries of " + collection,
+			Responses: map[string]*OpenAPIResponse{
+				"200": {
+					Description: "The entries keyed by instance number",
+					Content: openAPIJSON(&JSONSchema{
+						Type:                 "object",
+						PropertyNames:        &JSONSchema{Pattern: "^[1-9][0-9]*$"},
+						AdditionalProperties: ref,
+					}),
+				},
+				"default": {Ref: "#/components/responses/Fault"},
+			},
+		},
+	}
+	if addable {
+		collectionItem.Post = &OpenAPIOperation{
+			OperationID: "add" + name,
+			Summary:     "Add an entry to " + collection + " (AddObject), setting the given parameters",
+			RequestBody: &OpenAPIRequestBody{Content: openAPIJSON(ref)},
+			Responses: map[string]*OpenAPIResponse{
+				"201": {
+					Description: "The entry was added",
+					Headers: map[string]*OpenAPIParameter{
+						"Location": {Description: "Path of the new entry", Schema: &JSONSchema{Type: "string"}},
+					},
+					Content: openAPIJSON(&JSONSchema{Ref: openAPISchemasRef + "AddObjectResponse"}),
+				},
+				"default": {Ref: "#/components/responses/Fault"},
+			},
+		}
+	}
+	doc.Paths[collectionPath] = collectionItem
+}
+
+// openAPIPath converts an object path to a REST path, replacing each {i}
+// with a path parameter named after its table. The parameters are added to
+// the components and referenced.
+func openAPIPath(doc *OpenAPIDocument, objectPath string) (string, []*OpenAPIParameter) {
+	segments := strings.Split(strings.TrimSuffix(objectPath, "."), ".")
+	parameters := []*OpenAPIParameter{}
+	used := make(map[string]bool)
+	for i, segment := range segments {
+		if segment != "{i}" || i == 0 {
+			continue
+		}
+
+		// A table name may repeat within a path, e.g. Device.X.{i}.X.{i}.
+		name := segments[i-1] + "Instance"
+		for n := 2; used[name]; n++ {
+			name = segments[i-1] + "Instance" + strconv.Itoa(n)
+		}
+		used[name] = true
+
+		if _, ok := doc.Components.Parameters[name]; !ok {
+			doc.Components.Parameters[name] = &OpenAPIParameter{
+				Name:        name,
+				In:          "path",
+				Description: "Instance number of a " + segments[i-1] + " entry",
+				Required:    true,
+				Schema:      &JSONSchema{Type: "integer", Minimum: "1"},
+			}
+		}
+		segments[i] = "{" + name + "}"
+		parameters = append(parameters, &OpenAPIParameter{Ref: "#/components/parameters/" + name})
+	}
+	return "/" + strings.Join(segments, "/"), parameters
+}
+
+// openAPIResponses returns the responses shared by the operations
+func openAPIResponses() map[string]*OpenAPIResponse {
+	fault := openAPIJSON(&JSONSchema{Ref: openAPISchemasRef + "Fault"})
+	return map[string]*OpenAPIResponse{
+		"NotFound": {Description: "No such object instance", Content: fault},
+		"Fault":    {Description: "The request failed with a CWMP fault", Content: fault},
+	}
+}
+
+// openAPIJSON returns JSON content with the given schema
+func openAPIJSON(schema *JSONSchema) map[string]OpenAPIMediaType {
+	return map[string]OpenAPIMediaType{"application/json": {Schema: schema}}
+}
+
+// openAPIVersion returns the API version: the model version, else the
+// version in the model name (e.g. 2.1 for Device:2.1)
+func openAPIVersion(model *models.DataModel) string {
+	if model.Version != "" {
+		return model.Version
+	}
+	if i := strings.LastIndex(model.Name, ":"); i >= 0 && i < len(model.Name)-1 {
+		return model.Name[i+1:]
+	}
+	return "1.0"
+}
@@ -0,0 +1,128 @@
+package generator
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Niceblueman/cwmp-codegen/internal/models"
+)
+
+func TestGenerateOpenAPI(t *testing.T) {
+	model := &models.DataModel{
+		Name: "Device:2.1",
+		DataTypes: []models.DataType{
+			{Name: "IPAddress", String: &models.StringType{Size: &models.Size{Max: 45}}},
+		},
+		Objects: []models.Object{
+			{Name: "Device.", Path: "Device.", Access: "readOnly"},
+			{
+				Name:   "Device.ManagementServer.",
+				Path:   "Device.ManagementServer.",
+				Access: "readOnly",
+				Parameters: []models.Parameter{
+					{Name: "URL", Type: "string", Access: "readWrite"},
+				},
+			},
+			{
+				Name:   "Device.Host.{i}.",
+				Path:   "Device.Host.{i}.",
+				Access: "readWrite",
+				Parameters: []models.Parameter{
+					{Name: "IPAddress", Type: "IPAddress", Access: "readOnly"},
+					{Name: "Alias", Type: "string", Access: "readWrite"},
+				},
+			},
+			{
+				Name:   "Device.Host.{i}.IPv4Address.{i}.",
+				Path:   "Device.Host.{i}.IPv4Address.{i}.",
+				Access: "readOnly",
+				Parameters: []models.Parameter{
+					{Name: "Address", Type: "IPAddress", Access: "readOnly"},
+				},
+			},
+		},
+	}
+
+	tmpDir := t.TempDir()
+	files, err := GenerateOpenAPI(model, tmpDir)
+	if err != nil {
+		t.Fatalf("GenerateOpenAPI returned error: %v", err)
+	}
+	if len(files) != 1 || files[0] != "device_2_1.openapi.json" {
+		t.Fatalf("Expected device_2_1.openapi.json, got %v", files)
+	}
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, files[0]))
+	if err != nil {
+		t.Fatalf("Failed to read generated file: %v", err)
+	}
+	var doc OpenAPIDocument
+	if err := json.Unmarshal(content, &doc); err != nil {
+		t.Fatalf("Generated document is not valid JSON: %v", err)
+	}
+
+	if doc.OpenAPI != "3.1.0" || doc.Info.Title != "Device:2.1" || doc.Info.Version != "2.1" {
+		t.Errorf("Unexpected document header: %s %+v", doc.OpenAPI, doc.Info)
+	}
+
+	// Expected operations per path: get, post, patch, delete
+	expected := map[string]string{
+		"/Device":                                 "get",
+		"/Device/ManagementServer":                "get patch",
+		"/Device/Host":                            "get post",
+		"/Device/Host/{HostInstance}":             "get patch delete",
+		"/Device/Host/{HostInstance}/IPv4Address": "get",
+		"/Device/Host/{HostInstance}/IPv4Address/{IPv4AddressInstance}": "get",
+	}
+	if len(doc.Paths) != len(expected) {
+		t.Errorf("Expected %d paths, got %d", len(expected), len(doc.Paths))
+	}
+	for path, operations := range expected {
+		item := doc.Paths[path]
+		if item == nil {
+			t.Errorf("Expected path %s", path)
+			continue
+		}
+		got := ""
+		for _, op := range []struct {
+			name string
+			op   *OpenAPIOperation
+		}{{"get", item.Get}, {"post", item.Post}, {"patch", item.Patch}, {"delete", item.Delete}} {
+			if op.op != nil {
+				if got != "" {
+					got += " "
+				}
+				got += op.name
+			}
+		}
+		if got != operations {
+			t.Errorf("Expected %s to have operations %q, got %q", path, operations, got)
+		}
+	}
+
+	entry := doc.Paths["/Device/Host/{HostInstance}/IPv4Address/{IPv4AddressInstance}"]
+	if len(entry.Parameters) != 2 || entry.Parameters[1].Ref != "#/components/parameters/IPv4AddressInstance" {
+		t.Errorf("Expected references to both instance parameters, got %+v", entry.Parameters)
+	}
+	if param := doc.Components.Parameters["HostInstance"]; param == nil || param.In != "path" || !param.Required {
+		t.Errorf("Expected the HostInstance path parameter component, got %+v", param)
+	}
+
+	host := doc.Paths["/Device/Host/{HostInstance}"]
+	if ref := host.Patch.RequestBody.Content["application/json"].Schema.Ref; ref != "#/components/schemas/DeviceHostInstance" {
+		t.Errorf("Expected PATCH to reuse the DeviceHostInstance schema, got %s", ref)
+	}
+	if add := doc.Paths["/Device/Host"].Post; add.OperationID != "addDeviceHostInstance" || add.Responses["201"] == nil {
+		t.Errorf("Expected POST to add a Host entry, got %+v", add)
+	}
+
+	schema := doc.Components.Schemas["DeviceHostInstance"]
+	if schema == nil || schema.Properties["IPAddress"].Ref != "#/components/schemas/IPAddress" || !schema.Properties["IPAddress"].ReadOnly {
+		t.Errorf("Expected IPAddress to reference the IPAddress component, got %+v", schema)
+	}
+	if doc.Components.Schemas["IPAddress"] == nil || doc.Components.Schemas["Fault"] == nil {
+		t.Errorf("Expected the IPAddress and Fault components")
+	}
+}
@@ -0,0 +1,348 @@
+package generator
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/Niceblueman/cwmp-codegen/internal/models"
+)
+
+// Protocol Buffers schema template
+const protoTemplate = `// Code generated by cwmp-codegen. DO NOT EDIT.
+// Data model {{.ModelName}}
+//
+// Field numbers are persisted in {{.LockFile}}; keep it under version
+// control so that regenerating never renumbers existing fields.
+syntax = "proto3";
+
+package {{.Package}};
+{{- if .GoPackage}}
+
+option go_package = {{printf "%q" .GoPackage}};
+{{- end}}
+{{range .Enums}}
+// Values of {{.Path}}
+enum {{.Name}} {
+{{- range .Values}}
+  {{.Name}} = {{.Number}};
+{{- end}}
+{{- template "reserved" .}}
+}
+{{end}}{{range .Messages}}
+{{.Comment}}message {{.Name}} {
+{{- range .Fields}}
+{{.Comment}}  {{.Label}}{{.Type}} {{.Name}} = {{.Number}};
+{{- end}}
+{{- template "reserved" .}}
+}
+{{end}}
+{{- define "reserved"}}{{if .ReservedNumbers}}
+  reserved {{join .ReservedNumbers ", "}};
+  reserved {{join .ReservedNames ", "}};
+{{- end}}{{end}}`
+
+// ProtoOptions controls the generated Protocol Buffers schema
+type ProtoOptions struct {
+	Package   string // proto package, derived from the model name when empty
+	GoPackage string // go_package option, omitted when empty
+}
+
+// ProtoTemplate contains data for the Protocol Buffers template
+type ProtoTemplate struct {
+	ModelName string
+	LockFile  string
+	Package   string
+	GoPackage string
+	Enums     []ProtoEnum
+	Messages  []ProtoMessage
+}
+
+// ProtoEnum is the enum of an enumerated parameter
+type ProtoEnum struct {
+	Name            string
+	Path            string
+	Values          []ProtoField
+	ReservedNumbers []string
+	ReservedNames   []string
+}
+
+// ProtoMessage represents a message
+type ProtoMessage struct {
+	Name            string
+	Comment         string
+	Fields          []ProtoField
+	ReservedNumbers []string
+	ReservedNames   []string
+}
+
+// ProtoField represents a field of a message or a value of an enum
+type ProtoField struct {
+	Name    string
+	Comment string
+	Label   string // "optional " or "repeated ", if any
+	Type    string
+	Number  int
+}
+
+// ProtoLock persists the field and value numbers of a generated schema
+type ProtoLock struct {
+	Messages map[string]*ProtoLockEntry `json:"messages"`
+	Enums    map[string]*ProtoLockEntry `json:"enums"`
+}
+
+// ProtoLockEntry maps the field or value names of a message or enum to
+// their numbers. Names removed from the model are kept so that their
+// numbers are reserved rather than reused.
+type ProtoLockEntry struct {
+	Numbers map[string]int `json:"numbers"`
+}
+
+// GenerateProto generates a proto3 schema from a data model. Field numbers
+// are read from and written back to a lock file next to the schema.
+func GenerateProto(model *models.DataModel, outputDir string, options ProtoOptions) ([]string, error) {
+	baseName := strings.ToLower(sanitize(model.Name))
+	fileName := baseName + ".proto"
+	lockName := ProtoLockFile(model)
+
+	lock, err := readProtoLock(filepath.Join(outputDir, lockName))
+	if err != nil {
+		return nil, err
+	}
+
+	tmplData := ProtoTemplate{
+		ModelName: model.Name,
+		LockFile:  lockName,
+		Package:   options.Package,
+		GoPackage: options.GoPackage,
+		Enums:     []ProtoEnum{},
+		Messages:  []ProtoMessage{},
+	}
+	if tmplData.Package == "" {
+		tmplData.Package = baseName
+	}
+
+	dataTypes := make(map[string]models.DataType)
+	for _, dataType := range model.DataTypes {
+		dataTypes[dataType.Name] = dataType
+	}
+
+	// Convert each object to a message
+	tree := linkGoObjectTree(model)
+	for _, obj := range model.Objects {
+		message, enums := convertObjectToProtoMessage(obj, tree[objectPathPrefix(obj)], dataTypes)
+		assignProtoNumbers(lock.Messages, message.Name, message.Fields, &message.ReservedNumbers, &message.ReservedNames)
+		for i := range enums {
+			assignProtoNumbers(lock.Enums, enums[i].Name, enums[i].Values[1:], &enums[i].ReservedNumbers, &enums[i].ReservedNames)
+		}
+		tmplData.Messages = append(tmplData.Messages, message)
+		tmplData.Enums = append(tmplData.Enums, enums...)
+	}
+
+	tmpl, err := template.New("proto").Funcs(template.FuncMap{"join": strings.Join}).Parse(protoTemplate)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeTemplate(tmpl, filepath.Join(outputDir, fileName), tmplData); err != nil {
+		return nil, err
+	}
+	if err := writeJSON(filepath.Join(outputDir, lockName), lock); err != nil {
+		return nil, err
+	}
+
+	return []string{fileName, lockName}, nil
+}
+
+// ProtoLockFile returns the name of the field number lock file GenerateProto
+// reads from and writes to the output directory
+func ProtoLockFile(model *models.DataModel) string {
+	return strings.ToLower(sanitize(model.Name)) + ".proto.lock"
+}
+
+// readProtoLock reads a lock file, returning an empty lock if it does not exist
+func readProtoLock(path string) (*ProtoLock, error) {
+	lock := &ProtoLock{
+		Messages: make(map[string]*ProtoLockEntry),
+		Enums:    make(map[string]*ProtoLockEntry),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return lock, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, lock); err != nil {
+		return nil, err
+	}
+	if lock.Messages == nil {
+		lock.Messages = make(map[string]*ProtoLockEntry)
+	}
+	if lock.Enums == nil {
+		lock.Enums = make(map[string]*ProtoLockEntry)
+	}
+	return lock, nil
+}
+
+// assignProtoNumbers assigns the locked numbers to fields, allocating new
+// numbers after the highest number ever used, and lists the locked names
+// that are gone
+func assignProtoNumbers(entries map[string]*ProtoLockEntry, name string, fields []ProtoField, reservedNumbers, reservedNames *[]string) {
+	entry, ok := entries[name]
+	if !ok || entry.Numbers == nil {
+		entry = &ProtoLockEntry{Numbers: make(map[string]int)}
+		entries[name] = entry
+	}
+
+	next := 1
+	for _, number := range entry.Numbers {
+		if number >= next {
+			next = number + 1
+		}
+	}
+
+	present := make(map[string]bool)
+	for i := range fields {
+		present[fields[i].Name] = true
+		number, ok := entry.Numbers[fields[i].Name]
+		if !ok {
+			// 19000 to 19999 are reserved for the protobuf implementation
+			if next >= 19000 && next <= 19999 {
+				next = 20000
+			}
+			number = next
+			next++
+			entry.Numbers[fields[i].Name] = number
+		}
+		fields[i].Number = number
+	}
+
+	removed := []string{}
+	for fieldName := range entry.Numbers {
+		if !present[fieldName] {
+			removed = append(removed, fieldName)
+		}
+	}
+	sort.Slice(removed, func(i, j int) bool {
+		return entry.Numbers[removed[i]] < entry.Numbers[removed[j]]
+	})
+	for _, fieldName := range removed {
+		*reservedNumbers = append(*reservedNumbers, strconv.Itoa(entry.Numbers[fieldName]))
+		*reservedNames = append(*reservedNames, strconv.Quote(fieldName))
+	}
+}
+
+// convertObjectToProtoMessage converts a CWMP object to a message and the
+// enums of its enumerated parameters, leaving the field numbers unassigned
+func convertObjectToProtoMessage(obj models.Object, children []GoChildObject, dataTypes map[string]models.DataType) (ProtoMessage, []ProtoEnum) {
+	message := ProtoMessage{
+		Name:    upperCamelCase(sanitize(obj.Name)),
+		Comment: protoComment(obj.Description, ""),
+		Fields:  []ProtoField{},
+	}
+	enums := []ProtoEnum{}
+
+	if isTableEntry(obj) {
+		message.Fields = append(message.Fields, ProtoField{
+			Name:    "instance_number",
+			Comment: protoComment(instanceNumberDescription, "  "),
+			Type:    "uint32",
+		})
+	}
+
+	for _, param := range obj.Parameters {
+		value := models.ResolveSyntax(param, dataTypes)
+		field := ProtoField{
+			Name:    snakeCase(param.Name),
+			Comment: protoComment(param.Description, "  "),
+			Label:   "optional ",
+			Type:    mapCWMPTypeToProtoType(value.Type),
+		}
+
+		switch {
+		case param.IsList:
+			field.Label = "repeated "
+		case value.Type == "string" && len(value.Enumeration) > 0:
+			enum := convertEnumerationToProto(message.Name+upperCamelCase(param.Name), objectPathPrefix(obj)+param.Name, value.Enumeration)
+			enums = append(enums, enum)
+			field.Type = enum.Name
+		}
+		message.Fields = append(message.Fields, field)
+	}
+
+	// Child objects become message fields, tables repeated fields
+	for _, child := range children {
+		field := ProtoField{
+			Name: snakeCase(child.Name),
+			Type: upperCamelCase(child.StructName),
+		}
+		if child.IsMultiInstance {
+			field.Label = "repeated "
+		}
+		message.Fields = append(message.Fields, field)
+	}
+
+	return message, enums
+}
+
+// convertEnumerationToProto converts enumeration values to enum values
+// prefixed with the enum name, starting with the zero UNSPECIFIED value
+func convertEnumerationToProto(name, path string, enumeration []models.Enumeration) ProtoEnum {
+	prefix := strings.ToUpper(snakeCase(name)) + "_"
+	enum := ProtoEnum{
+		Name:   name,
+		Path:   path,
+		Values: []ProtoField{{Name: prefix + "UNSPECIFIED"}},
+	}
+
+	used := map[string]bool{"UNSPECIFIED": true}
+	for _, value := range enumeration {
+		suffix := upperSnakeCase(value.Value)
+		for n := 2; used[suffix]; n++ {
+			suffix = upperSnakeCase(value.Value) + "_" + strconv.Itoa(n)
+		}
+		used[suffix] = true
+		enum.Values = append(enum.Values, ProtoField{Name: prefix + suffix})
+	}
+	return enum
+}
+
+// mapCWMPTypeToProtoType maps CWMP types to proto3 scalar types
+func mapCWMPTypeToProtoType(cwmpType string) string {
+	switch strings.ToLower(cwmpType) {
+	case "unsignedint", "unsignedinteger":
+		return "uint32"
+	case "int", "integer":
+		return "int32"
+	case "long":
+		return "int64"
+	case "unsignedlong":
+		return "uint64"
+	case "boolean", "bool":
+		return "bool"
+	case "base64", "hexbinary":
+		return "bytes"
+	default:
+		// Strings, and ISO 8601 dateTimes which may lack a time zone
+		return "string"
+	}
+}
+
+// protoComment formats a description as // comment lines
+func protoComment(description, indent string) string {
+	var b strings.Builder
+	for _, line := range strings.Split(strings.TrimSpace(description), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		b.WriteString(indent + "// " + line + "\n")
+	}
+	return b.String()
+}
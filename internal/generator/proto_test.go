@@ -0,0 +1,111 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Niceblueman/cwmp-codegen/internal/models"
+)
+
+func TestGenerateProto(t *testing.T) {
+	model := &models.DataModel{
+		Name: "Device:2.1",
+		DataTypes: []models.DataType{
+			{Name: "IPAddress", String: &models.StringType{}},
+		},
+		Objects: []models.Object{
+			{Name: "Device.", Path: "Device.", Description: "The root object"},
+			{
+				Name: "Device.Host.{i}.",
+				Path: "Device.Host.{i}.",
+				Parameters: []models.Parameter{
+					{Name: "IPAddress", Type: "IPAddress", Description: "Address of the host"},
+					{Name: "DNSServers", Type: "list", IsList: true, ItemType: "IPAddress"},
+					{Name: "Status", Type: "string", Syntax: models.Syntax{String: &models.StringCons{
+						Enumeration: []models.Enumeration{{Value: "Up"}, {Value: "Down"}},
+					}}},
+					{Name: "LeaseTime", Type: "unsignedInt"},
+					{Name: "Key", Type: "base64"},
+				},
+			},
+		},
+	}
+
+	tmpDir := t.TempDir()
+	files, err := GenerateProto(model, tmpDir, ProtoOptions{GoPackage: "example.com/telemetry"})
+	if err != nil {
+		t.Fatalf("GenerateProto returned error: %v", err)
+	}
+	if len(files) != 2 || files[0] != "device_2_1.proto" || files[1] != "device_2_1.proto.lock" {
+		t.Fatalf("Expected device_2_1.proto and its lock file, got %v", files)
+	}
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, files[0]))
+	if err != nil {
+		t.Fatalf("Failed to read generated file: %v", err)
+	}
+
+	for _, expected := range []string{
+		`syntax = "proto3";`,
+		"package device_2_1;",
+		`option go_package = "example.com/telemetry";`,
+		"enum DeviceHostInstanceStatus {\n  DEVICE_HOST_INSTANCE_STATUS_UNSPECIFIED = 0;\n  DEVICE_HOST_INSTANCE_STATUS_UP = 1;\n  DEVICE_HOST_INSTANCE_STATUS_DOWN = 2;\n}",
+		"// The root object\nmessage Device {\n  repeated DeviceHostInstance host = 1;\n}",
+		"uint32 instance_number = 1;",
+		"  // Address of the host\n  optional string ip_address = 2;",
+		"repeated string dns_servers = 3;",
+		"optional DeviceHostInstanceStatus status = 4;",
+		"optional uint32 lease_time = 5;",
+		"optional bytes key = 6;",
+	} {
+		if !strings.Contains(string(content), expected) {
+			t.Errorf("Expected generated schema to contain %q", expected)
+		}
+	}
+
+	// Regenerate after removing a parameter and an enumeration value and
+	// adding new ones: existing numbers stay, removed ones are reserved
+	host := &model.Objects[1]
+	host.Parameters = append(host.Parameters[:3], models.Parameter{Name: "Key", Type: "base64"}, models.Parameter{Name: "HostName", Type: "string"})
+	host.Parameters[2].Syntax.String.Enumeration = []models.Enumeration{{Value: "Down"}, {Value: "Error"}}
+
+	if _, err := GenerateProto(model, tmpDir, ProtoOptions{}); err != nil {
+		t.Fatalf("GenerateProto returned error on regeneration: %v", err)
+	}
+	content, err = os.ReadFile(filepath.Join(tmpDir, files[0]))
+	if err != nil {
+		t.Fatalf("Failed to read regenerated file: %v", err)
+	}
+
+	for _, expected := range []string{
+		"DEVICE_HOST_INSTANCE_STATUS_DOWN = 2;\n  DEVICE_HOST_INSTANCE_STATUS_ERROR = 3;\n  reserved 1;\n  reserved \"DEVICE_HOST_INSTANCE_STATUS_UP\";",
+		"optional DeviceHostInstanceStatus status = 4;",
+		"optional bytes key = 6;",
+		"optional string host_name = 7;\n  reserved 5;\n  reserved \"lease_time\";",
+	} {
+		if !strings.Contains(string(content), expected) {
+			t.Errorf("Expected regenerated schema to contain %q", expected)
+		}
+	}
+	if strings.Contains(string(content), "go_package") {
+		t.Errorf("Expected no go_package option without GoPackage")
+	}
+}
+
+func TestAssignProtoNumbersSkipsImplementationRange(t *testing.T) {
+	entries := map[string]*ProtoLockEntry{
+		"Device": {Numbers: map[string]int{"last": 18999}},
+	}
+	fields := []ProtoField{{Name: "last"}, {Name: "next"}}
+	var reservedNumbers, reservedNames []string
+	assignProtoNumbers(entries, "Device", fields, &reservedNumbers, &reservedNames)
+
+	if fields[0].Number != 18999 || fields[1].Number != 20000 {
+		t.Errorf("Expected numbers 18999 and 20000, got %d and %d", fields[0].Number, fields[1].Number)
+	}
+	if len(reservedNumbers) != 0 || len(reservedNames) != 0 {
+		t.Errorf("Expected nothing reserved, got %v %v", reservedNumbers, reservedNames)
+	}
+}
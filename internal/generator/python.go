@@ -0,0 +1,434 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/Niceblueman/cwmp-codegen/internal/models"
+)
+
+// Python package __init__ template
+const pyInitTemplate = `# Code generated by cwmp-codegen. DO NOT EDIT.
+"""Data model {{.ModelName}}"""
+from . import paths
+from ._validation import DataModelObject, ValidationError
+from .models import *  # noqa: F401,F403
+`
+
+// Python models template
+const pyModelsTemplate = `# Code generated by cwmp-codegen. DO NOT EDIT.
+"""Classes of data model {{.ModelName}}"""
+from __future__ import annotations
+
+import dataclasses
+import datetime
+import enum
+from typing import ClassVar, List, NewType, Optional
+
+from ._validation import (
+    DataModelObject,
+    boolean_validator,
+    bytes_validator,
+    datetime_validator,
+    enum_validator,
+    integer_validator,
+    list_validator,
+    string_validator,
+)
+
+__all__ = [
+{{- range .DataTypes}}
+    "{{.Name}}",
+{{- end}}
+{{- range .Enums}}
+    "{{.Name}}",
+{{- end}}
+{{- range .Classes}}
+    "{{.Name}}",
+{{- end}}
+]
+{{range .DataTypes}}
+{{.Name}} = NewType("{{.Name}}", {{.BaseType}})
+{{- .Doc}}
+{{end}}{{range .Enums}}
+
+class {{.Name}}(str, enum.Enum):
+    """Values of {{.Path}}"""
+{{range .Members}}
+    {{.Name}} = {{.Literal}}
+{{- end}}
+{{end}}{{range .Classes}}
+
+@dataclasses.dataclass
+class {{.Name}}(DataModelObject):
+{{- .Doc}}
+
+    OBJECT_PATH: ClassVar[str] = {{.PathLiteral}}
+{{range .Fields}}
+    {{.Name}}: {{.Type}} = {{.Default}}
+{{- .Doc}}
+{{- end}}
+{{end}}`
+
+// Python path constants template
+const pyPathsTemplate = `# Code generated by cwmp-codegen. DO NOT EDIT.
+"""Paths of the objects and parameters of data model {{.ModelName}}, with {i}
+in place of instance numbers"""
+{{range .Paths}}
+{{.Name}} = {{.Literal}}
+{{- end}}
+`
+
+// PythonTemplate contains data for the Python templates
+type PythonTemplate struct {
+	ModelName string
+	DataTypes []PyNewType
+	Enums     []PyEnum
+	Classes   []PyClass
+	Paths     []PathConstant
+}
+
+// PyNewType is the NewType of a named dataType
+type PyNewType struct {
+	Name     string
+	Doc      string
+	BaseType string
+}
+
+// PyEnum is the enum.Enum class of an enumerated parameter
+type PyEnum struct {
+	Name    string
+	Path    string
+	Members []PyEnumMember
+}
+
+// PyEnumMember is a member of a PyEnum
+type PyEnumMember struct {
+	Name    string
+	Literal string
+}
+
+// PyClass represents a dataclass
+type PyClass struct {
+	Name        string
+	Doc         string
+	PathLiteral string
+	Fields      []PyField
+}
+
+// PyField represents a field of a dataclass
+type PyField struct {
+	Name    string
+	Doc     string
+	Type    string
+	Default string
+}
+
+// GeneratePython generates an importable Python package from a data model
+func GeneratePython(model *models.DataModel, outputDir string) ([]string, error) {
+	packageName := strings.ToLower(sanitize(model.Name))
+	packageDir := filepath.Join(outputDir, packageName)
+	if err := os.MkdirAll(packageDir, 0755); err != nil {
+		return nil, err
+	}
+
+	tmplData := PythonTemplate{
+		ModelName: model.Name,
+		DataTypes: []PyNewType{},
+		Enums:     []PyEnum{},
+		Classes:   []PyClass{},
+		Paths:     []PathConstant{},
+	}
+
+	// Convert each named dataType to a NewType
+	dataTypes := make(map[string]models.DataType)
+	for _, dataType := range model.DataTypes {
+		dataTypes[dataType.Name] = dataType
+	}
+	for _, dataType := range model.DataTypes {
+		tmplData.DataTypes = append(tmplData.DataTypes, PyNewType{
+			Name:     upperCamelCase(dataType.Name),
+			Doc:      pyDocstring(dataType.Description, ""),
+			BaseType: mapCWMPTypeToPythonType(models.ResolveSyntax(models.Parameter{Type: dataType.Name}, dataTypes).Type),
+		})
+	}
+
+	// Convert each object to a dataclass
+	tree := linkGoObjectTree(model)
+	for _, obj := range model.Objects {
+		pyClass, enums := convertObjectToPyClass(obj, dataTypes)
+		addPyChildFields(&pyClass, tree[objectPathPrefix(obj)])
+		tmplData.Classes = append(tmplData.Classes, pyClass)
+		tmplData.Enums = append(tmplData.Enums, enums...)
+		tmplData.Paths = append(tmplData.Paths, objectPathConstants(obj)...)
+	}
+
+	files := []struct {
+		name, text string
+	}{
+		{"__init__.py", pyInitTemplate},
+		{"_validation.py", pyRuntimeTemplate},
+		{"models.py", pyModelsTemplate},
+		{"paths.py", pyPathsTemplate},
+	}
+
+	outputFiles := []string{}
+	for _, f := range files {
+		tmpl, err := template.New(f.name).Parse(f.text)
+		if err != nil {
+			return outputFiles, err
+		}
+		if err := writeTemplate(tmpl, filepath.Join(packageDir, f.name), tmplData); err != nil {
+			return outputFiles, err
+		}
+		outputFiles = append(outputFiles, filepath.Join(packageName, f.name))
+	}
+
+	return outputFiles, nil
+}
+
+// convertObjectToPyClass converts a CWMP object to a dataclass and the enums
+// of its enumerated parameters
+func convertObjectToPyClass(obj models.Object, dataTypes map[string]models.DataType) (PyClass, []PyEnum) {
+	pyClass := PyClass{
+		Name:        upperCamelCase(sanitize(obj.Name)),
+		Doc:         pyDocstring(obj.Description, "    "),
+		PathLiteral: strconv.Quote(objectPathPrefix(obj)),
+		Fields:      []PyField{},
+	}
+	enums := []PyEnum{}
+
+	if isTableEntry(obj) {
+		pyClass.Fields = append(pyClass.Fields, PyField{
+			Name:    "instance_number",
+			Doc:     pyDocstring(instanceNumberDescription, "    "),
+			Type:    "int",
+			Default: "0",
+		})
+	}
+
+	for _, param := range obj.Parameters {
+		value := models.ResolveSyntax(param, dataTypes)
+		itemType := param.Type
+		if param.IsList {
+			itemType = param.ItemType
+		}
+		pyType := pythonValueType(itemType, dataTypes)
+
+		// Enumerated strings become enum.Enum classes
+		validator := pyScalarValidator(value)
+		if param.Syntax.String != nil && len(param.Syntax.String.Enumeration) > 0 {
+			enum := convertEnumerationToPy(pyClass.Name+upperCamelCase(param.Name), objectPathPrefix(obj)+param.Name, param.Syntax.String.Enumeration)
+			enums = append(enums, enum)
+			pyType = enum.Name
+			validator = "enum_validator(" + enum.Name + ")"
+		}
+
+		if param.IsList {
+			pyType = "List[" + pyType + "]"
+			validator = pyListValidator(validator, param.Syntax.List)
+		}
+
+		pyClass.Fields = append(pyClass.Fields, PyField{
+			Name:    pythonFieldName(param.Name),
+			Doc:     pyDocstring(param.Description, "    "),
+			Type:    "Optional[" + pyType + "]",
+			Default: pyFieldDefault("None", param.Name, "parameter", validator),
+		})
+	}
+
+	return pyClass, enums
+}
+
+// addPyChildFields adds fields for the child objects derived from the object
+// paths. Tables become lists ordered by instance number.
+func addPyChildFields(pyClass *PyClass, children []GoChildObject) {
+	for _, child := range children {
+		childType := upperCamelCase(child.StructName)
+		field := PyField{
+			Name:    pythonFieldName(child.Name),
+			Type:    "Optional[" + childType + "]",
+			Default: pyFieldDefault("None", child.Name, "object", ""),
+		}
+		if child.IsMultiInstance {
+			field.Type = "List[" + childType + "]"
+			field.Default = pyFieldDefault("", child.Name, "table", "")
+		}
+		pyClass.Fields = append(pyClass.Fields, field)
+	}
+}
+
+// pyFieldDefault renders a dataclasses.field call carrying the CWMP name,
+// the kind of member and its validator. An empty default means an empty list.
+func pyFieldDefault(defaultValue, name, kind, validator string) string {
+	args := "default=" + defaultValue
+	if defaultValue == "" {
+		args = "default_factory=list"
+	}
+	metadata := `{"name": ` + strconv.Quote(name) + `, "kind": "` + kind + `"`
+	if validator != "" {
+		metadata += `, "validator": ` + validator
+	}
+	return "dataclasses.field(" + args + ", metadata=" + metadata + "})"
+}
+
+// convertEnumerationToPy converts enumeration values to enum members, keeping
+// the member names unique
+func convertEnumerationToPy(name, path string, enumeration []models.Enumeration) PyEnum {
+	enum := PyEnum{Name: name, Path: path}
+	used := make(map[string]bool)
+	for _, value := range enumeration {
+		member := enumValueName(value.Value)
+		for n := 2; used[member]; n++ {
+			member = enumValueName(value.Value) + "_" + strconv.Itoa(n)
+		}
+		used[member] = true
+		enum.Members = append(enum.Members, PyEnumMember{
+			Name:    member,
+			Literal: strconv.Quote(value.Value),
+		})
+	}
+	return enum
+}
+
+// pyScalarValidator renders the validator of a single value from its facets
+func pyScalarValidator(value models.ValueSyntax) string {
+	switch strings.ToLower(value.Type) {
+	case "boolean", "bool":
+		return "boolean_validator()"
+	case "datetime":
+		return "datetime_validator()"
+	case "base64", "hexbinary":
+		return "bytes_validator(" + pySizeFacets(value.Size) + ")"
+	case "int", "integer", "unsignedint", "unsignedinteger", "long", "unsignedlong":
+		minimum, maximum := integerBounds(value)
+		if minimum == "" {
+			minimum = "None"
+		}
+		if maximum == "" {
+			maximum = "None"
+		}
+		return "integer_validator(minimum=" + minimum + ", maximum=" + maximum + ")"
+	}
+
+	facets := []string{}
+	if sizeFacets := pySizeFacets(value.Size); sizeFacets != "" {
+		facets = append(facets, sizeFacets)
+	}
+	if len(value.Patterns) > 0 {
+		patterns := make([]string, len(value.Patterns))
+		for i, pattern := range value.Patterns {
+			patterns[i] = strconv.Quote(pattern.Value)
+		}
+		facets = append(facets, "patterns=("+strings.Join(patterns, ", ")+",)")
+	}
+	if len(value.Enumeration) > 0 {
+		values := make([]string, len(value.Enumeration))
+		for i, enum := range value.Enumeration {
+			values[i] = strconv.Quote(enum.Value)
+		}
+		facets = append(facets, "values=("+strings.Join(values, ", ")+",)")
+	}
+	return "string_validator(" + strings.Join(facets, ", ") + ")"
+}
+
+// pyListValidator wraps an item validator with the item count facets of a list
+func pyListValidator(item string, list *models.List) string {
+	args := []string{item}
+	if list != nil {
+		if n, err := strconv.Atoi(list.MinItems); err == nil && n > 0 {
+			args = append(args, "min_items="+list.MinItems)
+		}
+		if n, err := strconv.Atoi(list.MaxItems); err == nil && n > 0 {
+			args = append(args, "max_items="+list.MaxItems)
+		}
+	}
+	return "list_validator(" + strings.Join(args, ", ") + ")"
+}
+
+// pySizeFacets renders the keyword arguments of a size facet
+func pySizeFacets(size *models.Size) string {
+	if size == nil {
+		return ""
+	}
+	facets := []string{}
+	if size.Min > 0 {
+		facets = append(facets, "min_length="+strconv.Itoa(size.Min))
+	}
+	if size.Max > 0 {
+		facets = append(facets, "max_length="+strconv.Itoa(size.Max))
+	}
+	return strings.Join(facets, ", ")
+}
+
+// pythonValueType maps a CWMP type to a Python type annotation. Named
+// dataTypes map to their NewType.
+func pythonValueType(cwmpType string, dataTypes map[string]models.DataType) string {
+	if _, ok := dataTypes[cwmpType]; ok {
+		return upperCamelCase(cwmpType)
+	}
+	return mapCWMPTypeToPythonType(cwmpType)
+}
+
+// mapCWMPTypeToPythonType maps CWMP types to Python types
+func mapCWMPTypeToPythonType(cwmpType string) string {
+	switch strings.ToLower(cwmpType) {
+	case "int", "integer", "unsignedint", "unsignedinteger", "long", "unsignedlong":
+		return "int"
+	case "boolean", "bool":
+		return "bool"
+	case "datetime":
+		return "datetime.datetime"
+	case "base64", "hexbinary":
+		return "bytes"
+	default:
+		return "str"
+	}
+}
+
+// pythonFieldName converts a CWMP name to a snake_case attribute name that
+// does not clash with Python keywords or DataModelObject methods
+func pythonFieldName(name string) string {
+	field := snakeCase(name)
+	if pythonReservedNames[field] {
+		return field + "_"
+	}
+	return field
+}
+
+// pythonReservedNames lists the keywords and inherited names fields must avoid
+var pythonReservedNames = map[string]bool{
+	"and": true, "as": true, "assert": true, "async": true, "await": true,
+	"break": true, "class": true, "continue": true, "def": true, "del": true,
+	"elif": true, "else": true, "except": true, "finally": true, "for": true,
+	"from": true, "global": true, "if": true, "import": true, "in": true,
+	"is": true, "lambda": true, "nonlocal": true, "not": true, "or": true,
+	"pass": true, "raise": true, "return": true, "try": true, "while": true,
+	"with": true, "yield": true, "validate": true, "check": true,
+	"instance_number": true,
+}
+
+// pyDocstring formats a description as a docstring on the lines following a
+// definition, or returns "" for an empty description
+func pyDocstring(description, indent string) string {
+	lines := []string{}
+	for _, line := range strings.Split(strings.TrimSpace(description), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+
+	text := strings.Join(lines, "\n"+indent)
+	text = strings.ReplaceAll(text, `\`, `\\`)
+	text = strings.ReplaceAll(text, `"""`, `\"\"\"`)
+	if strings.HasSuffix(text, `"`) {
+		text += " "
+	}
+	return "\n" + indent + `"""` + text + `"""`
+}
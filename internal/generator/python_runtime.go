@@ -0,0 +1,172 @@
+package generator
+
+// Python validation runtime shared by the generated data model classes
+const pyRuntimeTemplate = `# Code generated by cwmp-codegen. DO NOT EDIT.
+"""Runtime validation of the facets declared by the data model."""
+from __future__ import annotations
+
+import dataclasses
+import datetime
+import enum
+import re
+from typing import Any, Callable, List, Optional, Sequence, Type
+
+Validator = Callable[[List[str], str, Any], None]
+
+
+class ValidationError(ValueError):
+    """Raised when an object violates the facets of the data model."""
+
+    def __init__(self, issues: List[str]) -> None:
+        super().__init__("; ".join(issues))
+        self.issues = issues
+
+
+class DataModelObject:
+    """Base class of the generated data model classes."""
+
+    OBJECT_PATH = ""
+
+    def validate(self, prefix: Optional[str] = None) -> List[str]:
+        """Returns the facet violations of this object and its children.
+
+        prefix is the path of this object, with instance numbers filled in;
+        it defaults to the object path of the class.
+        """
+        issues: List[str] = []
+        _validate_object(issues, self.OBJECT_PATH if prefix is None else prefix, self)
+        return issues
+
+    def check(self, prefix: Optional[str] = None) -> None:
+        """Raises ValidationError if the object violates the data model."""
+        issues = self.validate(prefix)
+        if issues:
+            raise ValidationError(issues)
+
+
+def _validate_object(issues: List[str], prefix: str, obj: Any) -> None:
+    for field in dataclasses.fields(obj):
+        value = getattr(obj, field.name)
+        kind = field.metadata.get("kind")
+        if value is None or kind is None:
+            continue
+        name = prefix + field.metadata["name"]
+        if kind == "parameter":
+            field.metadata["validator"](issues, name, value)
+        elif kind == "object":
+            _validate_object(issues, name + ".", value)
+        elif kind == "table":
+            for entry in value:
+                _validate_object(issues, "%s.%d." % (name, entry.instance_number), entry)
+
+
+def string_validator(
+    min_length: Optional[int] = None,
+    max_length: Optional[int] = None,
+    patterns: Sequence[str] = (),
+    values: Sequence[str] = (),
+) -> Validator:
+    """Checks a string against its size, pattern and enumeration facets."""
+    compiled = []
+    for pattern in patterns:
+        try:
+            compiled.append(re.compile(pattern))
+        except re.error:
+            # XML Schema patterns Python cannot compile are not checked
+            pass
+
+    def check(issues: List[str], name: str, value: Any) -> None:
+        if not isinstance(value, str):
+            issues.append("%s: expected a string" % name)
+            return
+        if min_length is not None and len(value) < min_length:
+            issues.append("%s: shorter than %d characters" % (name, min_length))
+        if max_length is not None and len(value) > max_length:
+            issues.append("%s: longer than %d characters" % (name, max_length))
+        if compiled and not any(pattern.fullmatch(value) for pattern in compiled):
+            issues.append("%s: does not match the required pattern" % name)
+        if values and value not in values:
+            issues.append("%s: must be one of %s" % (name, ", ".join(values)))
+
+    return check
+
+
+def integer_validator(minimum: Optional[int] = None, maximum: Optional[int] = None) -> Validator:
+    """Checks an integer against its range facet."""
+
+    def check(issues: List[str], name: str, value: Any) -> None:
+        if not isinstance(value, int) or isinstance(value, bool):
+            issues.append("%s: expected an integer" % name)
+            return
+        if minimum is not None and value < minimum:
+            issues.append("%s: less than %d" % (name, minimum))
+        if maximum is not None and value > maximum:
+            issues.append("%s: greater than %d" % (name, maximum))
+
+    return check
+
+
+def boolean_validator() -> Validator:
+    """Checks that a value is a boolean."""
+
+    def check(issues: List[str], name: str, value: Any) -> None:
+        if not isinstance(value, bool):
+            issues.append("%s: expected a boolean" % name)
+
+    return check
+
+
+def datetime_validator() -> Validator:
+    """Checks that a value is a datetime."""
+
+    def check(issues: List[str], name: str, value: Any) -> None:
+        if not isinstance(value, datetime.datetime):
+            issues.append("%s: expected a datetime" % name)
+
+    return check
+
+
+def bytes_validator(min_length: Optional[int] = None, max_length: Optional[int] = None) -> Validator:
+    """Checks binary data against its size facet."""
+
+    def check(issues: List[str], name: str, value: Any) -> None:
+        if not isinstance(value, (bytes, bytearray)):
+            issues.append("%s: expected bytes" % name)
+            return
+        if min_length is not None and len(value) < min_length:
+            issues.append("%s: shorter than %d bytes" % (name, min_length))
+        if max_length is not None and len(value) > max_length:
+            issues.append("%s: longer than %d bytes" % (name, max_length))
+
+    return check
+
+
+def enum_validator(enum_type: Type[enum.Enum]) -> Validator:
+    """Checks that a value is a member, or the value of a member, of an enum."""
+
+    def check(issues: List[str], name: str, value: Any) -> None:
+        try:
+            enum_type(value)
+        except ValueError:
+            values = ", ".join(str(member.value) for member in enum_type)
+            issues.append("%s: must be one of %s" % (name, values))
+
+    return check
+
+
+def list_validator(item: Validator, min_items: Optional[int] = None, max_items: Optional[int] = None) -> Validator:
+    """Checks a list against its item count facets and its items."""
+
+    def check(issues: List[str], name: str, value: Any) -> None:
+        if not isinstance(value, (list, tuple)):
+            issues.append("%s: expected a list" % name)
+            return
+        if min_items is not None and len(value) < min_items:
+            issues.append("%s: fewer than %d items" % (name, min_items))
+        if max_items is not None and len(value) > max_items:
+            issues.append("%s: more than %d items" % (name, max_items))
+        for i, entry in enumerate(value):
+            item(issues, "%s[%d]" % (name, i), entry)
+
+    return check
+`
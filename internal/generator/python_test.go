@@ -0,0 +1,118 @@
+package generator
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Niceblueman/cwmp-codegen/internal/models"
+)
+
+func TestGeneratePython(t *testing.T) {
+	model := &models.DataModel{
+		Name: "Device:2.1",
+		DataTypes: []models.DataType{
+			{Name: "IPAddress", Description: "An IP address", String: &models.StringType{}},
+		},
+		Objects: []models.Object{
+			{Name: "Device.", Path: "Device.", Description: `The "root" object`},
+			{
+				Name: "Device.Host.{i}.",
+				Path: "Device.Host.{i}.",
+				Parameters: []models.Parameter{
+					{Name: "IPAddress", Type: "IPAddress", Description: "Address of the host"},
+					{Name: "Alias", Type: "string", Syntax: models.Syntax{String: &models.StringCons{
+						Size:    &models.Size{Max: 4},
+						Pattern: []models.Pattern{{Value: "[a-z]+"}},
+					}}},
+					{Name: "Type", Type: "string", Syntax: models.Syntax{String: &models.StringCons{
+						Enumeration: []models.Enumeration{{Value: "10BASE-T"}, {Value: "UBR+"}},
+					}}},
+					{Name: "LeaseTime", Type: "unsignedInt"},
+					{Name: "Class", Type: "boolean"},
+				},
+			},
+		},
+	}
+
+	tmpDir := t.TempDir()
+	files, err := GeneratePython(model, tmpDir)
+	if err != nil {
+		t.Fatalf("GeneratePython returned error: %v", err)
+	}
+
+	expectedFiles := []string{"__init__.py", "_validation.py", "models.py", "paths.py"}
+	if len(files) != len(expectedFiles) {
+		t.Fatalf("Expected %d files, got %v", len(expectedFiles), files)
+	}
+	for i, name := range expectedFiles {
+		if files[i] != filepath.Join("device_2_1", name) {
+			t.Errorf("Expected %s, got %s", filepath.Join("device_2_1", name), files[i])
+		}
+	}
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, "device_2_1", "models.py"))
+	if err != nil {
+		t.Fatalf("Failed to read generated file: %v", err)
+	}
+
+	for _, expected := range []string{
+		`IPAddress = NewType("IPAddress", str)`,
+		"class DeviceHostInstanceType(str, enum.Enum):",
+		`V_10BASE_T = "10BASE-T"`,
+		`UBR_PLUS = "UBR+"`,
+		"@dataclasses.dataclass\nclass Device(DataModelObject):\n    \"\"\"The \"root\" object\"\"\"",
+		`OBJECT_PATH: ClassVar[str] = "Device.Host.{i}."`,
+		"instance_number: int = 0",
+		`host: List[DeviceHostInstance] = dataclasses.field(default_factory=list, metadata={"name": "Host", "kind": "table"})`,
+		`ip_address: Optional[IPAddress] = dataclasses.field(default=None, metadata={"name": "IPAddress", "kind": "parameter", "validator": string_validator()})`,
+		`"validator": string_validator(max_length=4, patterns=("[a-z]+",))`,
+		`"validator": enum_validator(DeviceHostInstanceType)`,
+		`"validator": integer_validator(minimum=0, maximum=4294967295)`,
+		"class_: Optional[bool]",
+	} {
+		if !strings.Contains(string(content), expected) {
+			t.Errorf("Expected generated code to contain %q", expected)
+		}
+	}
+
+	paths, err := os.ReadFile(filepath.Join(tmpDir, "device_2_1", "paths.py"))
+	if err != nil {
+		t.Fatalf("Failed to read generated file: %v", err)
+	}
+	if !strings.Contains(string(paths), `DEVICE_HOST_INSTANCE_LEASE_TIME = "Device.Host.{i}.LeaseTime"`) {
+		t.Errorf("Expected paths.py to contain the LeaseTime path, got:\n%s", paths)
+	}
+
+	// Import the package and validate an object when Python is available
+	python, err := exec.LookPath("python3")
+	if err != nil {
+		t.Skip("python3 not found")
+	}
+	script := `
+import device_2_1 as m
+host = m.DeviceHostInstance(instance_number=3, alias="toolong", type="UBR+", lease_time=-1)
+device = m.Device(host=[host])
+issues = device.validate()
+assert issues == [
+    "Device.Host.3.Alias: longer than 4 characters",
+    "Device.Host.3.LeaseTime: less than 0",
+], issues
+try:
+    device.check()
+except m.ValidationError as e:
+    assert len(e.issues) == 2
+else:
+    raise AssertionError("check did not raise")
+host.alias, host.lease_time, host.type = "ok", 3600, m.DeviceHostInstanceType.V_10BASE_T
+assert device.validate() == []
+assert m.paths.DEVICE_HOST_INSTANCE_ALIAS == "Device.Host.{i}.Alias"
+`
+	cmd := exec.Command(python, "-c", script)
+	cmd.Dir = tmpDir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("Generated package failed: %v\n%s", err, output)
+	}
+}
@@ -0,0 +1,393 @@
+package generator
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/Niceblueman/cwmp-codegen/internal/models"
+)
+
+// Rust module template. The generated module depends on the serde crate
+// with the derive feature.
+const rustTemplate = `// Code generated by cwmp-codegen. DO NOT EDIT.
+//! Data model {{.ModelName}}
+//!
+//! Requires the serde crate with the derive feature.
+#![allow(dead_code)]
+
+use serde::{Deserialize, Serialize};
+{{range .DataTypes}}
+{{.Doc}}#[derive(Debug, Clone, Default, PartialEq, PartialOrd, Serialize, Deserialize)]
+#[serde(transparent)]
+pub struct {{.Name}}(pub {{.BaseType}});
+
+impl From<{{.BaseType}}> for {{.Name}} {
+    fn from(value: {{.BaseType}}) -> Self {
+        {{.Name}}(value)
+    }
+}
+{{end}}{{range .Enums}}
+/// Values of {{.Path}}
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Hash, Serialize, Deserialize)]
+pub enum {{.Name}} {
+{{- range .Variants}}
+    #[serde(rename = {{.Literal}})]
+    {{.Name}},
+{{- end}}
+}
+
+impl {{.Name}} {
+    /// Returns the string value used on the wire
+    pub const fn as_str(&self) -> &'static str {
+        match self {
+{{- $enum := .Name}}{{range .Variants}}
+            {{$enum}}::{{.Name}} => {{.Literal}},
+{{- end}}
+        }
+    }
+}
+
+impl std::fmt::Display for {{.Name}} {
+    fn fmt(&self, f: &mut std::fmt::Formatter<'_>) -> std::fmt::Result {
+        f.write_str(self.as_str())
+    }
+}
+
+impl std::str::FromStr for {{.Name}} {
+    type Err = String;
+
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        match s {
+{{- range .Variants}}
+            {{.Literal}} => Ok({{$enum}}::{{.Name}}),
+{{- end}}
+            _ => Err(format!("invalid {{.Name}} value {:?}", s)),
+        }
+    }
+}
+{{end}}{{range .Structs}}
+{{.Doc}}#[derive(Debug, Clone, Default, PartialEq, Serialize, Deserialize)]
+pub struct {{.Name}} {
+{{- range .Fields}}
+{{.Doc}}    #[serde({{.Serde}})]
+    pub {{.Name}}: {{.Type}},
+{{- end}}
+}
+{{end}}
+/// Paths of the objects and parameters of the data model, with {i} in place
+/// of instance numbers
+pub mod paths {
+{{- range .Paths}}
+    pub const {{.Name}}: &str = {{.Literal}};
+{{- end}}
+}
+`
+
+// RustTemplate contains data for the Rust template
+type RustTemplate struct {
+	ModelName string
+	DataTypes []RustNewtype
+	Enums     []RustEnum
+	Structs   []RustStruct
+	Paths     []PathConstant
+}
+
+// RustNewtype wraps the base type of a named dataType
+type RustNewtype struct {
+	Name     string
+	Doc      string
+	BaseType string
+}
+
+// RustEnum is the enum of an enumerated parameter
+type RustEnum struct {
+	Name     string
+	Path     string
+	Variants []RustVariant
+}
+
+// RustVariant is a variant of a RustEnum
+type RustVariant struct {
+	Name    string
+	Literal string
+}
+
+// RustStruct represents a Rust struct
+type RustStruct struct {
+	Name   string
+	Doc    string
+	Fields []RustField
+}
+
+// RustField represents a field in a Rust struct
+type RustField struct {
+	Name  string
+	Doc   string
+	Type  string
+	Serde string
+}
+
+// GenerateRust generates a Rust module from a data model
+func GenerateRust(model *models.DataModel, outputDir string) ([]string, error) {
+	fileName := strings.ToLower(sanitize(model.Name)) + ".rs"
+
+	tmplData := RustTemplate{
+		ModelName: model.Name,
+		DataTypes: []RustNewtype{},
+		Enums:     []RustEnum{},
+		Structs:   []RustStruct{},
+		Paths:     []PathConstant{},
+	}
+
+	// Convert each named dataType to a newtype
+	dataTypes := make(map[string]models.DataType)
+	for _, dataType := range model.DataTypes {
+		dataTypes[dataType.Name] = dataType
+	}
+	for _, dataType := range model.DataTypes {
+		tmplData.DataTypes = append(tmplData.DataTypes, RustNewtype{
+			Name:     upperCamelCase(dataType.Name),
+			Doc:      rustDoc(dataType.Description, ""),
+			BaseType: rustDataTypeBase(dataType, dataTypes),
+		})
+	}
+
+	// Convert each object to a struct
+	tree := linkGoObjectTree(model)
+	for _, obj := range model.Objects {
+		rustStruct, enums := convertObjectToRustStruct(obj, dataTypes)
+		addRustChildFields(&rustStruct, tree[objectPathPrefix(obj)])
+		tmplData.Structs = append(tmplData.Structs, rustStruct)
+		tmplData.Enums = append(tmplData.Enums, enums...)
+		tmplData.Paths = append(tmplData.Paths, objectPathConstants(obj)...)
+	}
+
+	tmpl, err := template.New("rust").Parse(rustTemplate)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeTemplate(tmpl, filepath.Join(outputDir, fileName), tmplData); err != nil {
+		return nil, err
+	}
+
+	return []string{fileName}, nil
+}
+
+// convertObjectToRustStruct converts a CWMP object to a Rust struct and the
+// enums of its enumerated parameters
+func convertObjectToRustStruct(obj models.Object, dataTypes map[string]models.DataType) (RustStruct, []RustEnum) {
+	rustStruct := RustStruct{
+		Name:   upperCamelCase(sanitize(obj.Name)),
+		Doc:    rustDoc(obj.Description, ""),
+		Fields: []RustField{},
+	}
+	enums := []RustEnum{}
+
+	if isTableEntry(obj) {
+		rustStruct.Fields = append(rustStruct.Fields, RustField{
+			Name:  "instance_number",
+			Doc:   rustDoc(instanceNumberDescription, "    "),
+			Type:  "u32",
+			Serde: `rename = "InstanceNumber", default`,
+		})
+	}
+
+	for _, param := range obj.Parameters {
+		valueType := rustValueType(param.Type, dataTypes)
+
+		// Enumerated strings become enums
+		if param.Syntax.String != nil && len(param.Syntax.String.Enumeration) > 0 {
+			enum := convertEnumerationToRust(rustStruct.Name+upperCamelCase(param.Name), objectPathPrefix(obj)+param.Name, param.Syntax.String.Enumeration)
+			enums = append(enums, enum)
+			valueType = enum.Name
+		} else if param.IsList {
+			valueType = rustValueType(param.ItemType, dataTypes)
+		}
+
+		if param.IsList {
+			valueType = "Vec<" + valueType + ">"
+		}
+
+		rustStruct.Fields = append(rustStruct.Fields, RustField{
+			Name:  rustFieldName(param.Name),
+			Doc:   rustDoc(param.Description, "    "),
+			Type:  "Option<" + valueType + ">",
+			Serde: "rename = " + strconv.Quote(param.Name) + `, default, skip_serializing_if = "Option::is_none"`,
+		})
+	}
+
+	return rustStruct, enums
+}
+
+// addRustChildFields adds fields for the child objects derived from the
+// object paths. Tables become vectors ordered by instance number.
+func addRustChildFields(rustStruct *RustStruct, children []GoChildObject) {
+	for _, child := range children {
+		field := RustField{
+			Name: rustFieldName(child.Name),
+			Type: "Option<" + upperCamelCase(child.StructName) + ">",
+			Serde: "rename = " + strconv.Quote(child.Name) +
+				`, default, skip_serializing_if = "Option::is_none"`,
+		}
+		if child.IsMultiInstance {
+			field.Type = "Vec<" + upperCamelCase(child.StructName) + ">"
+			field.Serde = "rename = " + strconv.Quote(child.Name) +
+				`, default, skip_serializing_if = "Vec::is_empty"`
+		}
+		rustStruct.Fields = append(rustStruct.Fields, field)
+	}
+}
+
+// convertEnumerationToRust converts enumeration values to enum variants,
+// keeping the variant names unique
+func convertEnumerationToRust(name, path string, enumeration []models.Enumeration) RustEnum {
+	enum := RustEnum{Name: name, Path: path}
+	used := make(map[string]bool)
+	for _, value := range enumeration {
+		variant := rustVariantName(value.Value)
+		for n := 2; used[variant]; n++ {
+			variant = rustVariantName(value.Value) + strconv.Itoa(n)
+		}
+		used[variant] = true
+		enum.Variants = append(enum.Variants, RustVariant{
+			Name:    variant,
+			Literal: strconv.Quote(value.Value),
+		})
+	}
+	return enum
+}
+
+// rustValueType maps a CWMP type to a Rust type, using the newtype of named
+// dataTypes
+func rustValueType(cwmpType string, dataTypes map[string]models.DataType) string {
+	if _, ok := dataTypes[cwmpType]; ok {
+		return upperCamelCase(cwmpType)
+	}
+	return mapCWMPTypeToRustType(cwmpType)
+}
+
+// mapCWMPTypeToRustType maps CWMP types to Rust types
+func mapCWMPTypeToRustType(cwmpType string) string {
+	switch strings.ToLower(cwmpType) {
+	case "int", "integer":
+		return "i32"
+	case "unsignedint", "unsignedinteger":
+		return "u32"
+	case "long":
+		return "i64"
+	case "unsignedlong":
+		return "u64"
+	case "boolean", "bool":
+		return "bool"
+	default:
+		// Strings, ISO 8601 dateTimes and encoded binaries
+		return "String"
+	}
+}
+
+// rustDataTypeBase returns the Rust type wrapped by a dataType's newtype
+func rustDataTypeBase(dataType models.DataType, dataTypes map[string]models.DataType) string {
+	for depth := 0; depth < 8; depth++ {
+		switch {
+		case dataType.Boolean != nil:
+			return "bool"
+		case dataType.UnsignedInt != nil:
+			return "u32"
+		case dataType.Int != nil:
+			return "i32"
+		case dataType.Long != nil:
+			return "i64"
+		case dataType.UnsignedLong != nil:
+			return "u64"
+		case dataType.String != nil, dataType.DateTime != nil, dataType.Base64 != nil, dataType.HexBinary != nil:
+			return "String"
+		}
+
+		base, ok := dataTypes[dataType.Base]
+		if !ok {
+			break
+		}
+		dataType = base
+	}
+	return "String"
+}
+
+// rustVariantName converts an enumeration value to an UpperCamelCase variant
+func rustVariantName(value string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range value {
+		switch {
+		case r == '+':
+			b.WriteString("Plus")
+			upper = true
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if r > unicode.MaxASCII {
+				continue
+			}
+			if upper {
+				r = unicode.ToUpper(r)
+			}
+			b.WriteRune(r)
+			upper = false
+		default:
+			upper = true
+		}
+	}
+
+	variant := b.String()
+	if variant == "" {
+		return "Empty"
+	}
+	if unicode.IsDigit(rune(variant[0])) {
+		return "V" + variant
+	}
+	if variant == "Self" {
+		// Self cannot be used as an identifier
+		return "SelfValue"
+	}
+	return variant
+}
+
+// rustFieldName converts a CWMP name to a snake_case field name
+func rustFieldName(name string) string {
+	field := snakeCase(name)
+	switch {
+	case field == "self" || field == "super" || field == "crate":
+		// These keywords cannot be raw identifiers
+		return field + "_"
+	case rustKeywords[field]:
+		return "r#" + field
+	}
+	return field
+}
+
+// rustKeywords lists the Rust keywords that need a raw identifier
+var rustKeywords = map[string]bool{
+	"as": true, "async": true, "await": true, "break": true, "const": true,
+	"continue": true, "dyn": true, "else": true, "enum": true,
+	"extern": true, "false": true, "fn": true, "for": true, "if": true,
+	"impl": true, "in": true, "let": true, "loop": true, "match": true,
+	"mod": true, "move": true, "mut": true, "pub": true, "ref": true,
+	"return": true, "static": true, "struct": true, "trait": true,
+	"true": true, "type": true, "unsafe": true, "use": true, "where": true,
+	"while": true, "abstract": true, "become": true, "box": true, "do": true,
+	"final": true, "macro": true, "override": true, "priv": true, "try": true,
+	"typeof": true, "unsized": true, "virtual": true, "yield": true,
+}
+
+// rustDoc formats a description as /// doc comment lines
+func rustDoc(description, indent string) string {
+	var b strings.Builder
+	for _, line := range strings.Split(strings.TrimSpace(description), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		b.WriteString(indent + "/// " + line + "\n")
+	}
+	return b.String()
+}
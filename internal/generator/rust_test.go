@@ -0,0 +1,92 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Niceblueman/cwmp-codegen/internal/models"
+)
+
+func TestGenerateRust(t *testing.T) {
+	model := &models.DataModel{
+		Name: "Device:2.1",
+		DataTypes: []models.DataType{
+			{Name: "IPAddress", Description: "An IP address", String: &models.StringType{}},
+			{Name: "IPv4Address", Base: "IPAddress"},
+		},
+		Objects: []models.Object{
+			{Name: "Device.", Path: "Device."},
+			{
+				Name: "Device.Host.{i}.",
+				Path: "Device.Host.{i}.",
+				Parameters: []models.Parameter{
+					{Name: "IPAddress", Type: "IPv4Address", Description: "Address of the host"},
+					{Name: "DNSServers", Type: "list", IsList: true, ItemType: "IPAddress"},
+					{Name: "Type", Type: "string", Syntax: models.Syntax{String: &models.StringCons{
+						Enumeration: []models.Enumeration{{Value: "10BASE-T"}, {Value: "UBR+"}, {Value: "Self"}},
+					}}},
+					{Name: "LeaseTime", Type: "int"},
+				},
+			},
+		},
+	}
+
+	tmpDir := t.TempDir()
+	files, err := GenerateRust(model, tmpDir)
+	if err != nil {
+		t.Fatalf("GenerateRust returned error: %v", err)
+	}
+	if len(files) != 1 || files[0] != "device_2_1.rs" {
+		t.Fatalf("Expected device_2_1.rs, got %v", files)
+	}
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, files[0]))
+	if err != nil {
+		t.Fatalf("Failed to read generated file: %v", err)
+	}
+
+	for _, expected := range []string{
+		"use serde::{Deserialize, Serialize};",
+		"/// An IP address\n#[derive(",
+		"pub struct IPAddress(pub String);",
+		"pub struct IPv4Address(pub String);",
+		"pub enum DeviceHostInstanceType {",
+		"#[serde(rename = \"10BASE-T\")]\n    V10BASET,",
+		"UBRPlus,",
+		"SelfValue,",
+		"pub struct Device {",
+		"#[serde(rename = \"Host\", default, skip_serializing_if = \"Vec::is_empty\")]\n    pub host: Vec<DeviceHostInstance>,",
+		"pub instance_number: u32,",
+		"    /// Address of the host\n    #[serde(rename = \"IPAddress\"",
+		"pub ip_address: Option<IPv4Address>,",
+		"pub dns_servers: Option<Vec<IPAddress>>,",
+		"pub r#type: Option<DeviceHostInstanceType>,",
+		"pub lease_time: Option<i32>,",
+		"pub mod paths {",
+		"pub const DEVICE_HOST_INSTANCE_DNS_SERVERS: &str = \"Device.Host.{i}.DNSServers\";",
+	} {
+		if !strings.Contains(string(content), expected) {
+			t.Errorf("Expected generated code to contain %q", expected)
+		}
+	}
+}
+
+func TestRustFieldName(t *testing.T) {
+	tests := map[string]string{
+		"HostNumberOfEntries": "host_number_of_entries",
+		"IPv4Address":         "ipv4_address",
+		"WANIPConnection":     "wanip_connection",
+		"DNSServers":          "dns_servers",
+		"URL":                 "url",
+		"X_ACME_Enable":       "x_acme_enable",
+		"Type":                "r#type",
+		"Self":                "self_",
+	}
+	for name, expected := range tests {
+		if got := rustFieldName(name); got != expected {
+			t.Errorf("rustFieldName(%q) = %s, expected %s", name, got, expected)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package generator
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Niceblueman/cwmp-codegen/internal/models"
+)
+
+// modelDataTypes returns the named dataTypes of a model by name
+func modelDataTypes(model *models.DataModel) map[string]models.DataType {
+	dataTypes := make(map[string]models.DataType)
+	for _, dataType := range model.DataTypes {
+		dataTypes[dataType.Name] = dataType
+	}
+	return dataTypes
+}
+
+// instanceNumberDescription documents the instance number field of table
+// entries
+const instanceNumberDescription = "Instance number of this entry"
+
+// isTableEntry reports whether an object is the entry object of a table.
+// Table entries are addressed by an instance number that is part of their
+// path rather than a parameter, so generated types give them a field of
+// their own to hold it.
+func isTableEntry(obj models.Object) bool {
+	node := models.Node{Path: objectPathPrefix(obj), Object: &obj}
+	return node.IsTable()
+}
+
+// integerPattern matches integer facet values
+var integerPattern = regexp.MustCompile(`^-?[0-9]+$`)
+
+// integerBounds returns the decimal bounds of an integer value, taken from
+// its range facet or else from its type. An empty bound is unbounded.
+func integerBounds(value models.ValueSyntax) (string, string) {
+	minimum, maximum := "", ""
+	switch strings.ToLower(value.Type) {
+	case "int", "integer":
+		minimum, maximum = "-2147483648", "2147483647"
+	case "unsignedint", "unsignedinteger":
+		minimum, maximum = "0", "4294967295"
+	case "long":
+		minimum, maximum = "-9223372036854775808", "9223372036854775807"
+	case "unsignedlong":
+		minimum, maximum = "0", "18446744073709551615"
+	}
+
+	if value.Range != nil {
+		if v := rangeMin(value.Range); integerPattern.MatchString(v) {
+			minimum = v
+		}
+		if v := rangeMax(value.Range); integerPattern.MatchString(v) {
+			maximum = v
+		}
+	}
+	return minimum, maximum
+}
+
+// enumValueName converts an enumeration value to an UPPER_CASE identifier
+// that does not start with a digit
+func enumValueName(value string) string {
+	name := upperSnakeCase(value)
+	if name[0] >= '0' && name[0] <= '9' {
+		return "V_" + name
+	}
+	return name
+}
+
+// upperSnakeCase converts an enumeration value to an UPPER_SNAKE_CASE
+// identifier
+func upperSnakeCase(value string) string {
+	var b strings.Builder
+	underscore := false
+	for _, r := range value {
+		switch {
+		case r >= 'a' && r <= 'z':
+			b.WriteRune(r - 'a' + 'A')
+			underscore = false
+		case (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9'):
+			b.WriteRune(r)
+			underscore = false
+		case r == '+':
+			// Keep e.g. "UBR" and "UBR+" apart
+			if !underscore && b.Len() > 0 {
+				b.WriteByte('_')
+			}
+			b.WriteString("PLUS")
+			underscore = false
+		case !underscore && b.Len() > 0:
+			b.WriteByte('_')
+			underscore = true
+		}
+	}
+
+	name := strings.TrimSuffix(b.String(), "_")
+	if name == "" {
+		return "EMPTY"
+	}
+	return name
+}
+
+// lowerCamelCase converts a CWMP name to a lowerCamelCase identifier
+func lowerCamelCase(name string) string {
+	parts := strings.Split(snakeCase(name), "_")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+// upperCamelCase converts a CWMP name to an UpperCamelCase identifier
+func upperCamelCase(name string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(sanitize(name), "_") {
+		if part == "" {
+			continue
+		}
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
+
+// PathConstant is a named constant holding an object or parameter path
+type PathConstant struct {
+	Name    string // UPPER_SNAKE_CASE name
+	Literal string // Quoted path, valid in Go, Rust and Python
+}
+
+// objectPathConstants returns the path constants of an object and its parameters
+func objectPathConstants(obj models.Object) []PathConstant {
+	path := objectPathPrefix(obj)
+	constName := strings.ToUpper(snakeCase(obj.Name))
+
+	constants := []PathConstant{{Name: constName, Literal: strconv.Quote(path)}}
+	for _, param := range obj.Parameters {
+		constants = append(constants, PathConstant{
+			Name:    constName + "_" + strings.ToUpper(snakeCase(param.Name)),
+			Literal: strconv.Quote(path + param.Name),
+		})
+	}
+	return constants
+}
@@ -9,10 +9,25 @@ import (
 	"github.com/Niceblueman/cwmp-codegen/internal/models"
 )
 
-// TypeScript interface template
+// TypeScript interface template. With Declare set it renders a .d.ts file
+// holding only types, since no JavaScript is shipped for the validators and
+// path builders.
 const tsInterfaceTemplate = `// Code generated by cwmp-codegen. DO NOT EDIT.
+{{range .Imports}}import { {{.Names}} } from "{{.From}}";
+{{end}}{{.Runtime}}
+{{range .DataTypes}}
+/**
+ * {{.Description}}
+ */
+export type {{.Name}} = {{.BaseType}} & { readonly __brand: "{{.Name}}" };
+{{if not $.Declare}}
+export const {{.Name}}Validator = brand<{{.Name}}>({{.Validator}});
+{{end}}{{end}}
+{{range .Interfaces}}{{$interface := .Name}}{{range .Enums}}
+{{if $.Declare}}export type {{.Name}} = {{.Union}};{{else}}export const {{.Name}}Values = [{{.Values}}] as const;
 
-{{range .Interfaces}}
+export type {{.Name}} = (typeof {{.Name}}Values)[number];{{end}}
+{{end}}
 /**
  * {{.Description}}
  */
@@ -24,12 +39,27 @@ export interface {{.Name}} {
   {{.Name}}{{.Optional}}: {{.Type}};
 {{end}}
 }
-
+{{if not $.Declare}}
+export const {{.Name}}Validator: Validator<{{.Name}}> = objectValidator<{{.Name}}>({
+{{range .Properties}}  {{.Name}}: {{.Validator}},
+{{end}}});
+{{end}}{{with .PathBuilder}}{{if not $.Declare}}
+/**
+ * Builds the path of a {{$interface}} instance
+ */
+export function {{.Name}}({{.Params}}): string {
+  return ` + "`{{.Template}}`" + `;
+}
+{{end}}{{end}}
 {{end}}
 `
 
 // TypeScriptTemplate contains data for the TypeScript template
 type TypeScriptTemplate struct {
+	Imports    []TSImport
+	Runtime    string
+	Declare    bool
+	DataTypes  []TSDataType
 	Interfaces []TSInterface
 }
 
@@ -38,6 +68,8 @@ type TSInterface struct {
 	Name        string
 	Description string
 	Properties  []TSProperty
+	Enums       []TSEnum
+	PathBuilder *TSPathBuilder
 }
 
 // TSProperty represents a property in a TypeScript interface
@@ -46,6 +78,7 @@ type TSProperty struct {
 	Description string
 	Type        string
 	Optional    string
+	Validator   string
 }
 
 // GenerateTypeScript generates TypeScript code from a data model
@@ -55,12 +88,23 @@ func GenerateTypeScript(model *models.DataModel, outputDir string) ([]string, er
 
 	// Create the template data
 	tmplData := TypeScriptTemplate{
+		Runtime:    tsRuntimeTemplate,
+		DataTypes:  []TSDataType{},
 		Interfaces: []TSInterface{},
 	}
 
+	// Convert each named dataType to a branded type
+	dataTypes := make(map[string]bool)
+	for _, dataType := range model.DataTypes {
+		tmplData.DataTypes = append(tmplData.DataTypes, convertDataTypeToTS(dataType))
+		dataTypes[dataType.Name] = true
+	}
+
 	// Convert each object to a TypeScript interface
+	tree := linkGoObjectTree(model)
 	for _, obj := range model.Objects {
-		tsInterface := convertObjectToTSInterface(obj)
+		tsInterface := convertObjectToTSInterface(obj, dataTypes)
+		addTSChildProperties(&tsInterface, tree[objectPathPrefix(obj)])
 		tmplData.Interfaces = append(tmplData.Interfaces, tsInterface)
 	}
 
@@ -84,13 +128,16 @@ func GenerateTypeScript(model *models.DataModel, outputDir string) ([]string, er
 	return []string{fileName}, nil
 }
 
-// convertObjectToTSInterface converts a CWMP object to a TypeScript interface
-func convertObjectToTSInterface(obj models.Object) TSInterface {
+// convertObjectToTSInterface converts a CWMP object to a TypeScript interface.
+// dataTypes holds the names of the model's named dataTypes.
+func convertObjectToTSInterface(obj models.Object, dataTypes map[string]bool) TSInterface {
 	tsInterface := TSInterface{
 		Name:        toExportedName(sanitize(obj.Name)),
 		Description: obj.Description,
 		Properties:  []TSProperty{},
+		Enums:       []TSEnum{},
 	}
+	tsInterface.PathBuilder = tsPathBuilder(obj, tsInterface.Name)
 
 	// Convert parameters to properties
 	for _, param := range obj.Parameters {
@@ -100,6 +147,28 @@ func convertObjectToTSInterface(obj models.Object) TSInterface {
 			Type:        mapCWMPTypeToTSType(param.Type),
 			Optional:    "?", // Make all properties optional by default
 		}
+
+		// Enumerated strings become string-literal unions
+		enumType := ""
+		if param.Syntax.String != nil && len(param.Syntax.String.Enumeration) > 0 {
+			enumType = tsInterface.Name + "_" + sanitize(param.Name)
+			tsInterface.Enums = append(tsInterface.Enums, TSEnum{
+				Name:   enumType,
+				Values: tsEnumLiterals(param.Syntax.String.Enumeration, ", "),
+				Union:  tsEnumLiterals(param.Syntax.String.Enumeration, " | "),
+			})
+		}
+
+		if param.IsList {
+			tsProperty.Type = mapTSListType(param.ItemType)
+			if namedType := tsNamedType(param.ItemType, enumType, dataTypes); namedType != "" {
+				tsProperty.Type = namedType + "[]"
+			}
+		} else if namedType := tsNamedType(param.Type, enumType, dataTypes); namedType != "" {
+			tsProperty.Type = namedType
+		}
+
+		tsProperty.Validator = tsParameterValidator(param, enumType, dataTypes)
 		tsInterface.Properties = append(tsInterface.Properties, tsProperty)
 	}
 
@@ -110,11 +179,17 @@ func convertObjectToTSInterface(obj models.Object) TSInterface {
 			propType = propType + "[]"
 		}
 
+		validator := "lazyValidator(() => " + toExportedName(sanitize(childObj.Name)) + "Validator)"
+		if childObj.MultiInstance {
+			validator = "listValidator(" + validator + ")"
+		}
+
 		tsProperty := TSProperty{
 			Name:        sanitizeTsPropertyName(childObj.Name),
 			Description: childObj.Description,
 			Type:        propType,
 			Optional:    "?",
+			Validator:   validator,
 		}
 		tsInterface.Properties = append(tsInterface.Properties, tsProperty)
 	}
@@ -127,19 +202,61 @@ func mapCWMPTypeToTSType(cwmpType string) string {
 	switch strings.ToLower(cwmpType) {
 	case "string":
 		return "string"
-	case "int", "integer", "unsignedint", "unsignedinteger":
+	case "int", "integer", "unsignedint", "unsignedinteger", "long", "unsignedlong":
 		return "number"
 	case "boolean", "bool":
 		return "boolean"
 	case "datetime":
 		return "Date"
-	case "base64":
+	case "base64", "hexbinary":
 		return "string"
 	default:
 		return "any"
 	}
 }
 
+// addTSChildProperties adds properties for the child objects derived from
+// the object paths, nesting the interfaces of a flat model
+func addTSChildProperties(tsInterface *TSInterface, children []GoChildObject) {
+	for _, child := range children {
+		propType := child.StructName
+		validator := "lazyValidator(() => " + child.StructName + "Validator)"
+		if child.IsMultiInstance {
+			propType += "[]"
+			validator = "listValidator(" + validator + ")"
+		}
+
+		tsInterface.Properties = append(tsInterface.Properties, TSProperty{
+			Name:      sanitizeTsPropertyName(child.Name),
+			Type:      propType,
+			Optional:  "?",
+			Validator: validator,
+		})
+	}
+}
+
+// tsNamedType returns the enum or branded type name for a value, or "" when
+// the value has a plain TypeScript type
+func tsNamedType(cwmpType, enumType string, dataTypes map[string]bool) string {
+	if enumType != "" {
+		return enumType
+	}
+	if dataTypes[cwmpType] {
+		return toExportedName(sanitize(cwmpType))
+	}
+	return ""
+}
+
+// mapTSListType maps a list item type to a TypeScript array type, of strings
+// for unknown item types
+func mapTSListType(itemType string) string {
+	tsType := mapCWMPTypeToTSType(itemType)
+	if tsType == "any" {
+		tsType = "string"
+	}
+	return tsType + "[]"
+}
+
 // sanitizeTsPropertyName ensures TypeScript property names are valid
 func sanitizeTsPropertyName(name string) string {
 	name = sanitize(name)
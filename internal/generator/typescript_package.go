@@ -0,0 +1,342 @@
+package generator
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/Niceblueman/cwmp-codegen/internal/models"
+)
+
+// Types of tsRuntimeTemplate, used for .d.ts packages. The validator
+// functions are left out as .d.ts packages ship no JavaScript.
+const tsRuntimeDeclarations = `
+/**
+ * A problem found while validating a value
+ */
+export interface ValidationIssue {
+  path: string;
+  message: string;
+}
+
+export type SafeParseResult<T> =
+  | { success: true; data: T }
+  | { success: false; issues: ValidationIssue[] };
+
+/**
+ * A runtime validator enforcing the facets declared by the data model
+ */
+export interface Validator<T> {
+  validate(value: unknown, path?: string): ValidationIssue[];
+  parse(value: unknown): T;
+  safeParse(value: unknown): SafeParseResult<T>;
+}
+
+export interface StringFacets {
+  minLength?: number;
+  maxLength?: number;
+  patterns?: readonly string[];
+  values?: readonly string[];
+}
+
+export interface NumberFacets {
+  min?: number;
+  max?: number;
+}
+
+export interface ListFacets {
+  minItems?: number;
+  maxItems?: number;
+}
+`
+
+// tsRuntimeExports lists the names exported by the runtime module
+var tsRuntimeExports = []string{
+	"ValidationIssue", "SafeParseResult", "Validator", "StringFacets", "NumberFacets", "ListFacets",
+	"stringValidator", "integerValidator", "booleanValidator", "dateTimeValidator", "anyValidator",
+	"listValidator", "objectValidator", "lazyValidator", "brand",
+}
+
+// tsIdentifierPattern matches TypeScript identifiers
+var tsIdentifierPattern = regexp.MustCompile(`[A-Za-z_$][A-Za-z0-9_$]*`)
+
+// tsLiteralPattern matches block comments and string literals, which are
+// ignored when resolving imports
+var tsLiteralPattern = regexp.MustCompile(`(?s)/\*.*?\*/|"(?:[^"\\]|\\.)*"|` + "`[^`]*`")
+
+// TypeScriptOptions configures GenerateTypeScriptPackage
+type TypeScriptOptions struct {
+	PackageName      string // npm package name, derived from the model name when empty
+	DeclarationsOnly bool   // Emit .d.ts declarations only
+}
+
+// TSImport represents an import statement of a generated module
+type TSImport struct {
+	Names string
+	From  string
+}
+
+// tsPackageJSON is the package.json of a generated TypeScript package
+type tsPackageJSON struct {
+	Name    string            `json:"name"`
+	Version string            `json:"version"`
+	Type    string            `json:"type"`
+	Main    string            `json:"main,omitempty"`
+	Types   string            `json:"types"`
+	Scripts map[string]string `json:"scripts,omitempty"`
+}
+
+// tsModule is a generated module of a TypeScript package
+type tsModule struct {
+	Name string
+	Data TypeScriptTemplate
+}
+
+// GenerateTypeScriptPackage generates an ES module package from a data model,
+// with one module per top-level object, a barrel index and a package.json
+func GenerateTypeScriptPackage(model *models.DataModel, outputDir string, options TypeScriptOptions) ([]string, error) {
+	extension := ".ts"
+	runtime := tsRuntimeTemplate
+	if options.DeclarationsOnly {
+		extension = ".d.ts"
+		runtime = tsRuntimeDeclarations
+	}
+
+	modules := []*tsModule{
+		{Name: "runtime", Data: TypeScriptTemplate{Runtime: runtime}},
+		{Name: "datatypes", Data: TypeScriptTemplate{}},
+	}
+
+	// Convert each named dataType to a branded type
+	dataTypes := make(map[string]bool)
+	for _, dataType := range model.DataTypes {
+		modules[1].Data.DataTypes = append(modules[1].Data.DataTypes, convertDataTypeToTS(dataType))
+		dataTypes[dataType.Name] = true
+	}
+
+	// Group the interfaces by the top-level object containing them
+	byName := make(map[string]*tsModule)
+	tree := linkGoObjectTree(model)
+	for _, obj := range model.Objects {
+		tsInterface := convertObjectToTSInterface(obj, dataTypes)
+		addTSChildProperties(&tsInterface, tree[objectPathPrefix(obj)])
+
+		name := tsModuleName(obj)
+		module, ok := byName[name]
+		if !ok {
+			module = &tsModule{Name: name}
+			byName[name] = module
+			modules = append(modules, module)
+		}
+		module.Data.Interfaces = append(module.Data.Interfaces, tsInterface)
+	}
+
+	sort.SliceStable(modules[2:], func(i, j int) bool {
+		return modules[2+i].Name < modules[2+j].Name
+	})
+
+	// Record which module exports each name so imports can be resolved
+	exports := map[string]string{}
+	for _, name := range tsRuntimeExports {
+		exports[name] = "runtime"
+	}
+	for _, module := range modules[1:] {
+		for _, name := range tsModuleExports(module.Data) {
+			exports[name] = module.Name
+		}
+	}
+
+	tmpl, err := template.New("typescript").Parse(tsInterfaceTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	outputFiles := []string{}
+	for _, module := range modules {
+		module.Data.Declare = options.DeclarationsOnly
+		if module.Name != "runtime" {
+			// Render once without imports to find the names the module uses
+			var body bytes.Buffer
+			if err := tmpl.Execute(&body, module.Data); err != nil {
+				return outputFiles, err
+			}
+			module.Data.Imports = resolveTSImports(body.String(), module.Name, exports)
+		}
+
+		fileName := module.Name + extension
+		if err := writeTemplate(tmpl, filepath.Join(outputDir, fileName), module.Data); err != nil {
+			return outputFiles, err
+		}
+		outputFiles = append(outputFiles, fileName)
+	}
+
+	// Generate the barrel index re-exporting every module
+	var index strings.Builder
+	index.WriteString("// Code generated by cwmp-codegen. DO NOT EDIT.\n\n")
+	for _, module := range modules {
+		index.WriteString("export * from \"./" + module.Name + ".js\";\n")
+	}
+	fileName := "index" + extension
+	if err := os.WriteFile(filepath.Join(outputDir, fileName), []byte(index.String()), 0644); err != nil {
+		return outputFiles, err
+	}
+	outputFiles = append(outputFiles, fileName)
+
+	// Generate package.json, plus a tsconfig.json to compile the sources
+	packageFiles, err := writeTSPackageManifest(model, outputDir, options)
+	outputFiles = append(outputFiles, packageFiles...)
+	return outputFiles, err
+}
+
+// tsModuleName returns the module holding an object: the second path segment
+// for objects below the root, or the root segment itself
+func tsModuleName(obj models.Object) string {
+	segments := strings.Split(strings.TrimSuffix(obj.GetPath(), "."), ".")
+	if len(segments) > 1 {
+		return sanitize(segments[1])
+	}
+	return sanitize(segments[0])
+}
+
+// tsModuleExports lists the names a module exports
+func tsModuleExports(data TypeScriptTemplate) []string {
+	names := []string{}
+	for _, dataType := range data.DataTypes {
+		names = append(names, dataType.Name, dataType.Name+"Validator")
+	}
+	for _, tsInterface := range data.Interfaces {
+		names = append(names, tsInterface.Name, tsInterface.Name+"Validator")
+		for _, enum := range tsInterface.Enums {
+			names = append(names, enum.Name, enum.Name+"Values")
+		}
+		if tsInterface.PathBuilder != nil {
+			names = append(names, tsInterface.PathBuilder.Name)
+		}
+	}
+	return names
+}
+
+// resolveTSImports returns the sorted imports needed by a module body
+func resolveTSImports(body, moduleName string, exports map[string]string) []TSImport {
+	body = tsLiteralPattern.ReplaceAllString(body, "")
+
+	needed := make(map[string]map[string]bool)
+	for _, identifier := range tsIdentifierPattern.FindAllString(body, -1) {
+		from, ok := exports[identifier]
+		if !ok || from == moduleName {
+			continue
+		}
+		if needed[from] == nil {
+			needed[from] = make(map[string]bool)
+		}
+		needed[from][identifier] = true
+	}
+
+	modules := make([]string, 0, len(needed))
+	for module := range needed {
+		modules = append(modules, module)
+	}
+	sort.Strings(modules)
+
+	imports := make([]TSImport, 0, len(modules))
+	for _, module := range modules {
+		names := make([]string, 0, len(needed[module]))
+		for name := range needed[module] {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		imports = append(imports, TSImport{Names: strings.Join(names, ", "), From: "./" + module + ".js"})
+	}
+	return imports
+}
+
+// writeTSPackageManifest writes package.json and, for source packages, tsconfig.json
+func writeTSPackageManifest(model *models.DataModel, outputDir string, options TypeScriptOptions) ([]string, error) {
+	name := options.PackageName
+	if name == "" {
+		name = strings.ToLower(strings.ReplaceAll(sanitize(model.Name), "_", "-"))
+	}
+
+	manifest := tsPackageJSON{
+		Name:    name,
+		Version: tsPackageVersion(model),
+		Type:    "module",
+		Types:   "index.d.ts",
+	}
+	if !options.DeclarationsOnly {
+		manifest.Main = "index.js"
+		manifest.Scripts = map[string]string{"build": "tsc"}
+	}
+
+	files := []string{}
+	if err := writeJSON(filepath.Join(outputDir, "package.json"), manifest); err != nil {
+		return files, err
+	}
+	files = append(files, "package.json")
+
+	if options.DeclarationsOnly {
+		return files, nil
+	}
+
+	tsconfig := map[string]interface{}{
+		"compilerOptions": map[string]interface{}{
+			"target":           "ES2020",
+			"module":           "ES2020",
+			"moduleResolution": "node",
+			"declaration":      true,
+			"strict":           true,
+		},
+		"include": []string{"*.ts"},
+	}
+	if err := writeJSON(filepath.Join(outputDir, "tsconfig.json"), tsconfig); err != nil {
+		return files, err
+	}
+	return append(files, "tsconfig.json"), nil
+}
+
+// tsPackageVersion derives a semantic version from the model version or the
+// version suffix of the model name (e.g. InternetGatewayDevice:1.4)
+func tsPackageVersion(model *models.DataModel) string {
+	version := model.Version
+	if version == "" {
+		if i := strings.LastIndex(model.Name, ":"); i >= 0 {
+			version = model.Name[i+1:]
+		}
+	}
+
+	parts := strings.Split(version, ".")
+	for _, part := range parts {
+		if part == "" || strings.Trim(part, "0123456789") != "" {
+			return "0.0.0"
+		}
+	}
+	for len(parts) < 3 {
+		parts = append(parts, "0")
+	}
+	return strings.Join(parts[:3], ".")
+}
+
+// writeTemplate executes a template into a new file
+func writeTemplate(tmpl *template.Template, path string, data interface{}) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return tmpl.Execute(file, data)
+}
+
+// writeJSON writes an indented JSON document
+func writeJSON(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
@@ -0,0 +1,153 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Niceblueman/cwmp-codegen/internal/models"
+)
+
+func packageTestModel() *models.DataModel {
+	return &models.DataModel{
+		Name: "Device:2.1",
+		DataTypes: []models.DataType{
+			{Name: "IPAddress", String: &models.StringType{Size: &models.Size{Max: 45}}},
+		},
+		Objects: []models.Object{
+			{Name: "Device", Path: "Device."},
+			{
+				Name: "Device_Host_Instance",
+				Path: "Device.Host.{i}.",
+				Parameters: []models.Parameter{
+					{Name: "IPAddress", Type: "IPAddress"},
+				},
+			},
+			{
+				Name: "Device_ManagementServer",
+				Path: "Device.ManagementServer.",
+				Parameters: []models.Parameter{
+					{Name: "URL", Type: "string"},
+				},
+			},
+		},
+	}
+}
+
+func TestGenerateTypeScriptPackage(t *testing.T) {
+	tmpDir := t.TempDir()
+	files, err := GenerateTypeScriptPackage(packageTestModel(), tmpDir, TypeScriptOptions{})
+	if err != nil {
+		t.Fatalf("GenerateTypeScriptPackage returned error: %v", err)
+	}
+
+	expected := []string{
+		"runtime.ts", "datatypes.ts", "Device.ts", "Host.ts", "ManagementServer.ts",
+		"index.ts", "package.json", "tsconfig.json",
+	}
+	if strings.Join(files, ",") != strings.Join(expected, ",") {
+		t.Fatalf("Expected files %v, got %v", expected, files)
+	}
+
+	read := func(name string) string {
+		content, err := os.ReadFile(filepath.Join(tmpDir, name))
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", name, err)
+		}
+		return string(content)
+	}
+
+	checks := map[string][]string{
+		"index.ts": {
+			`export * from "./runtime.js";`,
+			`export * from "./Host.js";`,
+		},
+		"Host.ts": {
+			`import { IPAddress, IPAddressValidator } from "./datatypes.js";`,
+			`import { Validator, objectValidator } from "./runtime.js";`,
+			"export interface Device_Host_Instance {",
+		},
+		"Device.ts": {
+			`import { Device_Host_Instance, Device_Host_InstanceValidator } from "./Host.js";`,
+			`import { Device_ManagementServer, Device_ManagementServerValidator } from "./ManagementServer.js";`,
+		},
+		"package.json": {
+			`"name": "device-2-1"`,
+			`"version": "2.1.0"`,
+			`"type": "module"`,
+			`"types": "index.d.ts"`,
+		},
+	}
+	for name, expectedStrings := range checks {
+		content := read(name)
+		for _, expected := range expectedStrings {
+			if !strings.Contains(content, expected) {
+				t.Errorf("Expected %s to contain %q", name, expected)
+			}
+		}
+	}
+
+	if strings.Contains(read("ManagementServer.ts"), "./Device.js") {
+		t.Error("Expected ManagementServer.ts not to import its parent module")
+	}
+}
+
+func TestGenerateTypeScriptPackageDeclarations(t *testing.T) {
+	model := packageTestModel()
+	model.Objects[2].Parameters = append(model.Objects[2].Parameters, models.Parameter{
+		Name: "Status", Type: "string",
+		Syntax: models.Syntax{String: &models.StringCons{Enumeration: []models.Enumeration{{Value: "Up"}, {Value: "Down"}}}},
+	})
+
+	tmpDir := t.TempDir()
+	files, err := GenerateTypeScriptPackage(model, tmpDir, TypeScriptOptions{
+		PackageName:      "@acme/device",
+		DeclarationsOnly: true,
+	})
+	if err != nil {
+		t.Fatalf("GenerateTypeScriptPackage returned error: %v", err)
+	}
+
+	for _, file := range files {
+		if file == "package.json" {
+			continue
+		}
+		if !strings.HasSuffix(file, ".d.ts") {
+			t.Errorf("Expected only declaration files, got %s", file)
+		}
+		// No JavaScript is shipped, so only types may be declared
+		content, err := os.ReadFile(filepath.Join(tmpDir, file))
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", file, err)
+		}
+		for _, value := range []string{"declare const", "declare function", "export const", "export function"} {
+			if strings.Contains(string(content), value) {
+				t.Errorf("Expected %s to declare no runtime values, found %q", file, value)
+			}
+		}
+	}
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, "Host.d.ts"))
+	if err != nil {
+		t.Fatalf("Failed to read Host.d.ts: %v", err)
+	}
+	if !strings.Contains(string(content), "export interface Device_Host_Instance {") {
+		t.Error("Expected the Host interface in Host.d.ts")
+	}
+	content, err = os.ReadFile(filepath.Join(tmpDir, "ManagementServer.d.ts"))
+	if err != nil {
+		t.Fatalf("Failed to read ManagementServer.d.ts: %v", err)
+	}
+	if !strings.Contains(string(content), `= "Up" | "Down";`) {
+		t.Errorf("Expected the enumeration as a union type, got\n%s", content)
+	}
+
+	manifest, err := os.ReadFile(filepath.Join(tmpDir, "package.json"))
+	if err != nil {
+		t.Fatalf("Failed to read package.json: %v", err)
+	}
+	if !strings.Contains(string(manifest), `"name": "@acme/device"`) || strings.Contains(string(manifest), `"main"`) {
+		t.Errorf("Unexpected package.json: %s", manifest)
+	}
+}
@@ -0,0 +1,377 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/Niceblueman/cwmp-codegen/internal/models"
+)
+
+// TypeScript runtime validators shared by the generated validators
+const tsRuntimeTemplate = `
+/**
+ * A problem found while validating a value
+ */
+export interface ValidationIssue {
+  path: string;
+  message: string;
+}
+
+export type SafeParseResult<T> =
+  | { success: true; data: T }
+  | { success: false; issues: ValidationIssue[] };
+
+/**
+ * A runtime validator enforcing the facets declared by the data model
+ */
+export interface Validator<T> {
+  validate(value: unknown, path?: string): ValidationIssue[];
+  parse(value: unknown): T;
+  safeParse(value: unknown): SafeParseResult<T>;
+}
+
+export interface StringFacets {
+  minLength?: number;
+  maxLength?: number;
+  patterns?: readonly string[];
+  values?: readonly string[];
+}
+
+export interface NumberFacets {
+  min?: number;
+  max?: number;
+}
+
+export interface ListFacets {
+  minItems?: number;
+  maxItems?: number;
+}
+
+function makeValidator<T>(check: (value: unknown, path: string) => ValidationIssue[]): Validator<T> {
+  return {
+    validate: (value: unknown, path: string = "") => check(value, path),
+    parse(value: unknown): T {
+      const issues = check(value, "");
+      if (issues.length > 0) {
+        throw new Error(issues.map((issue) => (issue.path || "value") + ": " + issue.message).join("; "));
+      }
+      return value as T;
+    },
+    safeParse(value: unknown): SafeParseResult<T> {
+      const issues = check(value, "");
+      return issues.length > 0 ? { success: false, issues } : { success: true, data: value as T };
+    },
+  };
+}
+
+export function stringValidator<T extends string = string>(facets: StringFacets = {}): Validator<T> {
+  // Data model patterns are XML Schema regular expressions, which are implicitly anchored
+  const patterns = (facets.patterns ?? []).map((pattern) => new RegExp("^(?:" + pattern + ")$"));
+  return makeValidator<T>((value, path) => {
+    if (typeof value !== "string") {
+      return [{ path, message: "expected a string" }];
+    }
+    const issues: ValidationIssue[] = [];
+    if (facets.minLength !== undefined && value.length < facets.minLength) {
+      issues.push({ path, message: "shorter than " + facets.minLength + " characters" });
+    }
+    if (facets.maxLength !== undefined && value.length > facets.maxLength) {
+      issues.push({ path, message: "longer than " + facets.maxLength + " characters" });
+    }
+    if (patterns.length > 0 && !patterns.some((pattern) => pattern.test(value))) {
+      issues.push({ path, message: "does not match the required pattern" });
+    }
+    if (facets.values !== undefined && !facets.values.includes(value)) {
+      issues.push({ path, message: "must be one of " + facets.values.join(", ") });
+    }
+    return issues;
+  });
+}
+
+export function integerValidator(facets: NumberFacets = {}): Validator<number> {
+  return makeValidator<number>((value, path) => {
+    if (typeof value !== "number" || !Number.isInteger(value)) {
+      return [{ path, message: "expected an integer" }];
+    }
+    const issues: ValidationIssue[] = [];
+    if (facets.min !== undefined && value < facets.min) {
+      issues.push({ path, message: "less than " + facets.min });
+    }
+    if (facets.max !== undefined && value > facets.max) {
+      issues.push({ path, message: "greater than " + facets.max });
+    }
+    return issues;
+  });
+}
+
+export function booleanValidator(): Validator<boolean> {
+  return makeValidator<boolean>((value, path) =>
+    typeof value === "boolean" ? [] : [{ path, message: "expected a boolean" }]);
+}
+
+export function dateTimeValidator(): Validator<Date> {
+  return makeValidator<Date>((value, path) =>
+    value instanceof Date && !isNaN(value.getTime()) ? [] : [{ path, message: "expected a valid Date" }]);
+}
+
+export function anyValidator(): Validator<any> {
+  return makeValidator<any>(() => []);
+}
+
+export function listValidator<T>(item: Validator<T>, facets: ListFacets = {}): Validator<T[]> {
+  return makeValidator<T[]>((value, path) => {
+    if (!Array.isArray(value)) {
+      return [{ path, message: "expected an array" }];
+    }
+    const issues: ValidationIssue[] = [];
+    if (facets.minItems !== undefined && value.length < facets.minItems) {
+      issues.push({ path, message: "fewer than " + facets.minItems + " items" });
+    }
+    if (facets.maxItems !== undefined && value.length > facets.maxItems) {
+      issues.push({ path, message: "more than " + facets.maxItems + " items" });
+    }
+    value.forEach((entry, index) => issues.push(...item.validate(entry, path + "[" + index + "]")));
+    return issues;
+  });
+}
+
+export function objectValidator<T>(shape: { [K in keyof T]-?: Validator<unknown> }): Validator<T> {
+  return makeValidator<T>((value, path) => {
+    if (typeof value !== "object" || value === null || Array.isArray(value)) {
+      return [{ path, message: "expected an object" }];
+    }
+    const record = value as Record<string, unknown>;
+    const issues: ValidationIssue[] = [];
+    for (const key of Object.keys(shape) as (keyof T & string)[]) {
+      // Every property of a data model interface is optional
+      if (record[key] !== undefined) {
+        issues.push(...shape[key].validate(record[key], path ? path + "." + key : key));
+      }
+    }
+    return issues;
+  });
+}
+
+export function lazyValidator<T>(get: () => Validator<T>): Validator<T> {
+  return makeValidator<T>((value, path) => get().validate(value, path));
+}
+
+export function brand<T>(validator: Validator<unknown>): Validator<T> {
+  return validator as Validator<T>;
+}
+`
+
+// TSDataType represents a branded TypeScript type for a named dataType
+type TSDataType struct {
+	Name        string
+	Description string
+	BaseType    string
+	Validator   string
+}
+
+// TSEnum represents a string-literal union for an enumerated parameter
+type TSEnum struct {
+	Name   string
+	Values string // Comma-separated quoted literals
+	Union  string // Quoted literals separated by |
+}
+
+// TSPathBuilder represents a function building the path of a multi-instance object
+type TSPathBuilder struct {
+	Name     string
+	Params   string
+	Template string
+}
+
+// convertDataTypeToTS converts a named dataType to a branded TypeScript type
+func convertDataTypeToTS(dataType models.DataType) TSDataType {
+	tsDataType := TSDataType{
+		Name:        toExportedName(sanitize(dataType.Name)),
+		Description: dataType.Description,
+		BaseType:    "string",
+		Validator:   "stringValidator()",
+	}
+
+	switch {
+	case dataType.String != nil:
+		tsDataType.Validator = tsStringValidator(dataType.String.Size, dataType.String.Pattern, nil)
+	case dataType.Boolean != nil:
+		tsDataType.BaseType, tsDataType.Validator = "boolean", "booleanValidator()"
+	case dataType.UnsignedInt != nil:
+		tsDataType.BaseType = "number"
+		tsDataType.Validator = tsIntegerValidator(dataType.UnsignedInt.Range, 0, math.MaxUint32)
+	case dataType.Int != nil:
+		tsDataType.BaseType = "number"
+		tsDataType.Validator = tsIntegerValidator(dataType.Int.Range, math.MinInt32, math.MaxInt32)
+	case dataType.Long != nil:
+		tsDataType.BaseType = "number"
+		tsDataType.Validator = tsIntegerValidator(dataType.Long.Range, math.MinInt64, math.MaxInt64)
+	case dataType.UnsignedLong != nil:
+		tsDataType.BaseType = "number"
+		tsDataType.Validator = tsIntegerValidator(nil, 0, math.MaxInt64)
+	}
+
+	return tsDataType
+}
+
+// tsParameterValidator returns the validator expression for a parameter
+func tsParameterValidator(param models.Parameter, enumType string, dataTypes map[string]bool) string {
+	validator := tsScalarValidator(param, param.Type, enumType, dataTypes)
+	if !param.IsList {
+		return validator
+	}
+
+	validator = tsScalarValidator(param, param.ItemType, enumType, dataTypes)
+	if validator == "anyValidator()" {
+		validator = "stringValidator()"
+	}
+
+	facets := []string{}
+	if list := param.Syntax.List; list != nil {
+		if n, err := strconv.Atoi(list.MinItems); err == nil {
+			facets = append(facets, fmt.Sprintf("minItems: %d", n))
+		}
+		if n, err := strconv.Atoi(list.MaxItems); err == nil {
+			facets = append(facets, fmt.Sprintf("maxItems: %d", n))
+		}
+	}
+	if len(facets) == 0 {
+		return fmt.Sprintf("listValidator(%s)", validator)
+	}
+	return fmt.Sprintf("listValidator(%s, { %s })", validator, strings.Join(facets, ", "))
+}
+
+// tsScalarValidator returns the validator expression for a single value of cwmpType
+func tsScalarValidator(param models.Parameter, cwmpType, enumType string, dataTypes map[string]bool) string {
+	syntax := param.Syntax
+
+	switch strings.ToLower(cwmpType) {
+	case "string":
+		if syntax.String == nil {
+			return "stringValidator()"
+		}
+		validator := tsStringValidator(syntax.String.Size, syntax.String.Pattern, syntax.String.Enumeration)
+		if enumType != "" {
+			validator = strings.Replace(validator, "stringValidator(", "stringValidator<"+enumType+">(", 1)
+		}
+		return validator
+	case "boolean", "bool":
+		return "booleanValidator()"
+	case "datetime":
+		return "dateTimeValidator()"
+	case "unsignedint", "unsignedinteger":
+		var r *models.Range
+		if syntax.UnsignedInt != nil {
+			r = syntax.UnsignedInt.Range
+		}
+		return tsIntegerValidator(r, 0, math.MaxUint32)
+	case "int", "integer":
+		var r *models.Range
+		if syntax.Int != nil {
+			r = syntax.Int.Range
+		}
+		return tsIntegerValidator(r, math.MinInt32, math.MaxInt32)
+	case "long":
+		var r *models.Range
+		if syntax.Long != nil {
+			r = syntax.Long.Range
+		}
+		return tsIntegerValidator(r, math.MinInt64, math.MaxInt64)
+	case "unsignedlong":
+		return tsIntegerValidator(nil, 0, math.MaxInt64)
+	case "base64", "hexbinary":
+		return "stringValidator()"
+	}
+
+	if dataTypes[cwmpType] {
+		return toExportedName(sanitize(cwmpType)) + "Validator"
+	}
+	return "anyValidator()"
+}
+
+// tsStringValidator renders a stringValidator call from string facets
+func tsStringValidator(size *models.Size, patterns []models.Pattern, enumeration []models.Enumeration) string {
+	facets := []string{}
+	if size != nil {
+		if size.Min > 0 {
+			facets = append(facets, fmt.Sprintf("minLength: %d", size.Min))
+		}
+		if size.Max > 0 {
+			facets = append(facets, fmt.Sprintf("maxLength: %d", size.Max))
+		}
+	}
+	if len(patterns) > 0 {
+		values := make([]string, 0, len(patterns))
+		for _, pattern := range patterns {
+			values = append(values, tsStringLiteral(pattern.Value))
+		}
+		facets = append(facets, "patterns: ["+strings.Join(values, ", ")+"]")
+	}
+	if len(enumeration) > 0 {
+		facets = append(facets, "values: ["+tsEnumLiterals(enumeration, ", ")+"]")
+	}
+
+	if len(facets) == 0 {
+		return "stringValidator()"
+	}
+	return "stringValidator({ " + strings.Join(facets, ", ") + " })"
+}
+
+// tsIntegerValidator renders an integerValidator call, falling back to the
+// bounds of the underlying type. Bounds beyond JavaScript's safe integer
+// range are left unchecked.
+func tsIntegerValidator(r *models.Range, min, max int64) string {
+	min, max = rangeBounds(r, min, max)
+
+	facets := []string{}
+	if min >= -(1<<53 - 1) {
+		facets = append(facets, fmt.Sprintf("min: %d", min))
+	}
+	if max <= 1<<53-1 {
+		facets = append(facets, fmt.Sprintf("max: %d", max))
+	}
+	if len(facets) == 0 {
+		return "integerValidator()"
+	}
+	return "integerValidator({ " + strings.Join(facets, ", ") + " })"
+}
+
+// tsEnumLiterals renders enumeration values as quoted string literals
+func tsEnumLiterals(enumeration []models.Enumeration, separator string) string {
+	values := make([]string, 0, len(enumeration))
+	for _, enum := range enumeration {
+		values = append(values, tsStringLiteral(enum.Value))
+	}
+	return strings.Join(values, separator)
+}
+
+// tsStringLiteral quotes a string as a TypeScript string literal
+func tsStringLiteral(s string) string {
+	quoted, _ := json.Marshal(s)
+	return string(quoted)
+}
+
+// tsPathBuilder returns the path builder for an object with {i} placeholders
+func tsPathBuilder(obj models.Object, interfaceName string) *TSPathBuilder {
+	path := obj.GetPath()
+	count := strings.Count(path, "{i}")
+	if count == 0 {
+		return nil
+	}
+
+	params := make([]string, 0, count)
+	template := path
+	for i := 1; i <= count; i++ {
+		params = append(params, fmt.Sprintf("i%d: number", i))
+		template = strings.Replace(template, "{i}", fmt.Sprintf("${i%d}", i), 1)
+	}
+
+	return &TSPathBuilder{
+		Name:     interfaceName + "Path",
+		Params:   strings.Join(params, ", "),
+		Template: template,
+	}
+}
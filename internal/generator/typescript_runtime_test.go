@@ -0,0 +1,50 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/Niceblueman/cwmp-codegen/internal/models"
+)
+
+func TestTSParameterValidator(t *testing.T) {
+	dataTypes := map[string]bool{"IPAddress": true}
+
+	tests := []struct {
+		param    models.Parameter
+		expected string
+	}{
+		{
+			param: models.Parameter{
+				Type: "string",
+				Syntax: models.Syntax{String: &models.StringCons{
+					Size:    &models.Size{Min: 1, Max: 17},
+					Pattern: []models.Pattern{{Value: `[0-9A-F]+`}},
+				}},
+			},
+			expected: `stringValidator({ minLength: 1, maxLength: 17, patterns: ["[0-9A-F]+"] })`,
+		},
+		{
+			param: models.Parameter{
+				Type:     "list",
+				IsList:   true,
+				ItemType: "IPAddress",
+				Syntax:   models.Syntax{List: &models.List{MaxItems: "3"}},
+			},
+			expected: "listValidator(IPAddressValidator, { maxItems: 3 })",
+		},
+		{
+			param:    models.Parameter{Type: "long"},
+			expected: "integerValidator()",
+		},
+		{
+			param:    models.Parameter{Type: "Unknown"},
+			expected: "anyValidator()",
+		},
+	}
+
+	for _, tt := range tests {
+		if validator := tsParameterValidator(tt.param, "", dataTypes); validator != tt.expected {
+			t.Errorf("Expected %q, got %q", tt.expected, validator)
+		}
+	}
+}
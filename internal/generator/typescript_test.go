@@ -76,3 +76,62 @@ func TestGenerateTypeScript(t *testing.T) {
 		t.Error("Generated code doesn't contain expected number property")
 	}
 }
+
+func TestGenerateTypeScriptRuntime(t *testing.T) {
+	model := &models.DataModel{
+		Name: "RuntimeModel",
+		DataTypes: []models.DataType{
+			{Name: "IPAddress", String: &models.StringType{Size: &models.Size{Max: 45}}},
+		},
+		Objects: []models.Object{
+			{
+				Name: "Device.Host.{i}.",
+				Parameters: []models.Parameter{
+					{
+						Name: "Status",
+						Type: "string",
+						Syntax: models.Syntax{String: &models.StringCons{
+							Enumeration: []models.Enumeration{{Value: "Up"}, {Value: "Down"}},
+						}},
+					},
+					{
+						Name:   "Address",
+						Type:   "IPAddress",
+						Syntax: models.Syntax{DataTypeRef: &models.DataTypeRef{Ref: "IPAddress"}},
+					},
+					{
+						Name:   "LeaseTime",
+						Type:   "unsignedInt",
+						Syntax: models.Syntax{UnsignedInt: &models.UnsignedInt{Range: &models.Range{MaxInclusive: "86400"}}},
+					},
+				},
+			},
+		},
+	}
+
+	tmpDir := t.TempDir()
+	files, err := GenerateTypeScript(model, tmpDir)
+	if err != nil {
+		t.Fatalf("GenerateTypeScript returned error: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, files[0]))
+	if err != nil {
+		t.Fatalf("Failed to read generated file: %v", err)
+	}
+
+	contentStr := string(content)
+	for _, expected := range []string{
+		`export type IPAddress = string & { readonly __brand: "IPAddress" };`,
+		`export const Device_Host_Instance_StatusValues = ["Up", "Down"] as const;`,
+		"Status?: Device_Host_Instance_Status;",
+		"Address?: IPAddress;",
+		"LeaseTime: integerValidator({ min: 0, max: 86400 }),",
+		"export function Device_Host_InstancePath(i1: number): string {",
+		"return `Device.Host.${i1}.`;",
+	} {
+		if !strings.Contains(contentStr, expected) {
+			t.Errorf("Generated code doesn't contain %q", expected)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package models
 
 import (
 	"encoding/xml"
+	"sync/atomic"
 )
 
 // Document represents the top-level XML element in a CWMP data model file
@@ -9,11 +10,40 @@ type Document struct {
 	XMLName      xml.Name     `xml:"document"`
 	Xmlns        string       `xml:"xmlns,attr,omitempty"`
 	Spec         string       `xml:"spec,attr,omitempty"`
+	Description  string       `xml:"description,omitempty"`
+	Imports      []Import     `xml:"import"`
 	DataTypes    []DataType   `xml:"dataType"`
 	Bibliography Bibliography `xml:"bibliography"`
 	Models       []DataModel  `xml:"model"`
 }
 
+// Model returns the model of the document with a name such as
+// InternetGatewayDevice:1.0
+func (d *Document) Model(name string) (*DataModel, bool) {
+	for i := range d.Models {
+		if d.Models[i].Name == name {
+			return &d.Models[i], true
+		}
+	}
+	return nil, false
+}
+
+// Import references definitions of another data model document
+type Import struct {
+	File       string       `xml:"file,attr"`
+	Spec       string       `xml:"spec,attr,omitempty"`
+	DataTypes  []ImportItem `xml:"dataType"`
+	Components []ImportItem `xml:"component"`
+	Models     []ImportItem `xml:"model"`
+}
+
+// ImportItem is a definition imported from another document, under another
+// name when Ref is set
+type ImportItem struct {
+	Name string `xml:"name,attr"`
+	Ref  string `xml:"ref,attr,omitempty"`
+}
+
 // Bibliography contains references used in the document
 type Bibliography struct {
 	References []Reference `xml:"reference"`
@@ -32,15 +62,25 @@ type Reference struct {
 
 // DataType represents a custom data type definition
 type DataType struct {
-	Name        string      `xml:"name,attr"`
-	Description string      `xml:"description,omitempty"`
-	String      *StringType `xml:"string,omitempty"`
+	Name         string        `xml:"name,attr"`
+	Base         string        `xml:"base,attr,omitempty"`
+	Description  string        `xml:"description,omitempty"`
+	String       *StringType   `xml:"string,omitempty"`
+	Boolean      *Boolean      `xml:"boolean,omitempty"`
+	DateTime     *DateTime     `xml:"dateTime,omitempty"`
+	UnsignedInt  *UnsignedInt  `xml:"unsignedInt,omitempty"`
+	Int          *Int          `xml:"int,omitempty"`
+	Long         *Long         `xml:"long,omitempty"`
+	UnsignedLong *UnsignedLong `xml:"unsignedLong,omitempty"`
+	Base64       *Base64       `xml:"base64,omitempty"`
+	HexBinary    *HexBinary    `xml:"hexBinary,omitempty"`
 }
 
 // StringType represents string data type constraints
 type StringType struct {
-	Size    *Size     `xml:"size,omitempty"`
-	Pattern []Pattern `xml:"pattern,omitempty"`
+	Size        *Size         `xml:"size,omitempty"`
+	Pattern     []Pattern     `xml:"pattern,omitempty"`
+	Enumeration []Enumeration `xml:"enumeration,omitempty"`
 }
 
 // Pattern represents a validation pattern
@@ -57,6 +97,9 @@ type DataModel struct {
 	Version     string      `xml:"version,attr,omitempty"`
 	Objects     []Object    `xml:"object"`
 	Parameters  []Parameter `xml:"parameter"`
+	DataTypes   []DataType  `xml:"-"` // Document-level dataTypes available to this model
+
+	index atomic.Value // *treeIndex, the path index built on first use
 }
 
 // Object represents a CWMP object
@@ -71,6 +114,8 @@ type Object struct {
 	UniqueKeys          []UniqueKey `xml:"uniqueKey"`
 	Objects             []Object    `xml:"object"`
 	Parameters          []Parameter `xml:"parameter"`
+	Commands            []Command   `xml:"command"`
+	Events              []Event     `xml:"event"`
 	MultiInstance       bool        // Derived field for code generation
 	Path                string      // Full path including parent object paths
 	HasIndexPlaceholder bool        // Whether the path contains an {i} placeholder
@@ -91,6 +136,30 @@ func (o *Object) IsMultiInstance() bool {
 	return o.MultiInstance || o.HasIndexPlaceholder
 }
 
+// Command represents a USP (TR-369) command such as Reboot()
+type Command struct {
+	Name        string     `xml:"name,attr"`
+	Description string     `xml:"description,omitempty"`
+	Async       bool       `xml:"async,attr,omitempty"`
+	Input       *Arguments `xml:"input,omitempty"`
+	Output      *Arguments `xml:"output,omitempty"`
+}
+
+// Event represents a USP (TR-369) event such as Boot!
+type Event struct {
+	Name        string      `xml:"name,attr"`
+	Description string      `xml:"description,omitempty"`
+	Parameters  []Parameter `xml:"parameter"`
+	Objects     []Object    `xml:"object"`
+}
+
+// Arguments holds the input or output arguments of a command. Argument
+// names and object paths are relative to the command.
+type Arguments struct {
+	Parameters []Parameter `xml:"parameter"`
+	Objects    []Object    `xml:"object"`
+}
+
 // UniqueKey represents a unique key constraint
 type UniqueKey struct {
 	Parameters []ParameterRef `xml:"parameter"`
@@ -103,13 +172,17 @@ type ParameterRef struct {
 
 // Parameter represents a CWMP parameter
 type Parameter struct {
-	Name        string `xml:"name,attr"`
-	Description string `xml:"description,omitempty"`
-	Access      string `xml:"access,attr,omitempty"`
-	Syntax      Syntax `xml:"syntax"`
-	Type        string // Derived field for code generation
-	ParentPath  string // Path to parent object
-	FullPath    string // Complete path including parent
+	Name         string `xml:"name,attr"`
+	Description  string `xml:"description,omitempty"`
+	Access       string `xml:"access,attr,omitempty"`
+	ActiveNotify string `xml:"activeNotify,attr,omitempty"`
+	ForcedInform bool   `xml:"forcedInform,attr,omitempty"`
+	Syntax       Syntax `xml:"syntax"`
+	Type         string // Derived field for code generation
+	IsList       bool   // Whether the value is a comma-separated list
+	ItemType     string // Type of each list item when IsList is set
+	ParentPath   string // Path to parent object
+	FullPath     string // Complete path including parent
 }
 
 // GetFullPath returns the full path to this parameter including parent paths
@@ -125,24 +198,39 @@ func (p *Parameter) GetFullPath() string {
 
 // Syntax defines the value constraints for a parameter
 type Syntax struct {
-	Hidden      string       `xml:"hidden,attr,omitempty"`
-	Default     string       `xml:"default,omitempty"`
-	List        *List        `xml:"list,omitempty"`
-	String      *StringCons  `xml:"string,omitempty"`
-	Boolean     *Boolean     `xml:"boolean,omitempty"`
-	DateTime    *DateTime    `xml:"dateTime,omitempty"`
-	UnsignedInt *UnsignedInt `xml:"unsignedInt,omitempty"`
-	DataTypeRef *DataTypeRef `xml:"dataType,omitempty"`
+	Hidden       string        `xml:"hidden,attr,omitempty"`
+	Default      *Default      `xml:"default,omitempty"`
+	List         *List         `xml:"list,omitempty"`
+	String       *StringCons   `xml:"string,omitempty"`
+	Boolean      *Boolean      `xml:"boolean,omitempty"`
+	DateTime     *DateTime     `xml:"dateTime,omitempty"`
+	UnsignedInt  *UnsignedInt  `xml:"unsignedInt,omitempty"`
+	Int          *Int          `xml:"int,omitempty"`
+	Long         *Long         `xml:"long,omitempty"`
+	UnsignedLong *UnsignedLong `xml:"unsignedLong,omitempty"`
+	Base64       *Base64       `xml:"base64,omitempty"`
+	HexBinary    *HexBinary    `xml:"hexBinary,omitempty"`
+	DataTypeRef  *DataTypeRef  `xml:"dataType,omitempty"`
 }
 
-// List defines a list parameter
+// Default defines the default value of a parameter
+type Default struct {
+	Type  string `xml:"type,attr,omitempty"` // object, factory, implementation or parameter
+	Value string `xml:"value,attr"`
+}
+
+// List defines a list parameter. The item syntax is given by the sibling
+// elements of the enclosing Syntax.
 type List struct {
-	Size *Size `xml:"size,omitempty"`
+	MinItems string `xml:"minItems,attr,omitempty"`
+	MaxItems string `xml:"maxItems,attr,omitempty"`
+	Size     *Size  `xml:"size,omitempty"`
 }
 
 // StringCons defines string constraints
 type StringCons struct {
 	Size        *Size         `xml:"size,omitempty"`
+	Pattern     []Pattern     `xml:"pattern,omitempty"`
 	Enumeration []Enumeration `xml:"enumeration,omitempty"`
 }
 
@@ -163,6 +251,31 @@ type UnsignedInt struct {
 	Range *Range `xml:"range,omitempty"`
 }
 
+// Int represents a 32-bit signed int parameter type
+type Int struct {
+	Range *Range `xml:"range,omitempty"`
+}
+
+// Long represents a 64-bit signed long parameter type
+type Long struct {
+	Range *Range `xml:"range,omitempty"`
+}
+
+// UnsignedLong represents a 64-bit unsignedLong parameter type
+type UnsignedLong struct {
+	Range *Range `xml:"range,omitempty"`
+}
+
+// Base64 represents a base64-encoded binary parameter type
+type Base64 struct {
+	Size *Size `xml:"size,omitempty"`
+}
+
+// HexBinary represents a hex-encoded binary parameter type
+type HexBinary struct {
+	Size *Size `xml:"size,omitempty"`
+}
+
 // Range defines min/max values for a parameter
 type Range struct {
 	MinInclusive string `xml:"minInclusive,attr,omitempty"`
@@ -178,6 +291,6 @@ type DataTypeRef struct {
 
 // Size defines size constraints for a parameter
 type Size struct {
-	Min int `xml:"min,attr,omitempty"`
+	Min int `xml:"minLength,attr,omitempty"`
 	Max int `xml:"maxLength,attr,omitempty"`
 }
@@ -0,0 +1,91 @@
+package models
+
+import "strings"
+
+// ValueSyntax is the built-in type of a parameter value together with its
+// facets
+type ValueSyntax struct {
+	Type        string // Built-in type, e.g. unsignedInt or hexBinary
+	Size        *Size
+	Range       *Range
+	Patterns    []Pattern
+	Enumeration []Enumeration
+}
+
+// builtinTypes maps the lower-case names of the built-in types to their
+// spelling in the schema
+var builtinTypes = map[string]string{
+	"string":       "string",
+	"boolean":      "boolean",
+	"datetime":     "dateTime",
+	"unsignedint":  "unsignedInt",
+	"int":          "int",
+	"long":         "long",
+	"unsignedlong": "unsignedLong",
+	"base64":       "base64",
+	"hexbinary":    "hexBinary",
+}
+
+// ResolveSyntax returns the built-in type and facets of a parameter, or of
+// the items of a list parameter, following named dataTypes to their base.
+//
+// Named dataTypes are string-encoded on the wire, so a type that is neither
+// built in nor resolved to a built-in base within eight levels is a string.
+func ResolveSyntax(param Parameter, dataTypes map[string]DataType) ValueSyntax {
+	syntax := param.Syntax
+	cwmpType := param.Type
+	if param.IsList {
+		cwmpType = param.ItemType
+	}
+
+	switch {
+	case syntax.String != nil:
+		return ValueSyntax{Type: "string", Size: syntax.String.Size, Patterns: syntax.String.Pattern, Enumeration: syntax.String.Enumeration}
+	case syntax.Base64 != nil:
+		return ValueSyntax{Type: "base64", Size: syntax.Base64.Size}
+	case syntax.HexBinary != nil:
+		return ValueSyntax{Type: "hexBinary", Size: syntax.HexBinary.Size}
+	case syntax.UnsignedInt != nil:
+		return ValueSyntax{Type: "unsignedInt", Range: syntax.UnsignedInt.Range}
+	case syntax.Int != nil:
+		return ValueSyntax{Type: "int", Range: syntax.Int.Range}
+	case syntax.Long != nil:
+		return ValueSyntax{Type: "long", Range: syntax.Long.Range}
+	case syntax.UnsignedLong != nil:
+		return ValueSyntax{Type: "unsignedLong", Range: syntax.UnsignedLong.Range}
+	}
+
+	name := cwmpType
+	for depth := 0; depth < 8; depth++ {
+		dataType, ok := dataTypes[name]
+		if !ok {
+			break
+		}
+		switch {
+		case dataType.String != nil:
+			return ValueSyntax{Type: "string", Size: dataType.String.Size, Patterns: dataType.String.Pattern, Enumeration: dataType.String.Enumeration}
+		case dataType.Base64 != nil:
+			return ValueSyntax{Type: "base64", Size: dataType.Base64.Size}
+		case dataType.HexBinary != nil:
+			return ValueSyntax{Type: "hexBinary", Size: dataType.HexBinary.Size}
+		case dataType.Boolean != nil:
+			return ValueSyntax{Type: "boolean"}
+		case dataType.DateTime != nil:
+			return ValueSyntax{Type: "dateTime"}
+		case dataType.UnsignedInt != nil:
+			return ValueSyntax{Type: "unsignedInt", Range: dataType.UnsignedInt.Range}
+		case dataType.Int != nil:
+			return ValueSyntax{Type: "int", Range: dataType.Int.Range}
+		case dataType.Long != nil:
+			return ValueSyntax{Type: "long", Range: dataType.Long.Range}
+		case dataType.UnsignedLong != nil:
+			return ValueSyntax{Type: "unsignedLong", Range: dataType.UnsignedLong.Range}
+		}
+		name = dataType.Base
+	}
+
+	if builtin, ok := builtinTypes[strings.ToLower(cwmpType)]; ok {
+		return ValueSyntax{Type: builtin}
+	}
+	return ValueSyntax{Type: "string"}
+}
@@ -0,0 +1,36 @@
+package models
+
+import "testing"
+
+func TestResolveSyntax(t *testing.T) {
+	dataTypes := map[string]DataType{
+		"IPAddress":    {Name: "IPAddress", String: &StringType{Size: &Size{Max: 45}}},
+		"IPv4Address":  {Name: "IPv4Address", Base: "IPAddress"},
+		"StatsCounter": {Name: "StatsCounter", UnsignedLong: &UnsignedLong{}},
+		"Loop":         {Name: "Loop", Base: "Loop"},
+	}
+
+	tests := []struct {
+		param Parameter
+		want  string
+	}{
+		{Parameter{Type: "string"}, "string"},
+		{Parameter{Type: "UnsignedInt"}, "unsignedInt"},
+		{Parameter{Type: "IPv4Address"}, "string"},
+		{Parameter{Type: "StatsCounter"}, "unsignedLong"},
+		{Parameter{Type: "Loop"}, "string"},
+		{Parameter{Type: "Unknown"}, "string"},
+		{Parameter{Type: "string", IsList: true, ItemType: "dateTime"}, "dateTime"},
+		{Parameter{Type: "IPAddress", Syntax: Syntax{Int: &Int{}}}, "int"},
+	}
+	for _, tt := range tests {
+		if got := ResolveSyntax(tt.param, dataTypes); got.Type != tt.want {
+			t.Errorf("ResolveSyntax(%+v) = %s, want %s", tt.param, got.Type, tt.want)
+		}
+	}
+
+	value := ResolveSyntax(Parameter{Type: "IPv4Address"}, dataTypes)
+	if value.Size == nil || value.Size.Max != 45 {
+		t.Errorf("Expected the size of the base dataType, got %+v", value.Size)
+	}
+}
@@ -0,0 +1,279 @@
+package models
+
+import (
+	"errors"
+	"iter"
+	"strconv"
+	"strings"
+)
+
+// SkipChildren is returned by a Walk visitor to skip the nodes below an
+// object
+var SkipChildren = errors.New("skip children")
+
+// Node is an object or parameter in the tree of a data model. Paths are
+// schema paths: object paths end with a dot and table entries with {i}.,
+// e.g. Device.Hosts.Host.{i}. and Device.Hosts.Host.{i}.IPAddress.
+type Node struct {
+	Path      string
+	Object    *Object    // The object, nil for parameters
+	Parameter *Parameter // The parameter, nil for objects
+	Parent    *Node      // Nearest declared ancestor object, nil for roots
+	Children  []*Node    // Parameters, then child objects, in declaration order
+}
+
+// IsObject reports whether the node is an object
+func (n *Node) IsObject() bool {
+	return n.Object != nil
+}
+
+// IsTable reports whether the node is the entry object of a table
+func (n *Node) IsTable() bool {
+	return n.Object != nil && strings.HasSuffix(n.Path, ".{i}.")
+}
+
+// Name returns the last segment of the node's path, without the {i} of
+// table entries, e.g. Host for Device.Hosts.Host.{i}.
+func (n *Node) Name() string {
+	trimmed := strings.TrimSuffix(strings.TrimSuffix(n.Path, "."), ".{i}")
+	return trimmed[strings.LastIndex(trimmed, ".")+1:]
+}
+
+// treeIndex indexes the objects and parameters of a data model by path
+type treeIndex struct {
+	objects []Object // The objects indexed
+	roots   []*Node
+	nodes   map[string]*Node
+}
+
+// covers reports whether the index was built from objects
+func (index *treeIndex) covers(objects []Object) bool {
+	if len(index.objects) != len(objects) {
+		return false
+	}
+	return len(objects) == 0 || &index.objects[0] == &objects[0]
+}
+
+// Reindex rebuilds the path index of the model. The index is built on first
+// use and rebuilt when the Objects slice is replaced, so Reindex only needs
+// to be called after changing objects in place. Reading a model from several
+// goroutines is safe; changing its objects while it is read is not.
+//
+// A copy of a model shares its index until either one's Objects slice is
+// replaced.
+//
+// Objects declared more than once share a node, later declarations adding
+// the parameters the earlier ones lack.
+func (m *DataModel) Reindex() {
+	m.index.Store(m.buildIndex())
+}
+
+// buildIndex indexes the objects and parameters of the model
+func (m *DataModel) buildIndex() *treeIndex {
+	index := &treeIndex{objects: m.Objects, nodes: make(map[string]*Node)}
+
+	var objects []*Node
+	var collect func(declared []Object)
+	collect = func(declared []Object) {
+		for i := range declared {
+			obj := &declared[i]
+			path := strings.TrimSuffix(obj.GetPath(), ".") + "."
+			node, ok := index.nodes[path]
+			if !ok {
+				node = &Node{Path: path, Object: obj}
+				index.nodes[path] = node
+				objects = append(objects, node)
+			}
+			for j := range obj.Parameters {
+				paramPath := path + obj.Parameters[j].Name
+				if _, ok := index.nodes[paramPath]; ok {
+					continue
+				}
+				param := &Node{Path: paramPath, Parameter: &obj.Parameters[j], Parent: node}
+				index.nodes[paramPath] = param
+				node.Children = append(node.Children, param)
+			}
+			collect(obj.Objects)
+		}
+	}
+	collect(m.Objects)
+
+	// Link every object to its nearest declared ancestor
+	for _, node := range objects {
+		for parent := parentObjectPath(node.Path); parent != ""; parent = parentObjectPath(parent) {
+			if p, ok := index.nodes[parent]; ok {
+				node.Parent = p
+				p.Children = append(p.Children, node)
+				break
+			}
+		}
+		if node.Parent == nil {
+			index.roots = append(index.roots, node)
+		}
+	}
+
+	return index
+}
+
+// tree returns the path index of the model, building it on first use and
+// after the Objects slice is replaced. Concurrent first uses may each build
+// an index, but all of them return the one stored first.
+func (m *DataModel) tree() *treeIndex {
+	stored := m.index.Load()
+	if index, ok := stored.(*treeIndex); ok && index.covers(m.Objects) {
+		return index
+	}
+	m.index.CompareAndSwap(stored, m.buildIndex())
+	return m.index.Load().(*treeIndex)
+}
+
+// Lookup returns the object or parameter at a path. Instance numbers in the
+// path match the {i} of tables, so Device.Hosts.Host.2.IPAddress finds
+// Device.Hosts.Host.{i}.IPAddress.
+func (m *DataModel) Lookup(path string) (*Node, bool) {
+	index := m.tree()
+	if node, ok := index.nodes[path]; ok {
+		return node, true
+	}
+	node, ok := index.nodes[SchemaPath(path)]
+	return node, ok
+}
+
+// Roots returns the objects that have no declared ancestor
+func (m *DataModel) Roots() []*Node {
+	return m.tree().roots
+}
+
+// Children returns the parameters and child objects of the object at a
+// path, or the root objects for an empty path
+func (m *DataModel) Children(path string) []*Node {
+	if path == "" {
+		return m.Roots()
+	}
+	if node, ok := m.Lookup(path); ok {
+		return node.Children
+	}
+	return nil
+}
+
+// ParametersOf returns the parameters of the object at a path
+func (m *DataModel) ParametersOf(path string) []*Parameter {
+	params := []*Parameter{}
+	for _, child := range m.Children(path) {
+		if child.Parameter != nil {
+			params = append(params, child.Parameter)
+		}
+	}
+	return params
+}
+
+// Walk visits every node of the model depth-first, an object before its
+// parameters and child objects. A visitor returning SkipChildren skips the
+// nodes below an object; any other error stops the walk and is returned.
+func (m *DataModel) Walk(visit func(node *Node) error) error {
+	var walk func(nodes []*Node) error
+	walk = func(nodes []*Node) error {
+		for _, node := range nodes {
+			err := visit(node)
+			if errors.Is(err, SkipChildren) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if err := walk(node.Children); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return walk(m.Roots())
+}
+
+// Ancestors iterates over the objects above the node at a path, nearest
+// first
+func (m *DataModel) Ancestors(path string) iter.Seq[*Node] {
+	return func(yield func(*Node) bool) {
+		node, ok := m.Lookup(path)
+		if !ok {
+			return
+		}
+		for parent := node.Parent; parent != nil; parent = parent.Parent {
+			if !yield(parent) {
+				return
+			}
+		}
+	}
+}
+
+// Match returns the nodes whose path matches a pattern, in Walk order. See
+// MatchPath for the pattern syntax; instance numbers in the pattern match
+// the {i} of tables.
+func (m *DataModel) Match(pattern string) []*Node {
+	pattern = SchemaPath(pattern)
+	matches := []*Node{}
+	m.Walk(func(node *Node) error {
+		if MatchPath(pattern, node.Path) {
+			matches = append(matches, node)
+		}
+		return nil
+	})
+	return matches
+}
+
+// MatchPath reports whether a path matches a pattern segment by segment. A
+// * segment matches any name or instance number and a {i} segment matches
+// {i} or an instance number, e.g. Device.*.Host.{i}.Enable matches
+// Device.Hosts.Host.2.Enable and Device.Hosts.Host.{i}.Enable.
+func MatchPath(pattern, path string) bool {
+	patternSegments := strings.Split(pattern, ".")
+	pathSegments := strings.Split(path, ".")
+	if len(patternSegments) != len(pathSegments) {
+		return false
+	}
+	for i, segment := range patternSegments {
+		actual := pathSegments[i]
+		switch segment {
+		case "*":
+			if actual == "" {
+				return false
+			}
+		case "{i}":
+			if actual != "{i}" && !IsInstanceNumber(actual) {
+				return false
+			}
+		default:
+			if segment != actual {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// SchemaPath replaces the instance numbers of a path with {i}
+func SchemaPath(path string) string {
+	segments := strings.Split(path, ".")
+	for i, segment := range segments {
+		if IsInstanceNumber(segment) {
+			segments[i] = "{i}"
+		}
+	}
+	return strings.Join(segments, ".")
+}
+
+// IsInstanceNumber reports whether a path segment is an instance number
+func IsInstanceNumber(segment string) bool {
+	n, err := strconv.Atoi(segment)
+	return err == nil && n > 0 && strconv.Itoa(n) == segment
+}
+
+// parentObjectPath returns the path of the object above an object, skipping
+// the table of a table entry, or an empty string for top-level objects
+func parentObjectPath(path string) string {
+	trimmed := strings.TrimSuffix(strings.TrimSuffix(path, "."), ".{i}")
+	if i := strings.LastIndex(trimmed, "."); i >= 0 {
+		return trimmed[:i+1]
+	}
+	return ""
+}
@@ -0,0 +1,258 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// testModel returns a model declaring its objects flat, out of order, with
+// an undeclared Device.Hosts. table parent and a repeated object
+func testModel() *DataModel {
+	return &DataModel{
+		Name: "Device:2.1",
+		Objects: []Object{
+			{Name: "Device.Hosts.Host.{i}.", Path: "Device.Hosts.Host.{i}.", Parameters: []Parameter{
+				{Name: "IPAddress", Type: "string"},
+				{Name: "Active", Type: "boolean"},
+			}},
+			{Name: "Device.", Path: "Device.", Parameters: []Parameter{
+				{Name: "RootDataModelVersion", Type: "string"},
+			}},
+			{Name: "Device.DeviceInfo.", Path: "Device.DeviceInfo.", Parameters: []Parameter{
+				{Name: "SoftwareVersion", Type: "string"},
+			}},
+			{Name: "Device.Hosts.Host.{i}.IPv4Address.{i}.", Path: "Device.Hosts.Host.{i}.IPv4Address.{i}.", Parameters: []Parameter{
+				{Name: "IPAddress", Type: "string"},
+			}},
+			{Name: "Device.DeviceInfo.", Path: "Device.DeviceInfo.", Parameters: []Parameter{
+				{Name: "SoftwareVersion", Type: "string"},
+				{Name: "HardwareVersion", Type: "string"},
+			}},
+		},
+	}
+}
+
+func TestLookup(t *testing.T) {
+	model := testModel()
+
+	node, ok := model.Lookup("Device.Hosts.Host.{i}.IPAddress")
+	if !ok || node.Parameter == nil || node.Parameter.Type != "string" {
+		t.Fatalf("Expected the IPAddress parameter, got %+v", node)
+	}
+	if node.Parent == nil || node.Parent.Path != "Device.Hosts.Host.{i}." {
+		t.Errorf("Expected the Host table as parent, got %+v", node.Parent)
+	}
+
+	node, ok = model.Lookup("Device.Hosts.Host.3.IPv4Address.1.")
+	if !ok || !node.IsTable() || node.Name() != "IPv4Address" {
+		t.Errorf("Expected an instance path to find its table, got %+v", node)
+	}
+	if _, ok := model.Lookup("Device.Hosts.Host.03.IPAddress"); ok {
+		t.Error("Expected a zero-padded instance number not to match")
+	}
+	if _, ok := model.Lookup("Device.Hosts."); ok {
+		t.Error("Expected the undeclared Device.Hosts. object to be missing")
+	}
+}
+
+func TestChildren(t *testing.T) {
+	model := testModel()
+
+	roots := model.Roots()
+	if len(roots) != 1 || roots[0].Path != "Device." {
+		t.Fatalf("Expected Device. as the only root, got %v", roots)
+	}
+
+	paths := []string{}
+	for _, child := range model.Children("Device.") {
+		paths = append(paths, child.Path)
+	}
+	expected := []string{"Device.RootDataModelVersion", "Device.Hosts.Host.{i}.", "Device.DeviceInfo."}
+	if len(paths) != len(expected) {
+		t.Fatalf("Expected children %v, got %v", expected, paths)
+	}
+	for i := range expected {
+		if paths[i] != expected[i] {
+			t.Errorf("Expected child %d to be %s, got %s", i, expected[i], paths[i])
+		}
+	}
+
+	params := model.ParametersOf("Device.DeviceInfo.")
+	if len(params) != 2 || params[0].Name != "SoftwareVersion" || params[1].Name != "HardwareVersion" {
+		t.Errorf("Expected repeated declarations to be merged, got %+v", params)
+	}
+	if params := model.ParametersOf("Device.Nothing."); len(params) != 0 {
+		t.Errorf("Expected no parameters for an unknown object, got %+v", params)
+	}
+}
+
+func TestWalk(t *testing.T) {
+	model := testModel()
+
+	visited := []string{}
+	err := model.Walk(func(node *Node) error {
+		visited = append(visited, node.Path)
+		if node.Path == "Device.Hosts.Host.{i}." {
+			return SkipChildren
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Walk returned error: %v", err)
+	}
+	expected := []string{
+		"Device.",
+		"Device.RootDataModelVersion",
+		"Device.Hosts.Host.{i}.",
+		"Device.DeviceInfo.",
+		"Device.DeviceInfo.SoftwareVersion",
+		"Device.DeviceInfo.HardwareVersion",
+	}
+	if len(visited) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, visited)
+	}
+	for i := range expected {
+		if visited[i] != expected[i] {
+			t.Errorf("Expected node %d to be %s, got %s", i, expected[i], visited[i])
+		}
+	}
+
+	// A wrapped SkipChildren skips as well
+	visited = visited[:0]
+	if err := model.Walk(func(node *Node) error {
+		visited = append(visited, node.Path)
+		return fmt.Errorf("%s: %w", node.Path, SkipChildren)
+	}); err != nil || len(visited) != 1 {
+		t.Errorf("Expected only the root to be visited, got %v %v", visited, err)
+	}
+
+	stop := errors.New("stop")
+	count := 0
+	if err := model.Walk(func(node *Node) error {
+		count++
+		return stop
+	}); err != stop || count != 1 {
+		t.Errorf("Expected Walk to stop at the first error, got %v after %d nodes", err, count)
+	}
+}
+
+func TestAncestors(t *testing.T) {
+	model := testModel()
+
+	paths := []string{}
+	for node := range model.Ancestors("Device.Hosts.Host.1.IPv4Address.2.IPAddress") {
+		paths = append(paths, node.Path)
+	}
+	expected := []string{"Device.Hosts.Host.{i}.IPv4Address.{i}.", "Device.Hosts.Host.{i}.", "Device."}
+	if len(paths) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, paths)
+	}
+	for i := range expected {
+		if paths[i] != expected[i] {
+			t.Errorf("Expected ancestor %d to be %s, got %s", i, expected[i], paths[i])
+		}
+	}
+
+	for node := range model.Ancestors("Device.Hosts.Host.{i}.IPAddress") {
+		if node.Path != "Device.Hosts.Host.{i}." {
+			t.Errorf("Expected iteration to stop after the first ancestor, got %s", node.Path)
+		}
+		break
+	}
+}
+
+func TestMatch(t *testing.T) {
+	model := testModel()
+
+	for pattern, expected := range map[string]int{
+		"Device.Hosts.Host.{i}.IPAddress":         1,
+		"Device.Hosts.Host.2.IPAddress":           1,
+		"Device.*.Host.{i}.*":                     2,
+		"Device.Hosts.Host.{i}.IPv4Address.{i}.*": 1,
+		"Device.*.*":                2,
+		"*.":                        1,
+		"Device.DeviceInfo.Nothing": 0,
+		"Device.Hosts.Host.{i}.IPv4Address.{i}.*.Sub": 0,
+	} {
+		if matches := model.Match(pattern); len(matches) != expected {
+			t.Errorf("Expected %d matches of %s, got %d", expected, pattern, len(matches))
+		}
+	}
+
+	for _, tc := range []struct {
+		pattern, path string
+		match         bool
+	}{
+		{"Device.Hosts.Host.{i}.", "Device.Hosts.Host.12.", true},
+		{"Device.Hosts.Host.{i}.", "Device.Hosts.Host.{i}.", true},
+		{"Device.Hosts.Host.{i}.", "Device.Hosts.Host.0.", false},
+		{"Device.*.", "Device.", false},
+		{"Device.*", "Device.DeviceInfo.", false},
+	} {
+		if MatchPath(tc.pattern, tc.path) != tc.match {
+			t.Errorf("Expected MatchPath(%q, %q) to be %v", tc.pattern, tc.path, tc.match)
+		}
+	}
+}
+
+func TestReindex(t *testing.T) {
+	model := testModel()
+	if _, ok := model.Lookup("Device.Time."); ok {
+		t.Fatal("Expected Device.Time. to be missing")
+	}
+
+	model.Objects = append(model.Objects, Object{Name: "Device.Time.", Path: "Device.Time."})
+	model.Reindex()
+	node, ok := model.Lookup("Device.Time.")
+	if !ok || node.Parent == nil || node.Parent.Path != "Device." {
+		t.Errorf("Expected Reindex to pick up Device.Time., got %+v", node)
+	}
+}
+
+func TestConcurrentFirstUse(t *testing.T) {
+	model := testModel()
+
+	var wg sync.WaitGroup
+	roots := make([][]*Node, 8)
+	for i := range roots {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, ok := model.Lookup("Device.Hosts.Host.3.IPAddress"); !ok {
+				t.Error("Expected Device.Hosts.Host.3.IPAddress")
+			}
+			roots[i] = model.Roots()
+		}()
+	}
+	wg.Wait()
+
+	// Every reader sees the same index
+	for _, r := range roots {
+		if len(r) != 1 || r[0] != roots[0][0] {
+			t.Fatalf("Expected one shared root, got %v and %v", r, roots[0])
+		}
+	}
+}
+
+func TestModelCopy(t *testing.T) {
+	models := []DataModel{*testModel()}
+	if _, ok := models[0].Lookup("Device.DeviceInfo."); !ok {
+		t.Fatal("Expected Device.DeviceInfo.")
+	}
+
+	for _, model := range models {
+		model.Objects = []Object{{Name: "Device.Time.", Path: "Device.Time."}}
+		if _, ok := model.Lookup("Device.Time."); !ok {
+			t.Error("Expected the copy to index its own objects")
+		}
+		if _, ok := model.Lookup("Device.DeviceInfo."); ok {
+			t.Error("Expected the copy not to see the objects it replaced")
+		}
+	}
+
+	if _, ok := models[0].Lookup("Device.Time."); ok {
+		t.Error("Expected the original to keep its index")
+	}
+}
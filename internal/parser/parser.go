@@ -1,8 +1,10 @@
 package parser
 
 import (
+	"bytes"
 	"encoding/xml"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/url"
@@ -13,8 +15,15 @@ import (
 	"github.com/Niceblueman/cwmp-codegen/internal/models"
 )
 
-// ParseXML reads an XML file or URL and converts it to our internal model representation
+// ParseXML reads an XML file or URL and converts its first model to our
+// internal model representation
 func ParseXML(source string) (*models.DataModel, error) {
+	return firstModel(ParseDocument(source))
+}
+
+// ParseDocument reads an XML file or URL and converts it with all of its
+// models
+func ParseDocument(source string) (*models.Document, error) {
 	var xmlData []byte
 	var err error
 
@@ -29,22 +38,80 @@ func ParseXML(source string) (*models.DataModel, error) {
 		return nil, err
 	}
 
+	return parseDocument(xmlData)
+}
+
+// Parse reads an XML document and converts it with all of its models, whose
+// derived fields are set. The root element is a <document>, or a bare
+// <model> that is wrapped in a document.
+func Parse(r io.Reader) (*models.Document, error) {
+	xmlData, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return parseDocument(xmlData)
+}
+
+// ParseReader reads an XML document and converts its first model to our
+// internal model representation
+func ParseReader(r io.Reader) (*models.DataModel, error) {
+	return firstModel(Parse(r))
+}
+
+// parseDocument converts an XML document and processes its models
+func parseDocument(xmlData []byte) (*models.Document, error) {
+	root, err := rootElement(xmlData)
+	if err != nil {
+		return nil, err
+	}
+
 	// Parse XML into our document structure
 	var document models.Document
-	err = xml.Unmarshal(xmlData, &document)
+	switch root.Local {
+	case "document":
+		err = xml.Unmarshal(xmlData, &document)
+	case "model":
+		document.Models = make([]models.DataModel, 1)
+		err = xml.Unmarshal(xmlData, &document.Models[0])
+	default:
+		err = fmt.Errorf("expected a <document> or <model> root element, got <%s>", root.Local)
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	// Check if we have any models
-	if len(document.Models) == 0 {
-		return nil, errors.New("no models found in the document")
+	// Process the models to set derived fields
+	for i := range document.Models {
+		processModel(&document.Models[i])
+		document.Models[i].DataTypes = document.DataTypes
+		document.Models[i].Reindex()
 	}
+	return &document, nil
+}
 
-	// Process the model to set derived fields
-	processModel(&document.Models[0])
+// rootElement returns the name of the root element of an XML document
+func rootElement(xmlData []byte) (xml.Name, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(xmlData))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return xml.Name{}, err
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name, nil
+		}
+	}
+}
 
-	// Return the first model found (most documents only have one)
+// firstModel returns the first model of a parsed document (most documents
+// only have one)
+func firstModel(document *models.Document, err error) (*models.DataModel, error) {
+	if err != nil {
+		return nil, err
+	}
+	if len(document.Models) == 0 {
+		return nil, errors.New("no models found in the document")
+	}
 	return &document.Models[0], nil
 }
 
@@ -100,12 +167,40 @@ func processObjectParameters(obj *models.Object) {
 		processParameter(&obj.Parameters[i])
 	}
 
+	// Process the arguments of USP commands and events
+	for i := range obj.Commands {
+		processArguments(obj.Commands[i].Input)
+		processArguments(obj.Commands[i].Output)
+	}
+	for i := range obj.Events {
+		// The arguments share the elements of the event's slices
+		processArguments(&models.Arguments{
+			Parameters: obj.Events[i].Parameters,
+			Objects:    obj.Events[i].Objects,
+		})
+	}
+
 	// Process nested objects recursively
 	for i := range obj.Objects {
 		processObjectParameters(&obj.Objects[i])
 	}
 }
 
+// processArguments sets derived fields of command or event arguments. Their
+// paths stay relative to the command or event.
+func processArguments(args *models.Arguments) {
+	if args == nil {
+		return
+	}
+	for i := range args.Parameters {
+		processParameter(&args.Parameters[i])
+	}
+	for i := range args.Objects {
+		processObjectInitial(&args.Objects[i], "")
+		processObjectParameters(&args.Objects[i])
+	}
+}
+
 // processObject processes an object to set derived fields and processes its children
 // Kept for backward compatibility
 func processObject(obj *models.Object) {
@@ -128,23 +223,47 @@ func processObject(obj *models.Object) {
 // processParameter processes a parameter to set derived fields
 func processParameter(param *models.Parameter) {
 	// Determine parameter type based on syntax
-	syntax := param.Syntax
+	itemType := syntaxType(param.Syntax)
 
+	// A list carries its item syntax alongside the <list> element
+	if param.Syntax.List != nil {
+		param.Type = "list"
+		param.IsList = true
+		if itemType == "" {
+			itemType = "string"
+		}
+		param.ItemType = itemType
+	} else if itemType != "" {
+		param.Type = itemType
+	} else {
+		param.Type = "string" // Default type
+	}
+}
+
+// syntaxType returns the type named by the value syntax, ignoring any list facet
+func syntaxType(syntax models.Syntax) string {
 	if syntax.Boolean != nil {
-		param.Type = "boolean"
+		return "boolean"
 	} else if syntax.String != nil {
-		param.Type = "string"
+		return "string"
 	} else if syntax.DateTime != nil {
-		param.Type = "datetime"
+		return "datetime"
 	} else if syntax.UnsignedInt != nil {
-		param.Type = "unsignedInt"
+		return "unsignedInt"
+	} else if syntax.Int != nil {
+		return "int"
+	} else if syntax.Long != nil {
+		return "long"
+	} else if syntax.UnsignedLong != nil {
+		return "unsignedLong"
+	} else if syntax.Base64 != nil {
+		return "base64"
+	} else if syntax.HexBinary != nil {
+		return "hexBinary"
 	} else if syntax.DataTypeRef != nil {
-		param.Type = syntax.DataTypeRef.Ref
-	} else if syntax.List != nil {
-		param.Type = "list"
-	} else {
-		param.Type = "string" // Default type
+		return syntax.DataTypeRef.Ref
 	}
+	return ""
 }
 
 // isURL checks if the input string is a URL
@@ -3,6 +3,7 @@ package parser
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -83,4 +84,248 @@ func TestParseXMLInvalid(t *testing.T) {
 	if err == nil {
 		t.Error("Expected error for invalid XML, got nil")
 	}
-}
\ No newline at end of file
+}
+
+func TestParseXMLListSyntax(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "list_model.xml")
+
+	xmlContent := `<?xml version="1.0" encoding="UTF-8"?>
+<document>
+  <model name="ListDevice:1.0">
+    <object name="Device.LANHostConfigManagement." access="readOnly" maxEntries="1">
+      <parameter name="DNSServers" access="readWrite">
+        <syntax>
+          <list minItems="1" maxItems="3">
+            <size maxLength="64"/>
+          </list>
+          <dataType ref="IPAddress"/>
+        </syntax>
+      </parameter>
+      <parameter name="Ports" access="readWrite">
+        <syntax>
+          <list/>
+          <unsignedInt/>
+        </syntax>
+      </parameter>
+    </object>
+  </model>
+</document>`
+
+	if err := os.WriteFile(testFile, []byte(xmlContent), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	model, err := ParseXML(testFile)
+	if err != nil {
+		t.Fatalf("Failed to parse XML: %v", err)
+	}
+
+	params := model.Objects[0].Parameters
+	if len(params) != 2 {
+		t.Fatalf("Expected 2 parameters, got %d", len(params))
+	}
+
+	dns := params[0]
+	if dns.Type != "list" || !dns.IsList || dns.ItemType != "IPAddress" {
+		t.Errorf("Expected list of IPAddress, got type %q list %v item %q", dns.Type, dns.IsList, dns.ItemType)
+	}
+
+	list := dns.Syntax.List
+	if list.MinItems != "1" || list.MaxItems != "3" {
+		t.Errorf("Expected minItems 1 and maxItems 3, got %q and %q", list.MinItems, list.MaxItems)
+	}
+	if list.Size == nil || list.Size.Max != 64 {
+		t.Error("Expected list size facet with maxLength 64")
+	}
+
+	if params[1].ItemType != "unsignedInt" {
+		t.Errorf("Expected item type 'unsignedInt', got '%s'", params[1].ItemType)
+	}
+}
+
+func TestParseXMLSyntaxFacets(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "facets_model.xml")
+
+	xmlContent := `<?xml version="1.0" encoding="UTF-8"?>
+<document>
+  <model name="FacetDevice:1.0">
+    <object name="Device.DeviceInfo." access="readOnly" maxEntries="1">
+      <parameter name="ProvisioningCode" access="readWrite">
+        <syntax>
+          <string>
+            <size minLength="2" maxLength="64"/>
+          </string>
+          <default type="object" value="none"/>
+        </syntax>
+      </parameter>
+    </object>
+  </model>
+</document>`
+
+	if err := os.WriteFile(testFile, []byte(xmlContent), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	model, err := ParseXML(testFile)
+	if err != nil {
+		t.Fatalf("Failed to parse XML: %v", err)
+	}
+
+	syntax := model.Objects[0].Parameters[0].Syntax
+
+	// The minimum length is the minLength attribute of size
+	size := syntax.String.Size
+	if size == nil || size.Min != 2 || size.Max != 64 {
+		t.Errorf("Expected minLength 2 and maxLength 64, got %+v", size)
+	}
+
+	// The default is given by the type and value attributes of default
+	if syntax.Default == nil || syntax.Default.Type != "object" || syntax.Default.Value != "none" {
+		t.Errorf("Expected an object default of none, got %+v", syntax.Default)
+	}
+}
+
+func TestParseXMLCommandsAndEvents(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "usp_model.xml")
+
+	xmlContent := `<?xml version="1.0" encoding="UTF-8"?>
+<document>
+  <model name="Device:2.16">
+    <object name="Device." access="readOnly" minEntries="1" maxEntries="1">
+      <command name="Reboot()">
+        <description>Reboots the device</description>
+      </command>
+      <event name="Boot!">
+        <parameter name="CommandKey">
+          <syntax><string/></syntax>
+        </parameter>
+      </event>
+    </object>
+    <object name="Device.IP.Diagnostics." access="readOnly" minEntries="1" maxEntries="1">
+      <command name="TraceRoute()" async="true">
+        <input>
+          <parameter name="Host" mandatory="true">
+            <syntax><string><size maxLength="256"/></string></syntax>
+          </parameter>
+          <parameter name="NumberOfTries">
+            <syntax><unsignedInt><range minInclusive="1" maxInclusive="3"/></unsignedInt></syntax>
+          </parameter>
+        </input>
+        <output>
+          <parameter name="ResponseTime">
+            <syntax><unsignedInt/></syntax>
+          </parameter>
+          <object name="RouteHops.{i}." access="readOnly" minEntries="0" maxEntries="unbounded">
+            <parameter name="Host">
+              <syntax><string/></syntax>
+            </parameter>
+          </object>
+        </output>
+      </command>
+    </object>
+  </model>
+</document>`
+
+	if err := os.WriteFile(testFile, []byte(xmlContent), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	model, err := ParseXML(testFile)
+	if err != nil {
+		t.Fatalf("Failed to parse XML: %v", err)
+	}
+
+	device := model.Objects[0]
+	if len(device.Commands) != 1 || device.Commands[0].Name != "Reboot()" || device.Commands[0].Async {
+		t.Fatalf("Expected synchronous Reboot() command, got %+v", device.Commands)
+	}
+	if device.Commands[0].Input != nil || device.Commands[0].Output != nil {
+		t.Error("Expected Reboot() without arguments")
+	}
+	if len(device.Events) != 1 || device.Events[0].Name != "Boot!" {
+		t.Fatalf("Expected Boot! event, got %+v", device.Events)
+	}
+	if param := device.Events[0].Parameters[0]; param.Name != "CommandKey" || param.Type != "string" {
+		t.Errorf("Expected string CommandKey argument, got %q of type %q", param.Name, param.Type)
+	}
+
+	traceRoute := model.Objects[1].Commands[0]
+	if !traceRoute.Async {
+		t.Error("Expected TraceRoute() to be asynchronous")
+	}
+	if traceRoute.Input == nil || len(traceRoute.Input.Parameters) != 2 {
+		t.Fatalf("Expected 2 input arguments, got %+v", traceRoute.Input)
+	}
+	if traceRoute.Input.Parameters[1].Type != "unsignedInt" {
+		t.Errorf("Expected unsignedInt NumberOfTries, got %q", traceRoute.Input.Parameters[1].Type)
+	}
+
+	hops := traceRoute.Output.Objects[0]
+	if hops.Path != "RouteHops.{i}." || !hops.IsMultiInstance() {
+		t.Errorf("Expected relative multi-instance RouteHops.{i}., got %q", hops.Path)
+	}
+	if hops.Parameters[0].FullPath != "RouteHops.{i}.Host" {
+		t.Errorf("Expected relative argument path, got %q", hops.Parameters[0].FullPath)
+	}
+}
+
+func TestParse(t *testing.T) {
+	xmlContent := `<?xml version="1.0" encoding="UTF-8"?>
+<dm:document xmlns:dm="urn:broadband-forum-org:cwmp:datamodel-1-0" spec="urn:example:multi">
+  <import file="tr-106-1-0-types.xml" spec="urn:broadband-forum-org:tr-106-1-0">
+    <dataType name="IPAddress"/>
+    <component name="DeviceInfo" ref="_DeviceInfo"/>
+  </import>
+  <dataType name="MACAddress">
+    <string><size maxLength="17"/></string>
+  </dataType>
+  <bibliography>
+    <reference id="RFC3986"><name>RFC 3986</name></reference>
+  </bibliography>
+  <model name="InternetGatewayDevice:1.0">
+    <object name="InternetGatewayDevice." access="readOnly" minEntries="1" maxEntries="1">
+      <parameter name="DeviceSummary" access="readOnly"><syntax><string/></syntax></parameter>
+    </object>
+  </model>
+  <model name="Device:1.0">
+    <object name="Device.LAN." access="readOnly" minEntries="1" maxEntries="1">
+      <parameter name="MACAddress" access="readOnly"><syntax><dataType ref="MACAddress"/></syntax></parameter>
+    </object>
+  </model>
+</dm:document>`
+
+	document, err := Parse(strings.NewReader(xmlContent))
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+	if len(document.Models) != 2 {
+		t.Fatalf("Expected 2 models, got %d", len(document.Models))
+	}
+	if len(document.DataTypes) != 1 || len(document.Bibliography.References) != 1 {
+		t.Errorf("Expected the document dataTypes and bibliography, got %+v", document)
+	}
+	if len(document.Imports) != 1 || document.Imports[0].File != "tr-106-1-0-types.xml" || len(document.Imports[0].Components) != 1 || document.Imports[0].Components[0].Ref != "_DeviceInfo" {
+		t.Errorf("Expected the document imports, got %+v", document.Imports)
+	}
+
+	model, ok := document.Model("Device:1.0")
+	if !ok {
+		t.Fatal("Expected to find model Device:1.0")
+	}
+	if len(model.DataTypes) != 1 {
+		t.Errorf("Expected the model to share the document dataTypes, got %d", len(model.DataTypes))
+	}
+	if _, ok := model.Lookup("Device.LAN.MACAddress"); !ok {
+		t.Error("Expected every model to be indexed")
+	}
+	if _, ok := document.Model("Device:2.0"); ok {
+		t.Error("Expected an unknown model not to be found")
+	}
+
+	if _, err := Parse(strings.NewReader("<models/>")); err == nil || !strings.Contains(err.Error(), "<models>") {
+		t.Errorf("Expected an unknown root element to fail, got %v", err)
+	}
+}
@@ -0,0 +1,434 @@
+package simulator
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/cookiejar"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Niceblueman/cwmp-codegen/internal/models"
+)
+
+// Identity identifies a simulated device in its Informs
+type Identity struct {
+	Manufacturer string
+	OUI          string
+	ProductClass string
+	SerialNumber string
+}
+
+// informParameters lists the parameters, relative to the root object, that
+// are sent with every Inform when the model defines them
+var informParameters = []string{
+	"DeviceSummary",
+	"DeviceInfo.SpecVersion",
+	"DeviceInfo.HardwareVersion",
+	"DeviceInfo.SoftwareVersion",
+	"DeviceInfo.ProvisioningCode",
+	"ManagementServer.ConnectionRequestURL",
+	"ManagementServer.ParameterKey",
+}
+
+// rpcMethods lists the RPCs a simulated device answers
+var rpcMethods = []string{
+	"GetRPCMethods",
+	"GetParameterNames",
+	"GetParameterValues",
+	"SetParameterValues",
+	"AddObject",
+	"DeleteObject",
+	"Reboot",
+}
+
+// Device is a simulated CPE. It holds a parameter tree instantiated from a
+// data model and runs CWMP sessions with an ACS over HTTP.
+type Device struct {
+	Identity Identity
+	Logger   *log.Logger // Logs sessions when set
+
+	mu        sync.Mutex
+	tree      *Tree
+	messageID int
+	reboot    *Event // Set by a Reboot RPC until the device has rebooted
+}
+
+// NewDevice creates a simulated device. The identity is also written to
+// the DeviceInfo parameters of the model, if it has them.
+func NewDevice(model *models.DataModel, identity Identity) *Device {
+	d := &Device{Identity: identity, tree: NewTree(model)}
+
+	for _, root := range d.tree.Roots() {
+		for name, value := range map[string]string{
+			"DeviceInfo.Manufacturer":    identity.Manufacturer,
+			"DeviceInfo.ManufacturerOUI": identity.OUI,
+			"DeviceInfo.ProductClass":    identity.ProductClass,
+			"DeviceInfo.SerialNumber":    identity.SerialNumber,
+		} {
+			if param, ok := d.tree.Lookup(root + name); ok {
+				param.Value = value
+			}
+		}
+	}
+	return d
+}
+
+// Get returns the value of a parameter of the device
+func (d *Device) Get(path string) (string, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	param, ok := d.tree.Lookup(path)
+	if !ok {
+		return "", false
+	}
+	return param.Value, true
+}
+
+// Set sets a parameter of the device, checking its value against the model
+// but not its access, as the device itself would
+func (d *Device) Set(path, value string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if fault := d.tree.Set([]ParameterValue{{Name: path, Value: value}}, true); fault != nil {
+		return fmt.Errorf("%s: %s", path, fault.SetParameterValuesFaults[0].FaultString)
+	}
+	return nil
+}
+
+// logf logs a message prefixed with the serial number of the device
+func (d *Device) logf(format string, args ...any) {
+	if d.Logger != nil {
+		d.Logger.Printf("%s: %s", d.Identity.SerialNumber, fmt.Sprintf(format, args...))
+	}
+}
+
+// Run informs the ACS with BOOTSTRAP and BOOT, then with PERIODIC every
+// interval and with BOOT and M Reboot after every Reboot, until ctx is
+// done. Events of failed sessions are retried after retryInterval.
+func (d *Device) Run(ctx context.Context, acsURL string, interval, retryInterval time.Duration) {
+	pending := []Event{{Code: EventBootstrap}, {Code: EventBoot}}
+
+	var periodic <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		periodic = ticker.C
+	}
+
+	for {
+		var retry <-chan time.Time
+		if len(pending) > 0 {
+			if err := d.Session(ctx, acsURL, pending); err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				d.logf("session failed: %v", err)
+				retry = time.After(retryInterval)
+			} else {
+				pending = nil
+				if event := d.takeReboot(); event != nil {
+					d.logf("rebooting")
+					pending = []Event{{Code: EventBoot}, *event}
+					continue
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-periodic:
+			pending = appendEvent(pending, Event{Code: EventPeriodic})
+		case <-retry:
+		}
+	}
+}
+
+// appendEvent adds an event unless an event with the same code is pending
+func appendEvent(events []Event, event Event) []Event {
+	for _, pending := range events {
+		if pending.Code == event.Code {
+			return events
+		}
+	}
+	return append(events, event)
+}
+
+// takeReboot returns and clears the M Reboot event of a requested reboot
+func (d *Device) takeReboot() *Event {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	event := d.reboot
+	d.reboot = nil
+	return event
+}
+
+// Session runs one CWMP session: it sends an Inform with the events, then
+// an empty POST, and answers the requests of the ACS until it replies with
+// an empty response
+func (d *Device) Session(ctx context.Context, acsURL string, events []Event) error {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return err
+	}
+	client := &http.Client{Jar: jar, Timeout: 30 * time.Second}
+
+	body, err := marshalEnvelope(d.nextMessageID(), d.inform(events))
+	if err != nil {
+		return err
+	}
+	response, err := post(ctx, client, acsURL, body)
+	if err != nil {
+		return err
+	}
+	if response == nil {
+		return fmt.Errorf("empty response to Inform")
+	}
+	msg, err := parseMessage(response)
+	if err != nil {
+		return err
+	}
+	if msg.Method == "Fault" {
+		var fault faultResponse
+		if err := msg.decode(&fault); err != nil {
+			return err
+		}
+		return &Fault{Code: fault.Detail.Fault.FaultCode, Message: fault.Detail.Fault.FaultString}
+	}
+	if msg.Method != "InformResponse" {
+		return fmt.Errorf("expected InformResponse, got %s", msg.Method)
+	}
+	d.logf("informed %s", eventCodes(events))
+
+	body = nil
+	for {
+		response, err := post(ctx, client, acsURL, body)
+		if err != nil {
+			return err
+		}
+		if response == nil {
+			return nil
+		}
+		msg, err := parseMessage(response)
+		if err != nil {
+			return err
+		}
+		d.logf("received %s", msg.Method)
+		if body, err = marshalEnvelope(msg.ID, d.handle(msg)); err != nil {
+			return err
+		}
+	}
+}
+
+// eventCodes joins the codes of events for logging
+func eventCodes(events []Event) string {
+	codes := make([]string, len(events))
+	for i, event := range events {
+		codes[i] = event.Code
+	}
+	return strings.Join(codes, ", ")
+}
+
+// nextMessageID returns the ID of the next request sent by the device
+func (d *Device) nextMessageID() string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.messageID++
+	return strconv.Itoa(d.messageID)
+}
+
+// post sends a SOAP message, or an empty POST for a nil body, and returns
+// the response body, or nil when the ACS sent an empty response
+func post(ctx context.Context, client *http.Client, url string, body []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", `text/xml; charset="utf-8"`)
+		req.Header.Set("SOAPAction", "")
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case resp.StatusCode == http.StatusNoContent:
+		return nil, nil
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("ACS responded with %s", resp.Status)
+	case len(bytes.TrimSpace(data)) == 0:
+		return nil, nil
+	}
+	return data, nil
+}
+
+// inform builds the Inform message reporting events
+func (d *Device) inform(events []Event) *inform {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	msg := &inform{
+		DeviceID: deviceID{
+			Manufacturer: d.Identity.Manufacturer,
+			OUI:          d.Identity.OUI,
+			ProductClass: d.Identity.ProductClass,
+			SerialNumber: d.Identity.SerialNumber,
+		},
+		MaxEnvelopes: 1,
+		CurrentTime:  time.Now().UTC().Format(time.RFC3339),
+	}
+	for _, event := range events {
+		msg.Event.Events = append(msg.Event.Events, eventStruct{EventCode: event.Code, CommandKey: event.CommandKey})
+	}
+	msg.Event.ArrayType = fmt.Sprintf("cwmp:EventStruct[%d]", len(msg.Event.Events))
+
+	for _, root := range d.tree.Roots() {
+		for _, name := range informParameters {
+			if param, ok := d.tree.Lookup(root + name); ok {
+				msg.ParameterList.Params = append(msg.ParameterList.Params, parameterValue(param))
+			}
+		}
+	}
+	msg.ParameterList.ArrayType = fmt.Sprintf("cwmp:ParameterValueStruct[%d]", len(msg.ParameterList.Params))
+	return msg
+}
+
+// parameterValue reports a parameter as a ParameterValueStruct
+func parameterValue(param *Parameter) parameterValueStruct {
+	value := param.Value
+	if param.Hidden {
+		value = ""
+	}
+	return parameterValueStruct{Name: param.Path, Value: typedValue{Type: param.XsdType(), Value: value}}
+}
+
+// handle answers a request of the ACS with its response or a fault
+func (d *Device) handle(msg *message) any {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	response, fault := d.dispatch(msg)
+	if fault != nil {
+		d.logf("%s failed: %v", msg.Method, fault)
+		return newSOAPFault(fault)
+	}
+	return response
+}
+
+// dispatch runs an RPC against the parameter tree
+func (d *Device) dispatch(msg *message) (any, *Fault) {
+	switch msg.Method {
+	case "GetRPCMethods":
+		return &getRPCMethodsResponse{MethodList: methodList{
+			ArrayType: fmt.Sprintf("xsd:string[%d]", len(rpcMethods)),
+			Methods:   rpcMethods,
+		}}, nil
+
+	case "GetParameterValues":
+		var req getParameterValues
+		if err := msg.decode(&req); err != nil {
+			return nil, newFault(FaultInvalidArguments, "%v", err)
+		}
+		response := &getParameterValuesResponse{}
+		for _, name := range req.ParameterNames {
+			params, fault := d.tree.Get(name)
+			if fault != nil {
+				return nil, fault
+			}
+			for _, param := range params {
+				response.ParameterList.Params = append(response.ParameterList.Params, parameterValue(param))
+			}
+		}
+		response.ParameterList.ArrayType = fmt.Sprintf("cwmp:ParameterValueStruct[%d]", len(response.ParameterList.Params))
+		return response, nil
+
+	case "GetParameterNames":
+		var req getParameterNames
+		if err := msg.decode(&req); err != nil {
+			return nil, newFault(FaultInvalidArguments, "%v", err)
+		}
+		names, fault := d.tree.Names(req.ParameterPath, req.NextLevel == "1" || req.NextLevel == "true")
+		if fault != nil {
+			return nil, fault
+		}
+		paths := make([]string, 0, len(names))
+		for path := range names {
+			paths = append(paths, path)
+		}
+		sort.Slice(paths, func(i, j int) bool { return lessInstancePath(paths[i], paths[j]) })
+		response := &getParameterNamesResponse{}
+		for _, path := range paths {
+			response.ParameterList.Params = append(response.ParameterList.Params, parameterInfoStruct{Name: path, Writable: names[path]})
+		}
+		response.ParameterList.ArrayType = fmt.Sprintf("cwmp:ParameterInfoStruct[%d]", len(paths))
+		return response, nil
+
+	case "SetParameterValues":
+		var req setParameterValues
+		if err := msg.decode(&req); err != nil {
+			return nil, newFault(FaultInvalidArguments, "%v", err)
+		}
+		if fault := d.tree.Set(req.ParameterList, false); fault != nil {
+			return nil, fault
+		}
+		d.setParameterKey(req.ParameterKey)
+		return &setParameterValuesResponse{Status: 0}, nil
+
+	case "AddObject":
+		var req addObject
+		if err := msg.decode(&req); err != nil {
+			return nil, newFault(FaultInvalidArguments, "%v", err)
+		}
+		instance, fault := d.tree.AddObject(req.ObjectName)
+		if fault != nil {
+			return nil, fault
+		}
+		d.setParameterKey(req.ParameterKey)
+		return &addObjectResponse{InstanceNumber: instance, Status: 0}, nil
+
+	case "DeleteObject":
+		var req deleteObject
+		if err := msg.decode(&req); err != nil {
+			return nil, newFault(FaultInvalidArguments, "%v", err)
+		}
+		if fault := d.tree.DeleteObject(req.ObjectName); fault != nil {
+			return nil, fault
+		}
+		d.setParameterKey(req.ParameterKey)
+		return &deleteObjectResponse{Status: 0}, nil
+
+	case "Reboot":
+		var req reboot
+		if err := msg.decode(&req); err != nil {
+			return nil, newFault(FaultInvalidArguments, "%v", err)
+		}
+		d.reboot = &Event{Code: EventMReboot, CommandKey: req.CommandKey}
+		return &rebootResponse{}, nil
+	}
+	return nil, newFault(FaultMethodNotSupported, "%s is not supported", msg.Method)
+}
+
+// setParameterKey records the ParameterKey of a successful change in
+// ManagementServer.ParameterKey
+func (d *Device) setParameterKey(key string) {
+	for _, root := range d.tree.Roots() {
+		if param, ok := d.tree.Lookup(root + "ManagementServer.ParameterKey"); ok {
+			param.Value = key
+		}
+	}
+}
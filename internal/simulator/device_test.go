@@ -0,0 +1,169 @@
+package simulator
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// scriptedACS is a test ACS that answers every Inform and then sends its
+// requests in order, recording what the device sent
+type scriptedACS struct {
+	mu       sync.Mutex
+	requests []string
+	received []string
+	informs  []string
+	sessions map[string]bool
+}
+
+func (a *scriptedACS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	body, _ := io.ReadAll(r.Body)
+	if strings.Contains(string(body), "<cwmp:Inform>") {
+		a.informs = append(a.informs, string(body))
+		if a.sessions == nil {
+			a.sessions = make(map[string]bool)
+		}
+		id := fmt.Sprintf("session-%d", len(a.informs))
+		a.sessions[id] = true
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: id})
+		io.WriteString(w, soapMessage("1", "<cwmp:InformResponse><MaxEnvelopes>1</MaxEnvelopes></cwmp:InformResponse>"))
+		return
+	}
+	if cookie, err := r.Cookie("session"); err != nil || !a.sessions[cookie.Value] {
+		http.Error(w, "no session", http.StatusForbidden)
+		return
+	}
+	if len(body) > 0 {
+		a.received = append(a.received, string(body))
+	}
+	if len(a.requests) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	io.WriteString(w, soapMessage("req", a.requests[0]))
+	a.requests = a.requests[1:]
+}
+
+// soapMessage wraps an RPC in a SOAP envelope as an ACS would send it
+func soapMessage(id, content string) string {
+	return `<soapenv:Envelope xmlns:soapenv="http://schemas.xmlsoap.org/soap/envelope/" xmlns:cwmp="urn:dslforum-org:cwmp-1-0">` +
+		`<soapenv:Header><cwmp:ID soapenv:mustUnderstand="1">` + id + `</cwmp:ID></soapenv:Header>` +
+		`<soapenv:Body>` + content + `</soapenv:Body></soapenv:Envelope>`
+}
+
+func TestDeviceSession(t *testing.T) {
+	acs := &scriptedACS{requests: []string{
+		`<cwmp:GetParameterValues><ParameterNames><string>Device.Host.1.</string></ParameterNames></cwmp:GetParameterValues>`,
+		`<cwmp:SetParameterValues><ParameterList><ParameterValueStruct><Name>Device.Host.1.Status</Name><Value>Up</Value></ParameterValueStruct></ParameterList><ParameterKey>key-1</ParameterKey></cwmp:SetParameterValues>`,
+		`<cwmp:SetParameterValues><ParameterList><ParameterValueStruct><Name>Device.DeviceInfo.SerialNumber</Name><Value>X</Value></ParameterValueStruct></ParameterList><ParameterKey>key-2</ParameterKey></cwmp:SetParameterValues>`,
+		`<cwmp:AddObject><ObjectName>Device.Host.</ObjectName><ParameterKey>key-3</ParameterKey></cwmp:AddObject>`,
+		`<cwmp:DeleteObject><ObjectName>Device.Host.1.</ObjectName><ParameterKey>key-4</ParameterKey></cwmp:DeleteObject>`,
+		`<cwmp:Download><URL>http://example.com/fw</URL></cwmp:Download>`,
+		`<cwmp:Reboot><CommandKey>reboot-1</CommandKey></cwmp:Reboot>`,
+	}}
+	server := httptest.NewServer(acs)
+	defer server.Close()
+
+	devices := Devices(testModel(), Config{Devices: 1, SerialPrefix: "TEST"})
+	device := devices[0]
+	if err := device.Session(context.Background(), server.URL, []Event{{Code: EventBootstrap}, {Code: EventBoot}}); err != nil {
+		t.Fatalf("Session returned error: %v", err)
+	}
+
+	if len(acs.informs) != 1 {
+		t.Fatalf("Expected one Inform, got %d", len(acs.informs))
+	}
+	for _, expected := range []string{
+		"<SerialNumber>TEST000001</SerialNumber>",
+		"<EventCode>0 BOOTSTRAP</EventCode>",
+		"<EventCode>1 BOOT</EventCode>",
+		`<Name>Device.DeviceInfo.SoftwareVersion</Name>`,
+		`<Value xsi:type="xsd:string">1.0</Value>`,
+	} {
+		if !strings.Contains(acs.informs[0], expected) {
+			t.Errorf("Expected Inform to contain %q", expected)
+		}
+	}
+
+	if len(acs.received) != 7 {
+		t.Fatalf("Expected 7 responses, got %d", len(acs.received))
+	}
+	for i, expected := range []string{
+		`<Name>Device.Host.1.Enable</Name>`,
+		"<cwmp:SetParameterValuesResponse>",
+		"<FaultCode>9008</FaultCode>",
+		"<InstanceNumber>2</InstanceNumber>",
+		"<cwmp:DeleteObjectResponse>",
+		"<FaultCode>9000</FaultCode>",
+		"<cwmp:RebootResponse>",
+	} {
+		if !strings.Contains(acs.received[i], expected) {
+			t.Errorf("Expected response %d to contain %q, got %s", i, expected, acs.received[i])
+		}
+		if !strings.Contains(acs.received[i], `<cwmp:ID soap-env:mustUnderstand="1">req</cwmp:ID>`) {
+			t.Errorf("Expected response %d to echo the request ID", i)
+		}
+	}
+
+	if value, _ := device.Get("Device.ManagementServer.ParameterKey"); value != "key-4" {
+		t.Errorf("Expected ParameterKey key-4, got %q", value)
+	}
+	if _, ok := device.Get("Device.Host.1.Status"); ok {
+		t.Error("Expected Device.Host.1. to be deleted")
+	}
+	if event := device.takeReboot(); event == nil || event.CommandKey != "reboot-1" {
+		t.Errorf("Expected a pending M Reboot event, got %+v", event)
+	}
+}
+
+func TestRun(t *testing.T) {
+	acs := &scriptedACS{requests: []string{`<cwmp:Reboot><CommandKey>k</CommandKey></cwmp:Reboot>`}}
+	server := httptest.NewServer(acs)
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- Run(ctx, testModel(), Config{ACSURL: server.URL, Devices: 3, PeriodicInterval: time.Hour})
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		acs.mu.Lock()
+		informs := append([]string(nil), acs.informs...)
+		acs.mu.Unlock()
+		if len(informs) >= 4 {
+			bootstraps, reboots := 0, 0
+			for _, inform := range informs {
+				if strings.Contains(inform, EventBootstrap) {
+					bootstraps++
+				}
+				if strings.Contains(inform, EventMReboot) && strings.Contains(inform, "<CommandKey>k</CommandKey>") {
+					reboots++
+				}
+			}
+			if bootstraps != 3 || reboots != 1 {
+				t.Errorf("Expected 3 BOOTSTRAP informs and one M Reboot inform, got %d and %d", bootstraps, reboots)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected 4 informs, got %d", len(informs))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Run returned error: %v", err)
+	}
+}
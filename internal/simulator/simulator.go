@@ -0,0 +1,83 @@
+// Package simulator simulates CWMP (TR-069) devices for testing an ACS. Each
+// device holds an in-memory parameter tree instantiated from a data model,
+// informs the ACS over HTTP and answers its GetParameterValues,
+// SetParameterValues, GetParameterNames, AddObject, DeleteObject and Reboot
+// requests, enforcing the access rights and value constraints of the model.
+package simulator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Niceblueman/cwmp-codegen/internal/models"
+)
+
+// Config configures a simulation
+type Config struct {
+	ACSURL           string
+	Devices          int           // Number of devices, 1 by default
+	PeriodicInterval time.Duration // Interval of PERIODIC informs, 0 disables them
+	RetryInterval    time.Duration // Delay before retrying a failed session, 10s by default
+	Manufacturer     string
+	OUI              string
+	ProductClass     string
+	SerialPrefix     string      // Serial numbers are the prefix followed by the device number
+	Logger           *log.Logger // Logs the sessions of every device when set
+}
+
+// Devices creates the simulated devices of a configuration
+func Devices(model *models.DataModel, config Config) []*Device {
+	count := config.Devices
+	if count < 1 {
+		count = 1
+	}
+	identity := Identity{
+		Manufacturer: valueOr(config.Manufacturer, "cwmp-codegen"),
+		OUI:          valueOr(config.OUI, "000000"),
+		ProductClass: valueOr(config.ProductClass, "Simulator"),
+	}
+	prefix := valueOr(config.SerialPrefix, "SIM")
+
+	devices := make([]*Device, count)
+	for i := range devices {
+		identity.SerialNumber = fmt.Sprintf("%s%06d", prefix, i+1)
+		devices[i] = NewDevice(model, identity)
+		devices[i].Logger = config.Logger
+	}
+	return devices
+}
+
+// Run simulates the devices of a configuration concurrently until ctx is
+// done
+func Run(ctx context.Context, model *models.DataModel, config Config) error {
+	if config.ACSURL == "" {
+		return errors.New("ACS URL is required")
+	}
+	retryInterval := config.RetryInterval
+	if retryInterval <= 0 {
+		retryInterval = 10 * time.Second
+	}
+
+	var wg sync.WaitGroup
+	for _, device := range Devices(model, config) {
+		wg.Add(1)
+		go func(device *Device) {
+			defer wg.Done()
+			device.Run(ctx, config.ACSURL, config.PeriodicInterval, retryInterval)
+		}(device)
+	}
+	wg.Wait()
+	return nil
+}
+
+// valueOr returns value, or fallback when value is empty
+func valueOr(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
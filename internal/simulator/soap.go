@@ -0,0 +1,307 @@
+package simulator
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+)
+
+// CWMP fault codes raised by the simulated devices
+const (
+	FaultMethodNotSupported    = 9000
+	FaultRequestDenied         = 9001
+	FaultInternalError         = 9002
+	FaultInvalidArguments      = 9003
+	FaultResourcesExceeded     = 9004
+	FaultInvalidParameterName  = 9005
+	FaultInvalidParameterType  = 9006
+	FaultInvalidParameterValue = 9007
+	FaultNonWritableParameter  = 9008
+)
+
+// Fault is a CWMP fault returned to the ACS instead of an RPC response
+type Fault struct {
+	Code                     int
+	Message                  string
+	SetParameterValuesFaults []SetParameterValuesFault
+}
+
+// Error implements the error interface
+func (f *Fault) Error() string {
+	return fmt.Sprintf("CWMP fault %d: %s", f.Code, f.Message)
+}
+
+// SetParameterValuesFault is the fault of one parameter of a rejected
+// SetParameterValues
+type SetParameterValuesFault struct {
+	ParameterName string `xml:"ParameterName"`
+	FaultCode     int    `xml:"FaultCode"`
+	FaultString   string `xml:"FaultString"`
+}
+
+// ParameterValue is a parameter name and value pair of SetParameterValues
+type ParameterValue struct {
+	Name  string `xml:"Name"`
+	Value string `xml:"Value"`
+}
+
+// Event is a CWMP event reported in an Inform
+type Event struct {
+	Code       string
+	CommandKey string
+}
+
+// Event codes reported by the simulated devices
+const (
+	EventBootstrap = "0 BOOTSTRAP"
+	EventBoot      = "1 BOOT"
+	EventPeriodic  = "2 PERIODIC"
+	EventMReboot   = "M Reboot"
+)
+
+// envelope is an outgoing SOAP envelope. The namespace prefixes are spelled
+// out in the element names, as CWMP implementations expect them.
+type envelope struct {
+	XMLName xml.Name       `xml:"soap-env:Envelope"`
+	SoapEnv string         `xml:"xmlns:soap-env,attr"`
+	SoapEnc string         `xml:"xmlns:soap-enc,attr"`
+	Xsd     string         `xml:"xmlns:xsd,attr"`
+	Xsi     string         `xml:"xmlns:xsi,attr"`
+	Cwmp    string         `xml:"xmlns:cwmp,attr"`
+	Header  envelopeHeader `xml:"soap-env:Header"`
+	Body    envelopeBody   `xml:"soap-env:Body"`
+}
+
+type envelopeHeader struct {
+	ID headerID `xml:"cwmp:ID"`
+}
+
+type headerID struct {
+	MustUnderstand string `xml:"soap-env:mustUnderstand,attr"`
+	Value          string `xml:",chardata"`
+}
+
+type envelopeBody struct {
+	Content any
+}
+
+// marshalEnvelope wraps a message in a SOAP envelope with the given ID
+func marshalEnvelope(id string, content any) ([]byte, error) {
+	env := envelope{
+		SoapEnv: "http://schemas.xmlsoap.org/soap/envelope/",
+		SoapEnc: "http://schemas.xmlsoap.org/soap/encoding/",
+		Xsd:     "http://www.w3.org/2001/XMLSchema",
+		Xsi:     "http://www.w3.org/2001/XMLSchema-instance",
+		Cwmp:    "urn:dslforum-org:cwmp-1-0",
+		Header:  envelopeHeader{ID: headerID{MustUnderstand: "1", Value: id}},
+		Body:    envelopeBody{Content: content},
+	}
+	data, err := xml.MarshalIndent(env, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+// incomingEnvelope is a SOAP envelope received from the ACS. Elements are
+// matched by local name, whatever prefixes the ACS uses.
+type incomingEnvelope struct {
+	Header struct {
+		ID string `xml:"ID"`
+	} `xml:"Header"`
+	Body struct {
+		Content []byte `xml:",innerxml"`
+	} `xml:"Body"`
+}
+
+// message is the method name and ID of a received SOAP message, with the
+// decoder positioned at its body element
+type message struct {
+	ID      string
+	Method  string
+	decoder *xml.Decoder
+	start   xml.StartElement
+}
+
+// decode decodes the body element of the message into v
+func (m *message) decode(v any) error {
+	return m.decoder.DecodeElement(v, &m.start)
+}
+
+// parseMessage parses a SOAP envelope received from the ACS
+func parseMessage(data []byte) (*message, error) {
+	var env incomingEnvelope
+	if err := xml.Unmarshal(data, &env); err != nil {
+		return nil, fmt.Errorf("invalid SOAP envelope: %w", err)
+	}
+
+	decoder := xml.NewDecoder(bytes.NewReader(env.Body.Content))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, fmt.Errorf("empty SOAP body")
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return &message{ID: env.Header.ID, Method: start.Name.Local, decoder: decoder, start: start}, nil
+		}
+	}
+}
+
+// Messages sent by the device
+
+type inform struct {
+	XMLName       xml.Name           `xml:"cwmp:Inform"`
+	DeviceID      deviceID           `xml:"DeviceId"`
+	Event         eventList          `xml:"Event"`
+	MaxEnvelopes  int                `xml:"MaxEnvelopes"`
+	CurrentTime   string             `xml:"CurrentTime"`
+	RetryCount    int                `xml:"RetryCount"`
+	ParameterList parameterValueList `xml:"ParameterList"`
+}
+
+type deviceID struct {
+	Manufacturer string `xml:"Manufacturer"`
+	OUI          string `xml:"OUI"`
+	ProductClass string `xml:"ProductClass"`
+	SerialNumber string `xml:"SerialNumber"`
+}
+
+type eventList struct {
+	ArrayType string        `xml:"soap-enc:arrayType,attr"`
+	Events    []eventStruct `xml:"EventStruct"`
+}
+
+type eventStruct struct {
+	EventCode  string `xml:"EventCode"`
+	CommandKey string `xml:"CommandKey"`
+}
+
+type parameterValueList struct {
+	ArrayType string                 `xml:"soap-enc:arrayType,attr"`
+	Params    []parameterValueStruct `xml:"ParameterValueStruct"`
+}
+
+type parameterValueStruct struct {
+	Name  string     `xml:"Name"`
+	Value typedValue `xml:"Value"`
+}
+
+type typedValue struct {
+	Type  string `xml:"xsi:type,attr"`
+	Value string `xml:",chardata"`
+}
+
+type getParameterValuesResponse struct {
+	XMLName       xml.Name           `xml:"cwmp:GetParameterValuesResponse"`
+	ParameterList parameterValueList `xml:"ParameterList"`
+}
+
+type setParameterValuesResponse struct {
+	XMLName xml.Name `xml:"cwmp:SetParameterValuesResponse"`
+	Status  int      `xml:"Status"`
+}
+
+type getParameterNamesResponse struct {
+	XMLName       xml.Name          `xml:"cwmp:GetParameterNamesResponse"`
+	ParameterList parameterInfoList `xml:"ParameterList"`
+}
+
+type parameterInfoList struct {
+	ArrayType string                `xml:"soap-enc:arrayType,attr"`
+	Params    []parameterInfoStruct `xml:"ParameterInfoStruct"`
+}
+
+type parameterInfoStruct struct {
+	Name     string `xml:"Name"`
+	Writable bool   `xml:"Writable"`
+}
+
+type addObjectResponse struct {
+	XMLName        xml.Name `xml:"cwmp:AddObjectResponse"`
+	InstanceNumber int      `xml:"InstanceNumber"`
+	Status         int      `xml:"Status"`
+}
+
+type deleteObjectResponse struct {
+	XMLName xml.Name `xml:"cwmp:DeleteObjectResponse"`
+	Status  int      `xml:"Status"`
+}
+
+type rebootResponse struct {
+	XMLName xml.Name `xml:"cwmp:RebootResponse"`
+}
+
+type getRPCMethodsResponse struct {
+	XMLName    xml.Name   `xml:"cwmp:GetRPCMethodsResponse"`
+	MethodList methodList `xml:"MethodList"`
+}
+
+type methodList struct {
+	ArrayType string   `xml:"soap-enc:arrayType,attr"`
+	Methods   []string `xml:"string"`
+}
+
+type soapFault struct {
+	XMLName     xml.Name `xml:"soap-env:Fault"`
+	FaultCode   string   `xml:"faultcode"`
+	FaultString string   `xml:"faultstring"`
+	Detail      struct {
+		Fault cwmpFault `xml:"cwmp:Fault"`
+	} `xml:"detail"`
+}
+
+type cwmpFault struct {
+	FaultCode                int                       `xml:"FaultCode"`
+	FaultString              string                    `xml:"FaultString"`
+	SetParameterValuesFaults []SetParameterValuesFault `xml:"SetParameterValuesFault"`
+}
+
+// newSOAPFault wraps a CWMP fault in a SOAP fault
+func newSOAPFault(fault *Fault) *soapFault {
+	f := &soapFault{FaultCode: "Client", FaultString: "CWMP fault"}
+	if fault.Code == FaultInternalError {
+		f.FaultCode = "Server"
+	}
+	f.Detail.Fault = cwmpFault{
+		FaultCode:                fault.Code,
+		FaultString:              fault.Message,
+		SetParameterValuesFaults: fault.SetParameterValuesFaults,
+	}
+	return f
+}
+
+// Messages received from the ACS
+
+type getParameterValues struct {
+	ParameterNames []string `xml:"ParameterNames>string"`
+}
+
+type setParameterValues struct {
+	ParameterList []ParameterValue `xml:"ParameterList>ParameterValueStruct"`
+	ParameterKey  string           `xml:"ParameterKey"`
+}
+
+type getParameterNames struct {
+	ParameterPath string `xml:"ParameterPath"`
+	NextLevel     string `xml:"NextLevel"`
+}
+
+type addObject struct {
+	ObjectName   string `xml:"ObjectName"`
+	ParameterKey string `xml:"ParameterKey"`
+}
+
+type deleteObject struct {
+	ObjectName   string `xml:"ObjectName"`
+	ParameterKey string `xml:"ParameterKey"`
+}
+
+type reboot struct {
+	CommandKey string `xml:"CommandKey"`
+}
+
+type faultResponse struct {
+	Detail struct {
+		Fault cwmpFault `xml:"Fault"`
+	} `xml:"detail"`
+}
@@ -0,0 +1,365 @@
+package simulator
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Niceblueman/cwmp-codegen/internal/models"
+)
+
+// Parameter is a parameter of a simulated device
+type Parameter struct {
+	Path     string
+	Value    string
+	Writable bool
+	Hidden   bool // Whether reads return an empty string
+	syntax   valueSyntax
+}
+
+// XsdType returns the xsi:type the parameter is reported with
+func (p *Parameter) XsdType() string {
+	return p.syntax.XsdType()
+}
+
+// Tree is the in-memory parameter tree of a simulated device. Objects are
+// instantiated from the data model with their parameters set to the model
+// defaults; tables start with their minimum number of entries.
+type Tree struct {
+	model        *models.DataModel
+	params       map[string]*Parameter
+	objects      map[string]string          // Concrete object path -> object template path
+	nextInstance map[string]int             // Table path -> last instance number used
+	templates    map[string]*models.Object  // Object template path -> object
+	children     map[string][]string        // Object template path -> nearest descendant templates
+	dataTypes    map[string]models.DataType // Named dataTypes of the model
+}
+
+// NewTree instantiates the parameter tree of a data model
+func NewTree(model *models.DataModel) *Tree {
+	t := &Tree{
+		model:        model,
+		params:       make(map[string]*Parameter),
+		objects:      make(map[string]string),
+		nextInstance: make(map[string]int),
+		templates:    make(map[string]*models.Object),
+		children:     make(map[string][]string),
+		dataTypes:    make(map[string]models.DataType),
+	}
+	for _, dataType := range model.DataTypes {
+		t.dataTypes[dataType.Name] = dataType
+	}
+
+	// Link every template to its nearest ancestor template, the root
+	// templates to the pseudo-template ""
+	model.Walk(func(node *models.Node) error {
+		if !node.IsObject() {
+			return nil
+		}
+		parent := ""
+		if node.Parent != nil {
+			parent = node.Parent.Path
+		}
+		t.templates[node.Path] = node.Object
+		t.children[parent] = append(t.children[parent], node.Path)
+		return nil
+	})
+
+	t.instantiate("", "")
+	return t
+}
+
+// instantiate creates the object at a concrete path from its template,
+// together with its parameters and the objects below it
+func (t *Tree) instantiate(path, template string) {
+	if obj := t.templates[template]; obj != nil {
+		t.objects[path] = template
+		for _, param := range t.model.ParametersOf(template) {
+			t.params[path+param.Name] = t.newParameter(path+param.Name, *param)
+		}
+	}
+
+	for _, child := range t.children[template] {
+		relative := strings.TrimPrefix(child, template)
+		table, rest, isTable := strings.Cut(relative, "{i}.")
+		t.addObjects(path, table)
+		switch {
+		case !isTable:
+			t.instantiate(path+relative, child)
+		case rest == "":
+			min, _ := strconv.Atoi(t.templates[child].MinEntries)
+			for i := 0; i < min; i++ {
+				t.addInstance(path+table, child)
+			}
+		}
+		// Entries of tables below another table are only created with the
+		// entries of the enclosing table, which the model does not define
+	}
+}
+
+// addObjects creates the objects along a relative path below an object that
+// have no template of their own
+func (t *Tree) addObjects(path, relative string) {
+	for _, segment := range strings.Split(strings.TrimSuffix(relative, "."), ".") {
+		if segment == "" {
+			continue
+		}
+		path += segment + "."
+		if _, ok := t.objects[path]; !ok {
+			t.objects[path] = ""
+		}
+	}
+}
+
+// newParameter creates a parameter set to its default value
+func (t *Tree) newParameter(path string, param models.Parameter) *Parameter {
+	syntax := resolveSyntax(param, t.dataTypes)
+	value := syntax.zeroValue()
+	if param.Syntax.Default != nil && param.Syntax.Default.Type != "parameter" {
+		value = param.Syntax.Default.Value
+	}
+	return &Parameter{
+		Path:     path,
+		Value:    value,
+		Writable: param.Access == "readWrite",
+		Hidden:   param.Syntax.Hidden == "true",
+		syntax:   syntax,
+	}
+}
+
+// addInstance creates the next entry of a table and returns its instance
+// number
+func (t *Tree) addInstance(table, template string) int {
+	n := t.nextInstance[table] + 1
+	t.nextInstance[table] = n
+	t.instantiate(table+strconv.Itoa(n)+".", template)
+	t.updateNumberOfEntries(table, template)
+	return n
+}
+
+// entries returns the number of entries of a table
+func (t *Tree) entries(table string) int {
+	count := 0
+	for path := range t.objects {
+		if rest, ok := strings.CutPrefix(path, table); ok && strings.Count(rest, ".") == 1 && models.IsInstanceNumber(strings.TrimSuffix(rest, ".")) {
+			count++
+		}
+	}
+	return count
+}
+
+// updateNumberOfEntries sets the numEntriesParameter of a table, if the
+// model names one
+func (t *Tree) updateNumberOfEntries(table, template string) {
+	name := t.templates[template].NumEntriesParameter
+	if name == "" {
+		return
+	}
+	parent := strings.TrimSuffix(table, ".")
+	parent = parent[:strings.LastIndex(parent, ".")+1]
+	if param, ok := t.params[parent+name]; ok {
+		param.Value = strconv.Itoa(t.entries(table))
+	}
+}
+
+// Lookup returns the parameter at a concrete path
+func (t *Tree) Lookup(path string) (*Parameter, bool) {
+	param, ok := t.params[path]
+	return param, ok
+}
+
+// HasObject reports whether an object exists at a concrete path
+func (t *Tree) HasObject(path string) bool {
+	_, ok := t.objects[path]
+	return ok
+}
+
+// Roots returns the paths of the top-level objects
+func (t *Tree) Roots() []string {
+	roots := []string{}
+	for path := range t.objects {
+		if strings.Count(path, ".") == 1 {
+			roots = append(roots, path)
+		}
+	}
+	sort.Strings(roots)
+	return roots
+}
+
+// Get returns the parameter at a path, or every parameter below a partial
+// path ending in a dot, in tree order. An empty path selects every
+// parameter.
+func (t *Tree) Get(path string) ([]*Parameter, *Fault) {
+	if path != "" && !strings.HasSuffix(path, ".") {
+		param, ok := t.params[path]
+		if !ok {
+			return nil, newFault(FaultInvalidParameterName, "%s does not exist", path)
+		}
+		return []*Parameter{param}, nil
+	}
+	if path != "" && !t.HasObject(path) {
+		return nil, newFault(FaultInvalidParameterName, "%s does not exist", path)
+	}
+
+	params := []*Parameter{}
+	for name, param := range t.params {
+		if strings.HasPrefix(name, path) {
+			params = append(params, param)
+		}
+	}
+	sort.Slice(params, func(i, j int) bool { return lessInstancePath(params[i].Path, params[j].Path) })
+	return params, nil
+}
+
+// Names returns the parameters and objects below a partial path, or only
+// the ones directly below it with nextLevel, as GetParameterNames reports
+// them. Writable is true for objects that accept AddObject or DeleteObject.
+func (t *Tree) Names(path string, nextLevel bool) (map[string]bool, *Fault) {
+	if path != "" && !strings.HasSuffix(path, ".") {
+		param, ok := t.params[path]
+		if !ok || nextLevel {
+			return nil, newFault(FaultInvalidParameterName, "%s is not a valid partial path", path)
+		}
+		return map[string]bool{path: param.Writable}, nil
+	}
+	if path != "" && !t.HasObject(path) {
+		return nil, newFault(FaultInvalidParameterName, "%s does not exist", path)
+	}
+
+	names := make(map[string]bool)
+	below := func(name string) bool {
+		rest, ok := strings.CutPrefix(name, path)
+		if !ok || rest == "" {
+			return false
+		}
+		if !nextLevel {
+			return true
+		}
+		return strings.Count(strings.TrimSuffix(rest, "."), ".") == 0
+	}
+	for name, param := range t.params {
+		if below(name) {
+			names[name] = param.Writable
+		}
+	}
+	for name := range t.objects {
+		if below(name) {
+			names[name] = t.creatable(name) || t.deletable(name)
+		}
+	}
+	if path != "" && !nextLevel {
+		names[path] = t.creatable(path) || t.deletable(path)
+	}
+	return names, nil
+}
+
+// creatable reports whether AddObject may create entries of a table
+func (t *Tree) creatable(table string) bool {
+	obj := t.templates[models.SchemaPath(table)+"{i}."]
+	return obj != nil && obj.Access == "readWrite"
+}
+
+// deletable reports whether DeleteObject may delete a table entry
+func (t *Tree) deletable(path string) bool {
+	template := t.objects[path]
+	return strings.HasSuffix(template, ".{i}.") && t.templates[template].Access == "readWrite"
+}
+
+// Set validates and applies parameter values as one transaction: either
+// every value is set or, on the first invalid one, none is. Read-only
+// parameters are rejected unless force is set.
+func (t *Tree) Set(values []ParameterValue, force bool) *Fault {
+	faults := []SetParameterValuesFault{}
+	for _, value := range values {
+		param, ok := t.params[value.Name]
+		var fault *Fault
+		switch {
+		case !ok:
+			fault = newFault(FaultInvalidParameterName, "%s does not exist", value.Name)
+		case !param.Writable && !force:
+			fault = newFault(FaultNonWritableParameter, "%s is not writable", value.Name)
+		default:
+			fault = param.syntax.validate(value.Value)
+		}
+		if fault != nil {
+			faults = append(faults, SetParameterValuesFault{
+				ParameterName: value.Name,
+				FaultCode:     fault.Code,
+				FaultString:   fault.Message,
+			})
+		}
+	}
+	if len(faults) > 0 {
+		return &Fault{Code: FaultInvalidArguments, Message: "Invalid arguments", SetParameterValuesFaults: faults}
+	}
+
+	for _, value := range values {
+		t.params[value.Name].Value = value.Value
+	}
+	return nil
+}
+
+// AddObject creates a new entry of the table at a path ending in a dot and
+// returns its instance number
+func (t *Tree) AddObject(table string) (int, *Fault) {
+	template := models.SchemaPath(table) + "{i}."
+	obj := t.templates[template]
+	switch {
+	case !strings.HasSuffix(table, ".") || !t.HasObject(table) || obj == nil:
+		return 0, newFault(FaultInvalidParameterName, "%s is not a table", table)
+	case obj.Access != "readWrite":
+		return 0, newFault(FaultRequestDenied, "entries of %s cannot be created", table)
+	}
+	if max, err := strconv.Atoi(obj.MaxEntries); err == nil && t.entries(table) >= max {
+		return 0, newFault(FaultResourcesExceeded, "%s already has %d entries", table, max)
+	}
+	return t.addInstance(table, template), nil
+}
+
+// DeleteObject deletes a table entry, given by its path ending in a dot,
+// and everything below it
+func (t *Tree) DeleteObject(path string) *Fault {
+	if !strings.HasSuffix(path, ".") || !t.HasObject(path) || !strings.HasSuffix(t.objects[path], ".{i}.") {
+		return newFault(FaultInvalidParameterName, "%s is not a table entry", path)
+	}
+	template := t.objects[path]
+	if !t.deletable(path) {
+		return newFault(FaultRequestDenied, "%s cannot be deleted", path)
+	}
+	table := strings.TrimSuffix(path, ".")
+	table = table[:strings.LastIndex(table, ".")+1]
+	if min, err := strconv.Atoi(t.templates[template].MinEntries); err == nil && t.entries(table) <= min {
+		return newFault(FaultRequestDenied, "%s needs at least %d entries", table, min)
+	}
+
+	for name := range t.params {
+		if strings.HasPrefix(name, path) {
+			delete(t.params, name)
+		}
+	}
+	for name := range t.objects {
+		if strings.HasPrefix(name, path) {
+			delete(t.objects, name)
+		}
+	}
+	t.updateNumberOfEntries(table, template)
+	return nil
+}
+
+// lessInstancePath orders paths segment by segment, comparing instance
+// numbers numerically
+func lessInstancePath(a, b string) bool {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if as[i] == bs[i] {
+			continue
+		}
+		if models.IsInstanceNumber(as[i]) && models.IsInstanceNumber(bs[i]) {
+			an, _ := strconv.Atoi(as[i])
+			bn, _ := strconv.Atoi(bs[i])
+			return an < bn
+		}
+		return as[i] < bs[i]
+	}
+	return len(as) < len(bs)
+}
@@ -0,0 +1,196 @@
+package simulator
+
+import (
+	"testing"
+
+	"github.com/Niceblueman/cwmp-codegen/internal/models"
+)
+
+// testModel returns a model with a writable table, constrained parameters
+// and a ManagementServer object
+func testModel() *models.DataModel {
+	return &models.DataModel{
+		Name: "Device:2.1",
+		Objects: []models.Object{
+			{Name: "Device.", Path: "Device.", Parameters: []models.Parameter{
+				{Name: "HostNumberOfEntries", Type: "unsignedInt", Access: "readOnly"},
+			}},
+			{Name: "Device.DeviceInfo.", Path: "Device.DeviceInfo.", Parameters: []models.Parameter{
+				{Name: "SerialNumber", Type: "string", Access: "readOnly"},
+				{Name: "SoftwareVersion", Type: "string", Access: "readOnly", Syntax: models.Syntax{Default: &models.Default{Type: "object", Value: "1.0"}}},
+			}},
+			{Name: "Device.ManagementServer.", Path: "Device.ManagementServer.", Parameters: []models.Parameter{
+				{Name: "ParameterKey", Type: "string", Access: "readOnly"},
+				{Name: "PeriodicInformInterval", Type: "unsignedInt", Access: "readWrite", Syntax: models.Syntax{
+					UnsignedInt: &models.UnsignedInt{Range: &models.Range{MinInclusive: "1"}},
+				}},
+			}},
+			{
+				Name:                "Device.Host.{i}.",
+				Path:                "Device.Host.{i}.",
+				Access:              "readWrite",
+				MinEntries:          "1",
+				MaxEntries:          "3",
+				NumEntriesParameter: "HostNumberOfEntries",
+				Parameters: []models.Parameter{
+					{Name: "Enable", Type: "boolean", Access: "readWrite"},
+					{Name: "Status", Type: "string", Access: "readWrite", Syntax: models.Syntax{String: &models.StringCons{
+						Enumeration: []models.Enumeration{{Value: "Up"}, {Value: "Down"}},
+					}, Default: &models.Default{Type: "object", Value: "Down"}}},
+					{Name: "DNSServers", Type: "list", IsList: true, ItemType: "string", Access: "readWrite", Syntax: models.Syntax{
+						List:   &models.List{MaxItems: "2"},
+						String: &models.StringCons{Size: &models.Size{Max: 15}},
+					}},
+				},
+			},
+			{Name: "Device.Host.{i}.Stats.", Path: "Device.Host.{i}.Stats.", Parameters: []models.Parameter{
+				{Name: "BytesSent", Type: "unsignedLong", Access: "readOnly"},
+			}},
+		},
+	}
+}
+
+func TestNewTree(t *testing.T) {
+	tree := NewTree(testModel())
+
+	for path, expected := range map[string]string{
+		"Device.HostNumberOfEntries":           "1",
+		"Device.DeviceInfo.SoftwareVersion":    "1.0",
+		"Device.Host.1.Enable":                 "false",
+		"Device.Host.1.Status":                 "Down",
+		"Device.Host.1.Stats.BytesSent":        "0",
+		"Device.ManagementServer.ParameterKey": "",
+	} {
+		param, ok := tree.Lookup(path)
+		if !ok {
+			t.Errorf("Expected parameter %s", path)
+			continue
+		}
+		if param.Value != expected {
+			t.Errorf("Expected %s to be %q, got %q", path, expected, param.Value)
+		}
+	}
+
+	if !tree.HasObject("Device.Host.") || !tree.HasObject("Device.Host.1.Stats.") {
+		t.Error("Expected the Host table and its first entry")
+	}
+	if roots := tree.Roots(); len(roots) != 1 || roots[0] != "Device." {
+		t.Errorf("Expected root Device., got %v", roots)
+	}
+}
+
+func TestTreeSet(t *testing.T) {
+	tree := NewTree(testModel())
+
+	for _, tc := range []struct {
+		name  string
+		value string
+		code  int
+	}{
+		{"Device.Host.1.Enable", "yes", FaultInvalidParameterType},
+		{"Device.Host.1.Status", "Unknown", FaultInvalidParameterValue},
+		{"Device.Host.1.DNSServers", "1.1.1.1,8.8.8.8,9.9.9.9", FaultInvalidParameterValue},
+		{"Device.Host.1.DNSServers", "1.1.1.1,a-very-long-hostname", FaultInvalidParameterValue},
+		{"Device.ManagementServer.PeriodicInformInterval", "0", FaultInvalidParameterValue},
+		{"Device.Host.1.Stats.BytesSent", "10", FaultNonWritableParameter},
+		{"Device.Host.2.Enable", "true", FaultInvalidParameterName},
+	} {
+		fault := tree.Set([]ParameterValue{{Name: tc.name, Value: tc.value}}, false)
+		if fault == nil {
+			t.Errorf("Expected setting %s to %q to fail", tc.name, tc.value)
+			continue
+		}
+		if fault.Code != FaultInvalidArguments || fault.SetParameterValuesFaults[0].FaultCode != tc.code {
+			t.Errorf("Expected fault %d for %s=%q, got %+v", tc.code, tc.name, tc.value, fault)
+		}
+	}
+
+	// A rejected value rolls back the whole request
+	fault := tree.Set([]ParameterValue{
+		{Name: "Device.Host.1.Enable", Value: "true"},
+		{Name: "Device.Host.1.Status", Value: "Unknown"},
+	}, false)
+	if fault == nil || len(fault.SetParameterValuesFaults) != 1 {
+		t.Fatalf("Expected one parameter fault, got %+v", fault)
+	}
+	if param, _ := tree.Lookup("Device.Host.1.Enable"); param.Value != "false" {
+		t.Error("Expected a failed SetParameterValues not to change any value")
+	}
+
+	if fault := tree.Set([]ParameterValue{
+		{Name: "Device.Host.1.Enable", Value: "true"},
+		{Name: "Device.Host.1.DNSServers", Value: "1.1.1.1, 8.8.8.8"},
+	}, false); fault != nil {
+		t.Fatalf("Expected valid values to be set, got %v", fault)
+	}
+	if fault := tree.Set([]ParameterValue{{Name: "Device.Host.1.Stats.BytesSent", Value: "10"}}, true); fault != nil {
+		t.Errorf("Expected a forced set of a read-only parameter to succeed, got %v", fault)
+	}
+}
+
+func TestTreeAddDeleteObject(t *testing.T) {
+	tree := NewTree(testModel())
+
+	for _, expected := range []int{2, 3} {
+		instance, fault := tree.AddObject("Device.Host.")
+		if fault != nil || instance != expected {
+			t.Fatalf("Expected instance %d, got %d, %v", expected, instance, fault)
+		}
+	}
+	if _, fault := tree.AddObject("Device.Host."); fault == nil || fault.Code != FaultResourcesExceeded {
+		t.Errorf("Expected adding beyond maxEntries to fail, got %v", fault)
+	}
+	if _, fault := tree.AddObject("Device.DeviceInfo."); fault == nil || fault.Code != FaultInvalidParameterName {
+		t.Errorf("Expected adding to a non-table to fail, got %v", fault)
+	}
+	if _, ok := tree.Lookup("Device.Host.3.Stats.BytesSent"); !ok {
+		t.Error("Expected new entries to have their sub-objects")
+	}
+
+	if fault := tree.DeleteObject("Device.Host.2."); fault != nil {
+		t.Fatalf("DeleteObject returned fault: %v", fault)
+	}
+	if tree.HasObject("Device.Host.2.Stats.") {
+		t.Error("Expected the deleted entry's sub-objects to be gone")
+	}
+	if param, _ := tree.Lookup("Device.HostNumberOfEntries"); param.Value != "2" {
+		t.Errorf("Expected HostNumberOfEntries 2, got %s", param.Value)
+	}
+	if instance, _ := tree.AddObject("Device.Host."); instance != 4 {
+		t.Errorf("Expected instance numbers not to be reused, got %d", instance)
+	}
+	if fault := tree.DeleteObject("Device.Host.1.Stats."); fault == nil {
+		t.Error("Expected deleting a non-entry object to fail")
+	}
+}
+
+func TestTreeNames(t *testing.T) {
+	tree := NewTree(testModel())
+
+	names, fault := tree.Names("Device.Host.1.", true)
+	if fault != nil {
+		t.Fatalf("Names returned fault: %v", fault)
+	}
+	expected := map[string]bool{
+		"Device.Host.1.Enable":     true,
+		"Device.Host.1.Status":     true,
+		"Device.Host.1.DNSServers": true,
+		"Device.Host.1.Stats.":     false,
+	}
+	if len(names) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, names)
+	}
+	for name, writable := range expected {
+		if w, ok := names[name]; !ok || w != writable {
+			t.Errorf("Expected %s with writable %v, got %v", name, writable, names)
+		}
+	}
+
+	names, _ = tree.Names("Device.", false)
+	if !names["Device.Host."] || !names["Device.Host.1."] {
+		t.Error("Expected the table and its entries to be writable objects")
+	}
+	if _, fault := tree.Names("Device.Nothing.", false); fault == nil || fault.Code != FaultInvalidParameterName {
+		t.Errorf("Expected an unknown path to fail, got %v", fault)
+	}
+}
@@ -0,0 +1,249 @@
+package simulator
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Niceblueman/cwmp-codegen/internal/models"
+)
+
+// valueSyntax is the built-in type of a parameter value together with the
+// facets it is checked against
+type valueSyntax struct {
+	Type        string
+	Size        *models.Size
+	Range       *models.Range
+	Patterns    []*regexp.Regexp
+	Enumeration []string
+	List        *models.List
+}
+
+// resolveSyntax returns the value syntax of a parameter, compiling the
+// facets of its built-in type
+func resolveSyntax(param models.Parameter, dataTypes map[string]models.DataType) valueSyntax {
+	resolved := models.ResolveSyntax(param, dataTypes)
+	value := valueSyntax{Type: resolved.Type, Size: resolved.Size, Range: resolved.Range, List: param.Syntax.List}
+	if value.Type == "string" {
+		value.setString(resolved.Size, resolved.Patterns, resolved.Enumeration)
+	}
+	return value
+}
+
+// setString sets a string syntax, compiling its patterns. Patterns Go cannot
+// compile are not enforced.
+func (s *valueSyntax) setString(size *models.Size, patterns []models.Pattern, enumeration []models.Enumeration) {
+	s.Type, s.Size = "string", size
+	for _, pattern := range patterns {
+		expr := pattern.Value
+		if expr == "" {
+			expr = pattern.Content
+		}
+		if re, err := regexp.Compile("^(?:" + expr + ")$"); err == nil && expr != "" {
+			s.Patterns = append(s.Patterns, re)
+		}
+	}
+	for _, enum := range enumeration {
+		s.Enumeration = append(s.Enumeration, enum.Value)
+	}
+}
+
+// XsdType returns the xsi:type a value is reported with. Lists are strings.
+func (s valueSyntax) XsdType() string {
+	if s.List != nil {
+		return "xsd:string"
+	}
+	return "xsd:" + s.Type
+}
+
+// zeroValue returns the value of a parameter without a default
+func (s valueSyntax) zeroValue() string {
+	if s.List != nil {
+		return ""
+	}
+	switch s.Type {
+	case "boolean":
+		return "false"
+	case "dateTime":
+		return "0001-01-01T00:00:00Z"
+	case "unsignedInt", "int", "long", "unsignedLong":
+		if s.Range != nil {
+			if min := rangeMin(s.Range); min != "" {
+				return min
+			}
+		}
+		return "0"
+	}
+	return ""
+}
+
+// validate checks a value against the syntax. It returns a fault with code
+// FaultInvalidParameterType for values of the wrong type and
+// FaultInvalidParameterValue for values violating a facet.
+func (s valueSyntax) validate(value string) *Fault {
+	if s.List == nil {
+		return s.validateItem(value)
+	}
+
+	if s.List.Size != nil && s.List.Size.Max > 0 && utf8.RuneCountInString(value) > s.List.Size.Max {
+		return newFault(FaultInvalidParameterValue, "list longer than %d characters", s.List.Size.Max)
+	}
+	items := []string{}
+	if strings.TrimSpace(value) != "" {
+		for _, item := range strings.Split(value, ",") {
+			items = append(items, strings.TrimSpace(item))
+		}
+	}
+	if min, err := strconv.Atoi(s.List.MinItems); err == nil && len(items) < min {
+		return newFault(FaultInvalidParameterValue, "list has fewer than %d items", min)
+	}
+	if max, err := strconv.Atoi(s.List.MaxItems); err == nil && len(items) > max {
+		return newFault(FaultInvalidParameterValue, "list has more than %d items", max)
+	}
+	for _, item := range items {
+		if fault := s.validateItem(item); fault != nil {
+			return fault
+		}
+	}
+	return nil
+}
+
+// validateItem checks a single value, or a single item of a list
+func (s valueSyntax) validateItem(value string) *Fault {
+	switch s.Type {
+	case "boolean":
+		switch value {
+		case "0", "1", "true", "false":
+			return nil
+		}
+		return newFault(FaultInvalidParameterType, "%q is not a boolean", value)
+	case "dateTime":
+		if _, err := time.Parse(time.RFC3339, value); err != nil {
+			return newFault(FaultInvalidParameterType, "%q is not a dateTime", value)
+		}
+		return nil
+	case "unsignedInt", "int", "long", "unsignedLong":
+		return s.validateInteger(value)
+	case "base64":
+		data, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return newFault(FaultInvalidParameterType, "%q is not base64", value)
+		}
+		return s.validateSize(len(data))
+	case "hexBinary":
+		data, err := hex.DecodeString(value)
+		if err != nil {
+			return newFault(FaultInvalidParameterType, "%q is not hexBinary", value)
+		}
+		return s.validateSize(len(data))
+	}
+
+	if fault := s.validateSize(utf8.RuneCountInString(value)); fault != nil {
+		return fault
+	}
+	if len(s.Enumeration) > 0 {
+		found := false
+		for _, enum := range s.Enumeration {
+			found = found || enum == value
+		}
+		if !found {
+			return newFault(FaultInvalidParameterValue, "%q is not one of %s", value, strings.Join(s.Enumeration, ", "))
+		}
+	}
+	if len(s.Patterns) > 0 {
+		for _, pattern := range s.Patterns {
+			if pattern.MatchString(value) {
+				return nil
+			}
+		}
+		return newFault(FaultInvalidParameterValue, "%q does not match the parameter's patterns", value)
+	}
+	return nil
+}
+
+// validateInteger checks an integer value against its type and range
+func (s valueSyntax) validateInteger(value string) *Fault {
+	var n, min, max float64
+	switch s.Type {
+	case "int":
+		v, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			return newFault(FaultInvalidParameterType, "%q is not an int", value)
+		}
+		n = float64(v)
+	case "long":
+		v, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return newFault(FaultInvalidParameterType, "%q is not a long", value)
+		}
+		n = float64(v)
+	case "unsignedInt":
+		v, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return newFault(FaultInvalidParameterType, "%q is not an unsignedInt", value)
+		}
+		n = float64(v)
+	default:
+		v, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return newFault(FaultInvalidParameterType, "%q is not an unsignedLong", value)
+		}
+		n = float64(v)
+	}
+
+	if s.Range == nil {
+		return nil
+	}
+	var err error
+	if bound := rangeMin(s.Range); bound != "" {
+		if min, err = strconv.ParseFloat(bound, 64); err == nil && n < min {
+			return newFault(FaultInvalidParameterValue, "%s is below the minimum %s", value, bound)
+		}
+	}
+	if bound := rangeMax(s.Range); bound != "" {
+		if max, err = strconv.ParseFloat(bound, 64); err == nil && n > max {
+			return newFault(FaultInvalidParameterValue, "%s is above the maximum %s", value, bound)
+		}
+	}
+	return nil
+}
+
+// validateSize checks a length in characters or bytes against the size facet
+func (s valueSyntax) validateSize(length int) *Fault {
+	if s.Size == nil {
+		return nil
+	}
+	if length < s.Size.Min {
+		return newFault(FaultInvalidParameterValue, "shorter than %d", s.Size.Min)
+	}
+	if s.Size.Max > 0 && length > s.Size.Max {
+		return newFault(FaultInvalidParameterValue, "longer than %d", s.Size.Max)
+	}
+	return nil
+}
+
+// rangeMin returns the lower bound of a range, whichever attribute spells it
+func rangeMin(r *models.Range) string {
+	if r.MinInclusive != "" {
+		return r.MinInclusive
+	}
+	return r.Min
+}
+
+// rangeMax returns the upper bound of a range, whichever attribute spells it
+func rangeMax(r *models.Range) string {
+	if r.MaxInclusive != "" {
+		return r.MaxInclusive
+	}
+	return r.Max
+}
+
+// newFault returns a fault with a formatted message
+func newFault(code int, format string, args ...any) *Fault {
+	return &Fault{Code: code, Message: fmt.Sprintf(format, args...)}
+}
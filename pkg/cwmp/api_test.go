@@ -0,0 +1,234 @@
+package cwmp
+
+import (
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// The signatures of the package functions, checked by the compiler
+var (
+	_ func(io.Reader, Options) (*Model, error)    = Parse
+	_ func(io.Reader, Options) (*Document, error) = ParseDocument
+	_ func(string) bool                           = Compatible
+	_ func(string, string) bool                   = MatchPath
+	_ func(string) string                         = SchemaPath
+	_ func(string, Generator)                     = Register
+	_ func(string) (Generator, bool)              = Lookup
+	_ func() []string                             = Generators
+	_ func(GolangOptions) Generator               = Golang
+	_ func(TypeScriptOptions) Generator           = TypeScript
+	_ func(ProtoOptions) Generator                = Proto
+	_ func(JVMOptions) Generator                  = Kotlin
+	_ func(JVMOptions) Generator                  = Java
+	_ func(string) ReadFS                         = DirFS
+)
+
+// modelAPI is the exported surface of the data model types as of version
+// 1.1.0. Lines may be added when the API grows, but not removed or changed
+// within a major version.
+const modelAPI = `
+Arguments.Objects []models.Object
+Arguments.Parameters []models.Parameter
+Base64.Size *models.Size
+Bibliography.References []models.Reference
+Command.Async bool
+Command.Description string
+Command.Input *models.Arguments
+Command.Name string
+Command.Output *models.Arguments
+DataModel.Ancestors() func(*models.DataModel, string) iter.Seq[*github.com/Niceblueman/cwmp-codegen/internal/models.Node]
+DataModel.Children() func(*models.DataModel, string) []*models.Node
+DataModel.DataTypes []models.DataType
+DataModel.Description string
+DataModel.Lookup() func(*models.DataModel, string) (*models.Node, bool)
+DataModel.Match() func(*models.DataModel, string) []*models.Node
+DataModel.Name string
+DataModel.Objects []models.Object
+DataModel.Parameters []models.Parameter
+DataModel.ParametersOf() func(*models.DataModel, string) []*models.Parameter
+DataModel.Reindex() func(*models.DataModel)
+DataModel.Roots() func(*models.DataModel) []*models.Node
+DataModel.Version string
+DataModel.Walk() func(*models.DataModel, func(*models.Node) error) error
+DataModel.XMLName xml.Name
+DataType.Base string
+DataType.Base64 *models.Base64
+DataType.Boolean *models.Boolean
+DataType.DateTime *models.DateTime
+DataType.Description string
+DataType.HexBinary *models.HexBinary
+DataType.Int *models.Int
+DataType.Long *models.Long
+DataType.Name string
+DataType.String *models.StringType
+DataType.UnsignedInt *models.UnsignedInt
+DataType.UnsignedLong *models.UnsignedLong
+DataTypeRef.Ref string
+Default.Type string
+Default.Value string
+Document.Bibliography models.Bibliography
+Document.DataTypes []models.DataType
+Document.Description string
+Document.Imports []models.Import
+Document.Model() func(*models.Document, string) (*models.DataModel, bool)
+Document.Models []models.DataModel
+Document.Spec string
+Document.XMLName xml.Name
+Document.Xmlns string
+Enumeration.Optional string
+Enumeration.Value string
+Event.Description string
+Event.Name string
+Event.Objects []models.Object
+Event.Parameters []models.Parameter
+HexBinary.Size *models.Size
+Import.Components []models.ImportItem
+Import.DataTypes []models.ImportItem
+Import.File string
+Import.Models []models.ImportItem
+Import.Spec string
+ImportItem.Name string
+ImportItem.Ref string
+Int.Range *models.Range
+List.MaxItems string
+List.MinItems string
+List.Size *models.Size
+Long.Range *models.Range
+Node.Children []*models.Node
+Node.IsObject() func(*models.Node) bool
+Node.IsTable() func(*models.Node) bool
+Node.Name() func(*models.Node) string
+Node.Object *models.Object
+Node.Parameter *models.Parameter
+Node.Parent *models.Node
+Node.Path string
+Object.Access string
+Object.BaseName string
+Object.Commands []models.Command
+Object.Description string
+Object.EnableParameter string
+Object.Events []models.Event
+Object.GetPath() func(*models.Object) string
+Object.HasIndexPlaceholder bool
+Object.IsMultiInstance() func(*models.Object) bool
+Object.MaxEntries string
+Object.MinEntries string
+Object.MultiInstance bool
+Object.Name string
+Object.NumEntriesParameter string
+Object.Objects []models.Object
+Object.Parameters []models.Parameter
+Object.ParentPath string
+Object.Path string
+Object.UniqueKeys []models.UniqueKey
+Parameter.Access string
+Parameter.ActiveNotify string
+Parameter.Description string
+Parameter.ForcedInform bool
+Parameter.FullPath string
+Parameter.GetFullPath() func(*models.Parameter) string
+Parameter.IsList bool
+Parameter.ItemType string
+Parameter.Name string
+Parameter.ParentPath string
+Parameter.Syntax models.Syntax
+Parameter.Type string
+ParameterRef.Ref string
+Pattern.Content string
+Pattern.Value string
+Range.Max string
+Range.MaxInclusive string
+Range.Min string
+Range.MinInclusive string
+Reference.Category string
+Reference.Date string
+Reference.Hyperlink string
+Reference.ID string
+Reference.Name string
+Reference.Organization string
+Reference.Title string
+Size.Max int
+Size.Min int
+StringCons.Enumeration []models.Enumeration
+StringCons.Pattern []models.Pattern
+StringCons.Size *models.Size
+StringType.Enumeration []models.Enumeration
+StringType.Pattern []models.Pattern
+StringType.Size *models.Size
+Syntax.Base64 *models.Base64
+Syntax.Boolean *models.Boolean
+Syntax.DataTypeRef *models.DataTypeRef
+Syntax.DateTime *models.DateTime
+Syntax.Default *models.Default
+Syntax.HexBinary *models.HexBinary
+Syntax.Hidden string
+Syntax.Int *models.Int
+Syntax.List *models.List
+Syntax.Long *models.Long
+Syntax.String *models.StringCons
+Syntax.UnsignedInt *models.UnsignedInt
+Syntax.UnsignedLong *models.UnsignedLong
+UniqueKey.Parameters []models.ParameterRef
+UnsignedInt.Range *models.Range
+UnsignedLong.Range *models.Range
+`
+
+// apiSurface lists the exported fields and methods of the given types, and
+// of the types they reach in the same package, one per line
+func apiSurface(types ...reflect.Type) []string {
+	seen := make(map[reflect.Type]bool)
+	lines := []string{}
+	var visit func(t reflect.Type)
+	visit = func(t reflect.Type) {
+		for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Map {
+			t = t.Elem()
+		}
+		if t.PkgPath() != types[0].PkgPath() || seen[t] {
+			return
+		}
+		seen[t] = true
+
+		if t.Kind() == reflect.Struct {
+			for i := 0; i < t.NumField(); i++ {
+				field := t.Field(i)
+				if field.IsExported() {
+					lines = append(lines, t.Name()+"."+field.Name+" "+field.Type.String())
+					visit(field.Type)
+				}
+			}
+		}
+		ptr := reflect.PointerTo(t)
+		for i := 0; i < ptr.NumMethod(); i++ {
+			method := ptr.Method(i)
+			lines = append(lines, t.Name()+"."+method.Name+"() "+method.Type.String())
+			for j := 0; j < method.Type.NumOut(); j++ {
+				visit(method.Type.Out(j))
+			}
+		}
+	}
+	for _, t := range types {
+		visit(t)
+	}
+	sort.Strings(lines)
+	return lines
+}
+
+func TestModelAPICompatibility(t *testing.T) {
+	surface := make(map[string]bool)
+	for _, line := range apiSurface(
+		reflect.TypeOf(Document{}), reflect.TypeOf(Import{}), reflect.TypeOf(ImportItem{}),
+		reflect.TypeOf(Model{}), reflect.TypeOf(Object{}), reflect.TypeOf(Parameter{}),
+		reflect.TypeOf(Syntax{}), reflect.TypeOf(DataType{}), reflect.TypeOf(Node{}),
+	) {
+		surface[line] = true
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(modelAPI), "\n") {
+		if !surface[line] {
+			t.Errorf("Exported API removed or changed: %s", line)
+		}
+	}
+}
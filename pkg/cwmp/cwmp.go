@@ -0,0 +1,147 @@
+// Package cwmp is the public Go API of cwmp-codegen. It parses CWMP (TR-069)
+// and USP (TR-369) data model documents and runs the code generators of the
+// command line tool, so build tools can embed code generation instead of
+// running the CLI:
+//
+//	model, err := cwmp.Parse(file, cwmp.Options{Model: "InternetGatewayDevice:1.0"})
+//	if err != nil {
+//		return err
+//	}
+//	gen, _ := cwmp.Lookup("golang")
+//	files, err := gen.Generate(model, cwmp.DirFS("messages"))
+//
+// # Compatibility
+//
+// The package follows semantic versioning, reported by Version. Within a
+// major version, exported identifiers are only added, never removed or
+// changed, and registered generator names keep producing the same kind of
+// output. Tools requiring a feature can check for it with Compatible.
+//
+// The data model types, such as Model and Object, are aliases of types in
+// internal/models. The promise covers their exported fields and methods as
+// seen through this package. The rest of internal/ carries no such promise.
+package cwmp
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/Niceblueman/cwmp-codegen/internal/models"
+	"github.com/Niceblueman/cwmp-codegen/internal/parser"
+)
+
+// Version is the semantic version of the package API
+const Version = "1.1.0"
+
+// Data model types. Models answer path queries with Lookup, Children,
+// ParametersOf, Ancestors, Walk and Match.
+type (
+	Document   = models.Document
+	Import     = models.Import
+	ImportItem = models.ImportItem
+	Model      = models.DataModel
+	Object     = models.Object
+	Parameter  = models.Parameter
+	Syntax     = models.Syntax
+	DataType   = models.DataType
+	Node       = models.Node
+)
+
+// SkipChildren is returned by a Model.Walk visitor to skip the nodes below
+// an object
+var SkipChildren = models.SkipChildren
+
+// MatchPath reports whether a path matches a pattern in which * matches any
+// segment and {i} matches {i} or an instance number
+func MatchPath(pattern, path string) bool {
+	return models.MatchPath(pattern, path)
+}
+
+// SchemaPath replaces the instance numbers of a path with {i}
+func SchemaPath(path string) string {
+	return models.SchemaPath(path)
+}
+
+// Options configures Parse and ParseDocument
+type Options struct {
+	MaxSize int64  // Maximum size of the document in bytes, 0 for no limit
+	Model   string // Name of the model returned by Parse, such as InternetGatewayDevice:1.0; the first model when empty
+}
+
+// Parse reads a data model document and returns the model selected by
+// options.Model
+func Parse(r io.Reader, options Options) (*Model, error) {
+	document, err := ParseDocument(r, options)
+	if err != nil {
+		return nil, err
+	}
+	if options.Model != "" {
+		model, ok := document.Model(options.Model)
+		if !ok {
+			return nil, fmt.Errorf("model %s not found in the document", options.Model)
+		}
+		return model, nil
+	}
+	if len(document.Models) == 0 {
+		return nil, errors.New("no models found in the document")
+	}
+	return &document.Models[0], nil
+}
+
+// ParseDocument reads a data model document with all of its models,
+// dataTypes, bibliography and imports
+func ParseDocument(r io.Reader, options Options) (*Document, error) {
+	if options.MaxSize > 0 {
+		data, err := io.ReadAll(io.LimitReader(r, options.MaxSize+1))
+		if err != nil {
+			return nil, err
+		}
+		if int64(len(data)) > options.MaxSize {
+			return nil, fmt.Errorf("document exceeds %d bytes", options.MaxSize)
+		}
+		r = bytes.NewReader(data)
+	}
+	return parser.Parse(r)
+}
+
+// Compatible reports whether the package satisfies a required version, such
+// as "1.2" or "1.2.0": the major versions must match and the package's minor
+// and patch versions must not be older
+func Compatible(required string) bool {
+	want, ok := parseVersion(required)
+	if !ok {
+		return false
+	}
+	have, _ := parseVersion(Version)
+	if have[0] != want[0] {
+		return false
+	}
+	for i := 1; i < 3; i++ {
+		if have[i] != want[i] {
+			return have[i] > want[i]
+		}
+	}
+	return true
+}
+
+// parseVersion parses a major[.minor[.patch]] version, with an optional v
+// prefix
+func parseVersion(version string) ([3]int, bool) {
+	var parts [3]int
+	fields := strings.Split(strings.TrimPrefix(version, "v"), ".")
+	if len(fields) > 3 {
+		return parts, false
+	}
+	for i, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 0 {
+			return parts, false
+		}
+		parts[i] = n
+	}
+	return parts, true
+}
@@ -0,0 +1,93 @@
+package cwmp
+
+import (
+	"strings"
+	"testing"
+)
+
+const testDocument = `<?xml version="1.0" encoding="UTF-8"?>
+<document>
+  <model name="Device:2.16">
+    <object name="Device." access="readOnly" minEntries="1" maxEntries="1">
+      <parameter name="RootDataModelVersion" access="readOnly"><syntax><string/></syntax></parameter>
+    </object>
+    <object name="Device.Hosts.Host.{i}." access="readOnly" minEntries="0" maxEntries="unbounded">
+      <parameter name="IPAddress" access="readOnly"><syntax><string/></syntax></parameter>
+      <parameter name="Active" access="readOnly"><syntax><boolean/></syntax></parameter>
+    </object>
+  </model>
+</document>`
+
+func TestParse(t *testing.T) {
+	model, err := Parse(strings.NewReader(testDocument), Options{})
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+	if model.Name != "Device:2.16" || len(model.Objects) != 2 {
+		t.Fatalf("Unexpected model %s with %d objects", model.Name, len(model.Objects))
+	}
+
+	node, ok := model.Lookup("Device.Hosts.Host.1.Active")
+	if !ok || node.Parameter.Type != "boolean" {
+		t.Errorf("Expected Lookup to find the Active parameter, got %+v", node)
+	}
+	if matches := model.Match("Device.Hosts.Host.{i}.*"); len(matches) != 2 {
+		t.Errorf("Expected 2 Host parameters, got %d", len(matches))
+	}
+	if !MatchPath("Device.*.Host.{i}.", "Device.Hosts.Host.3.") || SchemaPath("Device.Hosts.Host.3.") != "Device.Hosts.Host.{i}." {
+		t.Error("Expected the path helpers to handle instance numbers")
+	}
+}
+
+func TestParseOptions(t *testing.T) {
+	if _, err := Parse(strings.NewReader(testDocument), Options{MaxSize: 100}); err == nil || !strings.Contains(err.Error(), "exceeds 100 bytes") {
+		t.Errorf("Expected a document over MaxSize to fail, got %v", err)
+	}
+	if _, err := Parse(strings.NewReader(testDocument), Options{MaxSize: int64(len(testDocument))}); err != nil {
+		t.Errorf("Expected a document of exactly MaxSize to parse, got %v", err)
+	}
+	if _, err := Parse(strings.NewReader("<document></document>"), Options{}); err == nil {
+		t.Error("Expected a document without models to fail")
+	}
+}
+
+func TestParseDocument(t *testing.T) {
+	multi := strings.Replace(testDocument, "</document>", `  <model name="InternetGatewayDevice:1.0">
+    <object name="InternetGatewayDevice." access="readOnly" minEntries="1" maxEntries="1"/>
+  </model>
+</document>`, 1)
+
+	document, err := ParseDocument(strings.NewReader(multi), Options{})
+	if err != nil {
+		t.Fatalf("ParseDocument returned error: %v", err)
+	}
+	if len(document.Models) != 2 {
+		t.Fatalf("Expected 2 models, got %d", len(document.Models))
+	}
+
+	model, err := Parse(strings.NewReader(multi), Options{Model: "InternetGatewayDevice:1.0"})
+	if err != nil || model.Name != "InternetGatewayDevice:1.0" {
+		t.Errorf("Expected Parse to select the model by name, got %v", err)
+	}
+	if _, err := Parse(strings.NewReader(multi), Options{Model: "Device:2.0"}); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Expected an unknown model to fail, got %v", err)
+	}
+}
+
+func TestCompatible(t *testing.T) {
+	for required, expected := range map[string]bool{
+		"1":      true,
+		"1.0":    true,
+		"v1.0.0": true,
+		"1.1":    true,
+		"1.2":    false,
+		"0.9":    false,
+		"2.0":    false,
+		"1.x":    false,
+		"":       false,
+	} {
+		if Compatible(required) != expected {
+			t.Errorf("Expected Compatible(%q) to be %v with version %s", required, expected, Version)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package cwmp
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// FS is the file system generated files are written to. Names are
+// slash-separated paths relative to the root of the output.
+type FS interface {
+	WriteFile(name string, data []byte) error
+}
+
+// ReadFS is an FS generators can also read their previous output from, such
+// as the field number lock file of the proto generator
+type ReadFS interface {
+	FS
+	ReadFile(name string) ([]byte, error)
+}
+
+// DirFS returns an FS writing below a directory, creating it and its
+// subdirectories as needed
+func DirFS(dir string) ReadFS {
+	return dirFS(dir)
+}
+
+// dirFS is an FS rooted at a directory
+type dirFS string
+
+// WriteFile writes a file below the directory
+func (dir dirFS) WriteFile(name string, data []byte) error {
+	if !fs.ValidPath(name) {
+		return &fs.PathError{Op: "write", Path: name, Err: fs.ErrInvalid}
+	}
+	file := filepath.Join(string(dir), filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(file, data, 0644)
+}
+
+// ReadFile reads a file below the directory
+func (dir dirFS) ReadFile(name string) ([]byte, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrInvalid}
+	}
+	return os.ReadFile(filepath.Join(string(dir), filepath.FromSlash(name)))
+}
+
+// MemFS is an in-memory FS, for generating code without touching the disk.
+// The zero value is empty and ready to use; MemFS is safe for concurrent
+// use.
+type MemFS struct {
+	mu    sync.Mutex
+	files map[string][]byte
+}
+
+// NewMemFS creates an empty MemFS
+func NewMemFS() *MemFS {
+	return &MemFS{files: make(map[string][]byte)}
+}
+
+// WriteFile stores a file
+func (m *MemFS) WriteFile(name string, data []byte) error {
+	if !fs.ValidPath(name) {
+		return &fs.PathError{Op: "write", Path: name, Err: fs.ErrInvalid}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.files == nil {
+		m.files = make(map[string][]byte)
+	}
+	m.files[path.Clean(name)] = append([]byte(nil), data...)
+	return nil
+}
+
+// ReadFile returns a stored file
+func (m *MemFS) ReadFile(name string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.files[path.Clean(name)]
+	if !ok {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrNotExist}
+	}
+	return append([]byte(nil), data...), nil
+}
+
+// Files returns the names of the stored files
+func (m *MemFS) Files() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	names := make([]string, 0, len(m.files))
+	for name := range m.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
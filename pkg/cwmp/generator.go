@@ -0,0 +1,269 @@
+package cwmp
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/Niceblueman/cwmp-codegen/internal/generator"
+)
+
+// Generator generates code for a data model
+type Generator interface {
+	// Generate writes the generated files to fsys and returns their names
+	Generate(model *Model, fsys FS) ([]string, error)
+}
+
+// GeneratorFunc adapts a function to a Generator
+type GeneratorFunc func(model *Model, fsys FS) ([]string, error)
+
+// Generate calls f(model, fsys)
+func (f GeneratorFunc) Generate(model *Model, fsys FS) ([]string, error) {
+	return f(model, fsys)
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Generator)
+)
+
+// Register makes a generator available by name. It panics if the generator
+// is nil or the name is already registered.
+func Register(name string, g Generator) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if g == nil {
+		panic("cwmp: Register generator is nil")
+	}
+	if _, dup := registry[name]; dup {
+		panic("cwmp: Register called twice for generator " + name)
+	}
+	registry[name] = g
+}
+
+// Lookup returns the generator registered under a name
+func Lookup(name string) (Generator, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	g, ok := registry[name]
+	return g, ok
+}
+
+// Generators returns the sorted names of the registered generators
+func Generators() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	for _, entry := range []struct {
+		names     []string
+		generator Generator
+	}{
+		{[]string{"golang", "go"}, Golang(GolangOptions{})},
+		{[]string{"golang-usp"}, Golang(GolangOptions{USP: true})},
+		{[]string{"typescript", "ts"}, TypeScript(TypeScriptOptions{})},
+		{[]string{"typescript-package"}, TypeScript(TypeScriptOptions{Package: true})},
+		{[]string{"cheader", "c"}, CHeader()},
+		{[]string{"rust", "rs"}, Rust()},
+		{[]string{"python", "py"}, Python()},
+		{[]string{"proto", "protobuf"}, Proto(ProtoOptions{})},
+		{[]string{"jsonschema", "json-schema"}, JSONSchema()},
+		{[]string{"openapi"}, OpenAPI()},
+		{[]string{"graphql", "gql"}, GraphQL()},
+		{[]string{"kotlin", "kt"}, Kotlin(JVMOptions{})},
+		{[]string{"java"}, Java(JVMOptions{})},
+	} {
+		for _, name := range entry.names {
+			Register(name, entry.generator)
+		}
+	}
+}
+
+// GolangOptions configures the Go generator
+type GolangOptions struct {
+	USP bool // Also generate USP (TR-369) arguments, supported data model metadata and message bindings
+}
+
+// TypeScriptOptions configures the TypeScript generator
+type TypeScriptOptions struct {
+	Package          bool   // Generate an ES module package with one module per top-level object
+	PackageName      string // npm package name, derived from the model name when empty
+	DeclarationsOnly bool   // Emit .d.ts declarations only, implies Package
+}
+
+// ProtoOptions configures the Protocol Buffers generator
+type ProtoOptions struct {
+	Package   string // proto package, derived from the model name when empty
+	GoPackage string // go_package option, omitted when empty
+}
+
+// JVMOptions configures the Kotlin and Java generators
+type JVMOptions struct {
+	Package string // Kotlin or Java package, derived from the model name when empty
+}
+
+// Golang returns the Go generator
+func Golang(options GolangOptions) Generator {
+	if options.USP {
+		return dirGenerator{generate: generator.GenerateGolangUSP}
+	}
+	return dirGenerator{generate: generator.GenerateGolang}
+}
+
+// TypeScript returns the TypeScript generator
+func TypeScript(options TypeScriptOptions) Generator {
+	if !options.Package && !options.DeclarationsOnly {
+		return dirGenerator{generate: generator.GenerateTypeScript}
+	}
+	return dirGenerator{generate: func(model *Model, dir string) ([]string, error) {
+		return generator.GenerateTypeScriptPackage(model, dir, generator.TypeScriptOptions{
+			PackageName:      options.PackageName,
+			DeclarationsOnly: options.DeclarationsOnly,
+		})
+	}}
+}
+
+// CHeader returns the C header and descriptor table generator
+func CHeader() Generator {
+	return dirGenerator{generate: generator.GenerateCHeader}
+}
+
+// Rust returns the Rust generator
+func Rust() Generator {
+	return dirGenerator{generate: generator.GenerateRust}
+}
+
+// Python returns the Python package generator
+func Python() Generator {
+	return dirGenerator{generate: generator.GeneratePython}
+}
+
+// Proto returns the Protocol Buffers schema generator. Field numbers are
+// kept stable through a lock file read back from FSs implementing ReadFS.
+func Proto(options ProtoOptions) Generator {
+	return dirGenerator{
+		generate: func(model *Model, dir string) ([]string, error) {
+			return generator.GenerateProto(model, dir, generator.ProtoOptions{
+				Package:   options.Package,
+				GoPackage: options.GoPackage,
+			})
+		},
+		previous: func(model *Model) []string {
+			return []string{generator.ProtoLockFile(model)}
+		},
+	}
+}
+
+// JSONSchema returns the JSON Schema generator
+func JSONSchema() Generator {
+	return dirGenerator{generate: generator.GenerateJSONSchema}
+}
+
+// OpenAPI returns the OpenAPI document generator
+func OpenAPI() Generator {
+	return dirGenerator{generate: generator.GenerateOpenAPI}
+}
+
+// GraphQL returns the GraphQL schema generator
+func GraphQL() Generator {
+	return dirGenerator{generate: generator.GenerateGraphQL}
+}
+
+// Kotlin returns the Kotlin generator
+func Kotlin(options JVMOptions) Generator {
+	return dirGenerator{generate: func(model *Model, dir string) ([]string, error) {
+		return generator.GenerateKotlin(model, dir, generator.KotlinOptions{Package: options.Package})
+	}}
+}
+
+// Java returns the Java records generator
+func Java(options JVMOptions) Generator {
+	return dirGenerator{generate: func(model *Model, dir string) ([]string, error) {
+		return generator.GenerateJava(model, dir, generator.KotlinOptions{Package: options.Package})
+	}}
+}
+
+// dirGenerator adapts a generator writing to a directory. Output for a
+// DirFS is written in place; for other FSs it is generated in a temporary
+// directory and copied.
+type dirGenerator struct {
+	generate func(model *Model, dir string) ([]string, error)
+	previous func(model *Model) []string // Files the generator reads back, if any
+}
+
+// Generate runs the generator and writes its files to fsys
+func (g dirGenerator) Generate(model *Model, fsys FS) ([]string, error) {
+	if dir, ok := fsys.(dirFS); ok {
+		if err := os.MkdirAll(string(dir), 0755); err != nil {
+			return nil, err
+		}
+		return slashNames(g.generate(model, string(dir)))
+	}
+
+	tmp, err := os.MkdirTemp("", "cwmp-codegen-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	if reader, ok := fsys.(ReadFS); ok && g.previous != nil {
+		for _, name := range g.previous(model) {
+			data, err := reader.ReadFile(name)
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			if err := dirFS(tmp).WriteFile(name, data); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	files, err := slashNames(g.generate(model, tmp))
+	if err != nil {
+		return nil, err
+	}
+	err = filepath.WalkDir(tmp, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		name, err := filepath.Rel(tmp, file)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		if err := fsys.WriteFile(filepath.ToSlash(name), data); err != nil {
+			return fmt.Errorf("writing %s: %w", name, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// slashNames converts the file names returned by a generator to
+// slash-separated paths
+func slashNames(files []string, err error) ([]string, error) {
+	for i, file := range files {
+		files[i] = filepath.ToSlash(file)
+	}
+	return files, err
+}
@@ -0,0 +1,121 @@
+package cwmp
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerators(t *testing.T) {
+	names := Generators()
+	for _, name := range []string{"golang", "go", "golang-usp", "typescript", "typescript-package", "cheader", "rust", "python", "proto", "jsonschema", "openapi", "graphql", "kotlin", "java"} {
+		if _, ok := Lookup(name); !ok {
+			t.Errorf("Expected generator %s to be registered, got %v", name, names)
+		}
+	}
+	if _, ok := Lookup("cobol"); ok {
+		t.Error("Expected an unknown generator not to be found")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected registering a name twice to panic")
+		}
+	}()
+	Register("golang", Golang(GolangOptions{}))
+}
+
+func TestGenerateMemFS(t *testing.T) {
+	model, err := Parse(strings.NewReader(testDocument), Options{})
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+
+	for _, name := range Generators() {
+		fsys := &MemFS{}
+		gen, _ := Lookup(name)
+		files, err := gen.Generate(model, fsys)
+		if err != nil {
+			t.Errorf("Generator %s returned error: %v", name, err)
+			continue
+		}
+		if len(files) == 0 {
+			t.Errorf("Expected generator %s to report its files", name)
+		}
+		for _, file := range files {
+			if _, err := fsys.ReadFile(file); err != nil {
+				t.Errorf("Expected generator %s to write %s: %v", name, file, err)
+			}
+		}
+	}
+
+	fsys := &MemFS{}
+	gen, _ := Lookup("golang")
+	if _, err := gen.Generate(model, fsys); err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+	content, err := fsys.ReadFile("Device_Hosts_Host_Instance.go")
+	if err != nil {
+		t.Fatalf("Expected the Host struct, got %v", fsys.Files())
+	}
+	if !strings.Contains(string(content), "package messages") {
+		t.Error("Expected generated Go code")
+	}
+}
+
+func TestGenerateProtoLock(t *testing.T) {
+	model, err := Parse(strings.NewReader(testDocument), Options{})
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+
+	fsys := &MemFS{}
+	lock := `{"messages":{"DeviceHostsHostInstance":{"numbers":{"instance_number":1,"active":7,"ip_address":3}}},"enums":{}}`
+	fsys.WriteFile("device_2_16.proto.lock", []byte(lock))
+
+	if _, err := Proto(ProtoOptions{}).Generate(model, fsys); err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+	content, err := fsys.ReadFile("device_2_16.proto")
+	if err != nil {
+		t.Fatalf("Expected the proto schema, got %v", fsys.Files())
+	}
+	if !strings.Contains(string(content), "bool active = 7;") {
+		t.Errorf("Expected field numbers from the lock file, got\n%s", content)
+	}
+}
+
+func TestGenerateDirFS(t *testing.T) {
+	model, err := Parse(strings.NewReader(testDocument), Options{})
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+
+	dir := filepath.Join(t.TempDir(), "out")
+	files, err := Python().Generate(model, DirFS(dir))
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+	for _, file := range files {
+		if strings.Contains(file, `\`) {
+			t.Errorf("Expected slash-separated names, got %s", file)
+		}
+		if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(file))); err != nil {
+			t.Errorf("Expected %s to be written: %v", file, err)
+		}
+	}
+
+	custom := GeneratorFunc(func(model *Model, fsys FS) ([]string, error) {
+		return []string{"paths.txt"}, fsys.WriteFile("paths.txt", []byte(model.Name))
+	})
+	if _, err := custom.Generate(model, DirFS(dir)); err != nil {
+		t.Fatalf("GeneratorFunc returned error: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "paths.txt")); string(data) != "Device:2.16" {
+		t.Errorf("Expected the custom generator's output, got %q", data)
+	}
+	if err := DirFS(dir).WriteFile("../escape.txt", nil); err == nil {
+		t.Error("Expected a path outside the directory to be rejected")
+	}
+}